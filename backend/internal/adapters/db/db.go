@@ -19,17 +19,20 @@ import (
 	"gorm.io/gorm/logger"
 )
 
-// InitializeDatabase configures and returns a GORM DB instance.
-func InitializeDatabase() (*gorm.DB, error) {
-	dsn := fmt.Sprintf("host=%s user=%s password=%s dbname=%s port=%s sslmode=disable TimeZone=UTC",
+// DSNFromEnv builds the PostgreSQL connection string from the DB_* environment variables.
+func DSNFromEnv() string {
+	return fmt.Sprintf("host=%s user=%s password=%s dbname=%s port=%s sslmode=disable TimeZone=UTC",
 		os.Getenv("DB_HOST"),
 		os.Getenv("DB_USER"),
 		os.Getenv("DB_PASSWORD"),
 		os.Getenv("DB_NAME"),
 		os.Getenv("DB_PORT"),
 	)
+}
 
-	db, err := gorm.Open(postgres.Open(dsn), &gorm.Config{
+// InitializeDatabase configures and returns a GORM DB instance.
+func InitializeDatabase() (*gorm.DB, error) {
+	db, err := gorm.Open(postgres.Open(DSNFromEnv()), &gorm.Config{
 		Logger: logger.Default.LogMode(logger.Silent),
 	})
 	if err != nil {
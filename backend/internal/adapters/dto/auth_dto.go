@@ -0,0 +1,27 @@
+/*
+ * file: auth_dto.go
+ * package: dto
+ * description:
+ *     Provides the request and response bodies of the authentication endpoints.
+ */
+package dto
+
+import (
+	"time"
+)
+
+type CredentialsRequest struct {
+	PlayerName string `json:"playerName"`
+	Password   string `json:"password"`
+}
+
+type GuestRequest struct {
+	PlayerName string `json:"playerName"`
+}
+
+type SessionResponse struct {
+	Token     string    `json:"token"`
+	ExpiresAt time.Time `json:"expiresAt"`
+	Guest     bool      `json:"guest"`
+	Player    *Player   `json:"player"`
+}
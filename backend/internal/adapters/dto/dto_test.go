@@ -0,0 +1,173 @@
+/*
+ * file: dto_test.go
+ * package: dto
+ * description:
+ *     Golden-file tests pinning the JSON shape of the public API. A failing test means
+ *     a response changed shape: if the change is intended, rerun with -update and
+ *     review the diff of testdata/ like any other API change.
+ */
+package dto
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"gorm.io/gorm"
+
+	"github.com/juan10024/tictactoe-test/internal/core/domain"
+	"github.com/juan10024/tictactoe-test/internal/core/services"
+)
+
+var update = flag.Bool("update", false, "rewrite the golden files in testdata/")
+
+var fixedTime = time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+
+func uintPtr(v uint) *uint { return &v }
+
+func intPtr(v int) *int { return &v }
+
+func alice() domain.Player {
+	return domain.Player{
+		ID: 1, Name: "alice", Wins: 3, Draws: 1, Losses: 2,
+		PasswordHash: "secret-hash", Rating: 1612.5, RatingDeviation: 80, RatingVolatility: 0.06,
+		Version: 7, CreatedAt: fixedTime, UpdatedAt: fixedTime,
+	}
+}
+
+func bob() domain.Player {
+	return domain.Player{
+		ID: 2, Name: "bob", Wins: 2, Draws: 1, Losses: 3,
+		Rating: 1490, RatingDeviation: 120, RatingVolatility: 0.06,
+		Version: 4, CreatedAt: fixedTime, UpdatedAt: fixedTime,
+	}
+}
+
+func finishedGame() domain.Game {
+	return domain.Game{
+		Model:     gorm.Model{ID: 10, CreatedAt: fixedTime, UpdatedAt: fixedTime, DeletedAt: gorm.DeletedAt{Time: fixedTime, Valid: true}},
+		RoomID:    "room-1",
+		PlayerXID: uintPtr(1), PlayerX: alice(),
+		PlayerOID: uintPtr(2), PlayerO: bob(),
+		WinnerID: uintPtr(1), Winner: alice(),
+		Ruleset: "classic", Status: "finished", Board: "XXXOO    ",
+		BoardWidth: 3, BoardHeight: 3, WinLength: 3, CurrentTurn: "O",
+		EndReason:    domain.EndReasonWin,
+		ResumeTokenX: "token-x", ResumeTokenO: "token-o",
+		Version: 6,
+	}
+}
+
+func waitingGame() domain.Game {
+	return domain.Game{
+		Model:     gorm.Model{ID: 11, CreatedAt: fixedTime, UpdatedAt: fixedTime},
+		RoomID:    "room-2",
+		PlayerXID: uintPtr(1), PlayerX: alice(),
+		Ruleset: "ultimate", Status: "waiting", Board: "                                                                                 ",
+		BoardWidth: 9, BoardHeight: 9, WinLength: 3, CurrentTurn: "X",
+		ActiveBoard: intPtr(4), SubBoardWinners: "         ",
+		BaseTime: 180, Increment: 2, TimeLeftX: 180000, TimeLeftO: 180000,
+		ResumeTokenX: "token-x",
+		Version:      1,
+	}
+}
+
+/*
+ * assertGolden compares the indented JSON encoding of v with testdata/<name>.golden,
+ * or rewrites the file when the tests run with -update.
+ */
+func assertGolden(t *testing.T, name string, v interface{}) {
+	t.Helper()
+	got, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		t.Fatalf("marshal %s: %v", name, err)
+	}
+	got = append(got, '\n')
+
+	path := filepath.Join("testdata", name+".golden")
+	if *update {
+		if err := os.WriteFile(path, got, 0o644); err != nil {
+			t.Fatalf("write %s: %v", path, err)
+		}
+		return
+	}
+	want, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("read %s (run with -update to create it): %v", path, err)
+	}
+	if !bytes.Equal(got, want) {
+		t.Errorf("%s changed shape.\n--- got ---\n%s\n--- want ---\n%s", name, got, want)
+	}
+}
+
+func TestGameJSON(t *testing.T) {
+	finished := finishedGame()
+	waiting := waitingGame()
+	assertGolden(t, "game_finished", NewGame(&finished))
+	assertGolden(t, "game_waiting", NewGame(&waiting))
+}
+
+func TestPlayerJSON(t *testing.T) {
+	p := alice()
+	assertGolden(t, "player", NewPlayer(&p))
+
+	if NewPlayer(nil) != nil || NewPlayer(&domain.Player{}) != nil {
+		t.Error("an empty seat must map to a nil player")
+	}
+}
+
+func TestGameMovesJSON(t *testing.T) {
+	moves := []domain.GameMove{
+		{Model: gorm.Model{ID: 100, CreatedAt: fixedTime}, GameID: 10, PlayerID: 1, Player: alice(), MoveNumber: 1, Position: 0, Symbol: "X"},
+		{Model: gorm.Model{ID: 101, CreatedAt: fixedTime.Add(time.Second)}, GameID: 10, PlayerID: 2, MoveNumber: 2, Position: 4, Symbol: "O"},
+	}
+	assertGolden(t, "game_moves", NewGameMoves(10, moves))
+	assertGolden(t, "game_moves_empty", NewGameMoves(10, nil))
+}
+
+func TestGameReplayJSON(t *testing.T) {
+	game := finishedGame()
+	frames := []services.ReplayFrame{
+		{MoveNumber: 0, Board: "         "},
+		{MoveNumber: 1, Position: intPtr(0), Symbol: "X", Board: "X        "},
+	}
+	assertGolden(t, "game_replay", NewGameReplay(&game, NewReplayFrames(frames)))
+}
+
+func TestLobbyRoomsJSON(t *testing.T) {
+	waiting := waitingGame()
+	finished := finishedGame()
+	rooms := []services.LobbyRoom{
+		{Game: &waiting, Privacy: domain.RoomPassword},
+		{Game: &finished, Privacy: domain.RoomPublic, Spectators: 3},
+	}
+	assertGolden(t, "lobby_rooms", NewLobbyRooms(rooms))
+	assertGolden(t, "lobby_rooms_empty", NewLobbyRooms(nil))
+}
+
+func TestRankingJSON(t *testing.T) {
+	assertGolden(t, "ranking", NewRanking([]domain.Player{alice(), bob()}, 5))
+	assertGolden(t, "ranking_empty", NewRanking(nil, 5))
+}
+
+func TestRatingHistoryJSON(t *testing.T) {
+	history := []domain.RatingHistory{
+		{ID: 1, PlayerID: 1, GameID: uintPtr(10), Rating: 1612.5, RatingDeviation: 80, RatingVolatility: 0.06, CreatedAt: fixedTime},
+	}
+	assertGolden(t, "rating_history", NewRatingHistory("alice", history))
+}
+
+func TestGameHistoryJSON(t *testing.T) {
+	series := []domain.SeriesResult{{
+		ID: 1, RoomID: "room-1", BestOf: 3,
+		PlayerOneID: uintPtr(1), PlayerOne: alice(),
+		PlayerTwoID: uintPtr(2), PlayerTwo: bob(),
+		PlayerOneWins: 2, PlayerTwoWins: 0, Draws: 1,
+		WinnerID: uintPtr(1), CreatedAt: fixedTime,
+	}}
+	assertGolden(t, "game_history", NewGameHistory("room-1", []domain.Game{finishedGame()}, series))
+}
@@ -0,0 +1,180 @@
+/*
+ * file: game_dto.go
+ * package: dto
+ * description:
+ *     Provides the public JSON shape of players, games, moves and replays, and the
+ *     mappers that build them from the domain models, so storage fields never reach clients.
+ *     Players and games have the shape the socket protocol defines, so both APIs agree.
+ */
+package dto
+
+import (
+	"time"
+
+	"github.com/juan10024/tictactoe-test/internal/core/domain"
+	"github.com/juan10024/tictactoe-test/internal/core/protocol"
+	"github.com/juan10024/tictactoe-test/internal/core/services"
+)
+
+// Player is the public view of a player, shared with the socket protocol.
+type Player = protocol.Player
+
+// Game is the public view of a game, shared with the socket protocol.
+type Game = protocol.Game
+
+/*
+ * Move is the public view of a recorded move.
+ *
+ * Fields:
+ *   - MoveNumber (int): 1-based number of the move in the game.
+ *   - Position (int): The cell played, as encoded by the game's ruleset.
+ *   - Symbol (string): The symbol placed, "X" or "O".
+ *   - PlayerID (uint): The player who moved.
+ *   - Player (*Player): That player, when it was loaded.
+ *   - CreatedAt (time.Time): When the move was played.
+ */
+type Move struct {
+	MoveNumber int       `json:"moveNumber"`
+	Position   int       `json:"position"`
+	Symbol     string    `json:"symbol"`
+	PlayerID   uint      `json:"playerID"`
+	Player     *Player   `json:"player,omitempty"`
+	CreatedAt  time.Time `json:"createdAt"`
+}
+
+// GameMovesResponse is the body of GET /api/games/{id}/moves.
+type GameMovesResponse struct {
+	GameID uint   `json:"gameId"`
+	Moves  []Move `json:"moves"`
+}
+
+// ReplayFrame is the board after one ply of a replay.
+type ReplayFrame struct {
+	MoveNumber  int    `json:"moveNumber"`
+	Position    *int   `json:"position"`
+	Symbol      string `json:"symbol,omitempty"`
+	Board       string `json:"board"`
+	ActiveBoard *int   `json:"activeBoard"`
+}
+
+// GameReplayResponse is the body of GET /api/games/{id}/replay.
+type GameReplayResponse struct {
+	Game   *Game         `json:"game"`
+	Frames []ReplayFrame `json:"frames"`
+}
+
+/*
+ * NewPlayer maps a domain player to its public view; see protocol.NewPlayer.
+ */
+func NewPlayer(p *domain.Player) *Player {
+	return protocol.NewPlayer(p)
+}
+
+/*
+ * NewPlayers maps a list of domain players, keeping their order.
+ *
+ * Parameters:
+ *   - players ([]domain.Player): The players.
+ *
+ * Returns:
+ *   - []Player: The public views; empty, never nil, so it encodes as [].
+ */
+func NewPlayers(players []domain.Player) []Player {
+	out := make([]Player, 0, len(players))
+	for i := range players {
+		if p := NewPlayer(&players[i]); p != nil {
+			out = append(out, *p)
+		}
+	}
+	return out
+}
+
+/*
+ * NewGame maps a domain game to its public view; see protocol.NewGame.
+ */
+func NewGame(g *domain.Game) *Game {
+	return protocol.NewGame(g)
+}
+
+/*
+ * NewGames maps a list of domain games, keeping their order.
+ *
+ * Parameters:
+ *   - games ([]domain.Game): The games.
+ *
+ * Returns:
+ *   - []Game: The public views; empty, never nil, so it encodes as [].
+ */
+func NewGames(games []domain.Game) []Game {
+	out := make([]Game, 0, len(games))
+	for i := range games {
+		out = append(out, *NewGame(&games[i]))
+	}
+	return out
+}
+
+/*
+ * NewGameMoves builds the moves response of a game.
+ *
+ * Parameters:
+ *   - gameID (uint): The game the moves belong to.
+ *   - moves ([]domain.GameMove): The recorded moves, in order.
+ *
+ * Returns:
+ *   - GameMovesResponse: The response body.
+ */
+func NewGameMoves(gameID uint, moves []domain.GameMove) GameMovesResponse {
+	out := make([]Move, 0, len(moves))
+	for i := range moves {
+		m := &moves[i]
+		out = append(out, Move{
+			MoveNumber: m.MoveNumber,
+			Position:   m.Position,
+			Symbol:     m.Symbol,
+			PlayerID:   m.PlayerID,
+			Player:     NewPlayer(&m.Player),
+			CreatedAt:  m.CreatedAt,
+		})
+	}
+	return GameMovesResponse{GameID: gameID, Moves: out}
+}
+
+/*
+ * NewGameReplay builds the replay response of a game.
+ *
+ * Parameters:
+ *   - game (*domain.Game): The replayed game.
+ *   - frames ([]ReplayFrame): The board after each ply, starting with the empty board.
+ *
+ * Returns:
+ *   - GameReplayResponse: The response body.
+ */
+func NewGameReplay(game *domain.Game, frames []ReplayFrame) GameReplayResponse {
+	if frames == nil {
+		frames = []ReplayFrame{}
+	}
+	return GameReplayResponse{Game: NewGame(game), Frames: frames}
+}
+
+/*
+ * NewReplayFrames maps the frames of a replay, keeping their order.
+ *
+ * Parameters:
+ *   - frames ([]services.ReplayFrame): The board after each ply, starting with the empty board.
+ *
+ * Returns:
+ *   - []ReplayFrame: The public views; empty, never nil, so it encodes as [].
+ */
+func NewReplayFrames(frames []services.ReplayFrame) []ReplayFrame {
+	out := make([]ReplayFrame, 0, len(frames))
+	for _, f := range frames {
+		out = append(out, ReplayFrame{
+			MoveNumber:  f.MoveNumber,
+			Position:    f.Position,
+			Symbol:      f.Symbol,
+			Board:       f.Board,
+			ActiveBoard: f.ActiveBoard,
+		})
+	}
+	return out
+}
@@ -0,0 +1,34 @@
+/*
+ * file: lobby_dto.go
+ * package: dto
+ * description:
+ *     Provides the public JSON shape of the rooms listed in the lobby, and the mapper
+ *     that builds it from the lobby's rooms. The shape is the one the lobby socket
+ *     uses, so both APIs agree.
+ */
+package dto
+
+import (
+	"github.com/juan10024/tictactoe-test/internal/core/protocol"
+	"github.com/juan10024/tictactoe-test/internal/core/services"
+)
+
+// LobbyRoom is the public view of an open room, shared with the lobby socket.
+type LobbyRoom = protocol.LobbyRoom
+
+/*
+ * NewLobbyRooms maps the rooms listed in the lobby, keeping their order.
+ *
+ * Parameters:
+ *   - rooms ([]services.LobbyRoom): The open rooms.
+ *
+ * Returns:
+ *   - []LobbyRoom: The public views; empty, never nil, so it encodes as [].
+ */
+func NewLobbyRooms(rooms []services.LobbyRoom) []LobbyRoom {
+	out := make([]LobbyRoom, 0, len(rooms))
+	for _, room := range rooms {
+		out = append(out, protocol.NewLobbyRoom(room.Game, room.Privacy, room.Spectators))
+	}
+	return out
+}
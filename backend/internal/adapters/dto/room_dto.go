@@ -7,19 +7,44 @@
 package dto
 
 import (
-	"github.com/juan10024/tictactoe-test/internal/core/domain"
+	"time"
 )
 
 type JoinRoomRequest struct {
-	PlayerName string `json:"playerName"`
+	Ruleset   string `json:"ruleset,omitempty"`
+	Width     int    `json:"width,omitempty"`
+	Height    int    `json:"height,omitempty"`
+	WinLength int    `json:"winLength,omitempty"`
+	BotLevel  string `json:"botLevel,omitempty"`
+	BotSymbol string `json:"botSymbol,omitempty"`
+	MoveTime  int    `json:"moveTime,omitempty"`
+	BaseTime  int    `json:"baseTime,omitempty"`
+	Increment int    `json:"increment,omitempty"`
+	Privacy   string `json:"privacy,omitempty"`   // "public", "password" or "invite"; only used when creating the room.
+	Password  string `json:"password,omitempty"`  // The room password to set when creating it, or to enter it.
+	Invite    string `json:"invite,omitempty"`    // An invite to enter a private room.
+	BestOf    int    `json:"bestOf,omitempty"`    // 3, 5 or 7 to play the room as a series; only used when creating the room.
+	SwapSides bool   `json:"swapSides,omitempty"` // Exchange X and O on every rematch; only used when creating the room.
 }
 
 type JoinRoomResponse struct {
-	Error      bool           `json:"error"`
-	Message    string         `json:"message"`
-	Game       *domain.Game   `json:"game,omitempty"`
-	Player     *domain.Player `json:"player,omitempty"`
-	RoomID     string         `json:"roomId,omitempty"`
-	PlayerID   uint           `json:"playerId,omitempty"`
-	PlayerName string         `json:"playerName,omitempty"`
+	Error       bool    `json:"error"`
+	Message     string  `json:"message"`
+	Game        *Game   `json:"game,omitempty"`
+	Player      *Player `json:"player,omitempty"`
+	RoomID      string  `json:"roomId,omitempty"`
+	PlayerID    uint    `json:"playerId,omitempty"`
+	PlayerName  string  `json:"playerName,omitempty"`
+	Seat        string  `json:"seat,omitempty"`
+	ResumeToken string  `json:"resumeToken,omitempty"` // Reclaims the seat when reconnecting to the WebSocket (?resume=).
+}
+
+type CreateInviteRequest struct {
+	TTLMinutes int `json:"ttlMinutes,omitempty"` // Defaults to 60.
+}
+
+type InviteResponse struct {
+	RoomID    string    `json:"roomId"`
+	Invite    string    `json:"invite"`
+	ExpiresAt time.Time `json:"expiresAt"`
 }
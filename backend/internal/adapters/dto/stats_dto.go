@@ -0,0 +1,144 @@
+/*
+ * file: stats_dto.go
+ * package: dto
+ * description:
+ *     Provides the public JSON shape of the statistics endpoints: ranking, rating
+ *     history, general statistics and room history, and the mappers that build them.
+ */
+package dto
+
+import (
+	"time"
+
+	"github.com/juan10024/tictactoe-test/internal/core/domain"
+)
+
+// RankingResponse is the body of GET /api/stats/ranking.
+type RankingResponse struct {
+	Players  []Player `json:"players"`
+	MinGames int      `json:"minGames"`
+}
+
+/*
+ * RatingPoint is a player's rating after one rated game.
+ *
+ * Fields:
+ *   - GameID (*uint): The game that produced the rating, nil for adjustments outside a game.
+ *   - Rating, RatingDeviation, RatingVolatility (float64): The Glicko-2 rating after the game.
+ *   - CreatedAt (time.Time): When the rating was recorded.
+ */
+type RatingPoint struct {
+	GameID           *uint     `json:"gameID"`
+	Rating           float64   `json:"rating"`
+	RatingDeviation  float64   `json:"ratingDeviation"`
+	RatingVolatility float64   `json:"ratingVolatility"`
+	CreatedAt        time.Time `json:"createdAt"`
+}
+
+// RatingHistoryResponse is the body of GET /api/stats/rating-history.
+type RatingHistoryResponse struct {
+	PlayerName string        `json:"playerName"`
+	History    []RatingPoint `json:"history"`
+}
+
+// GeneralStatsResponse is the body of GET /api/stats/general.
+type GeneralStatsResponse struct {
+	TotalGames   int64 `json:"totalGames"`
+	TotalPlayers int64 `json:"totalPlayers"`
+}
+
+/*
+ * SeriesResult is the public view of a finished best-of-N series.
+ *
+ * Fields mirror domain.SeriesResult; the players are nil if they were not loaded.
+ */
+type SeriesResult struct {
+	RoomID        string    `json:"roomID"`
+	BestOf        int       `json:"bestOf"`
+	PlayerOneID   *uint     `json:"playerOneID"`
+	PlayerOne     *Player   `json:"playerOne,omitempty"`
+	PlayerTwoID   *uint     `json:"playerTwoID"`
+	PlayerTwo     *Player   `json:"playerTwo,omitempty"`
+	PlayerOneWins int       `json:"playerOneWins"`
+	PlayerTwoWins int       `json:"playerTwoWins"`
+	Draws         int       `json:"draws"`
+	WinnerID      *uint     `json:"winnerID"`
+	CreatedAt     time.Time `json:"createdAt"`
+}
+
+// GameHistoryResponse is the body of GET /api/rooms/history/{roomId}.
+type GameHistoryResponse struct {
+	RoomID string         `json:"roomId"`
+	Games  []Game         `json:"games"`
+	Series []SeriesResult `json:"series"`
+}
+
+/*
+ * NewRanking builds the ranking response.
+ *
+ * Parameters:
+ *   - players ([]domain.Player): The ranked players, best first.
+ *   - minGames (int): The minimum number of finished games required to be ranked.
+ *
+ * Returns:
+ *   - RankingResponse: The response body.
+ */
+func NewRanking(players []domain.Player, minGames int) RankingResponse {
+	return RankingResponse{Players: NewPlayers(players), MinGames: minGames}
+}
+
+/*
+ * NewRatingHistory builds the rating history response of a player.
+ *
+ * Parameters:
+ *   - playerName (string): The player's name.
+ *   - history ([]domain.RatingHistory): The player's ratings, oldest first.
+ *
+ * Returns:
+ *   - RatingHistoryResponse: The response body.
+ */
+func NewRatingHistory(playerName string, history []domain.RatingHistory) RatingHistoryResponse {
+	points := make([]RatingPoint, 0, len(history))
+	for _, h := range history {
+		points = append(points, RatingPoint{
+			GameID:           h.GameID,
+			Rating:           h.Rating,
+			RatingDeviation:  h.RatingDeviation,
+			RatingVolatility: h.RatingVolatility,
+			CreatedAt:        h.CreatedAt,
+		})
+	}
+	return RatingHistoryResponse{PlayerName: playerName, History: points}
+}
+
+/*
+ * NewGameHistory builds the history response of a room.
+ *
+ * Parameters:
+ *   - roomID (string): The room identifier.
+ *   - games ([]domain.Game): The games played in the room, with their players preloaded.
+ *   - series ([]domain.SeriesResult): The series finished in the room, newest first.
+ *
+ * Returns:
+ *   - GameHistoryResponse: The response body.
+ */
+func NewGameHistory(roomID string, games []domain.Game, series []domain.SeriesResult) GameHistoryResponse {
+	results := make([]SeriesResult, 0, len(series))
+	for i := range series {
+		s := &series[i]
+		results = append(results, SeriesResult{
+			RoomID:        s.RoomID,
+			BestOf:        s.BestOf,
+			PlayerOneID:   s.PlayerOneID,
+			PlayerOne:     NewPlayer(&s.PlayerOne),
+			PlayerTwoID:   s.PlayerTwoID,
+			PlayerTwo:     NewPlayer(&s.PlayerTwo),
+			PlayerOneWins: s.PlayerOneWins,
+			PlayerTwoWins: s.PlayerTwoWins,
+			Draws:         s.Draws,
+			WinnerID:      s.WinnerID,
+			CreatedAt:     s.CreatedAt,
+		})
+	}
+	return GameHistoryResponse{RoomID: roomID, Games: NewGames(games), Series: results}
+}
@@ -0,0 +1,183 @@
+/*
+ * file: auth_handlers.go
+ * package: handlers
+ * description:
+ *     Exposes HTTP endpoints for registration, login and guest sessions, and the
+ *     helper used by other handlers to read the session token of a request.
+ */
+
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"strings"
+
+	"github.com/juan10024/tictactoe-test/internal/adapters/dto"
+	"github.com/juan10024/tictactoe-test/internal/core/services"
+)
+
+/*
+ * AuthHandler handles authentication HTTP requests.
+ *
+ * Fields:
+ *   - authService (*services.AuthService): Service that contains the identity logic.
+ *
+ * Returns:
+ *   - *AuthHandler: A new instance of AuthHandler.
+ */
+type AuthHandler struct {
+	authService *services.AuthService
+}
+
+func NewAuthHandler(s *services.AuthService) *AuthHandler {
+	return &AuthHandler{authService: s}
+}
+
+/*
+ * Register creates a registered account, or claims a name used so far only by a guest.
+ * Claiming a guest name requires that guest's session in the Authorization header.
+ * Route: POST /api/v1/auth/register
+ *
+ * Parameters:
+ *   - w (http.ResponseWriter): The HTTP response writer.
+ *   - r (*http.Request): The HTTP request with a dto.CredentialsRequest body and,
+ *     for a guest name, an "Authorization: Bearer" header.
+ *
+ * Returns:
+ *   - None. Writes a dto.SessionResponse.
+ */
+func (h *AuthHandler) Register(w http.ResponseWriter, r *http.Request) {
+	var req dto.CredentialsRequest
+	if !decodeAuthRequest(w, r, &req) {
+		return
+	}
+
+	session, err := h.authService.Register(req.PlayerName, req.Password, bearerToken(r))
+	h.respondWithSession(w, session, false, err)
+}
+
+/*
+ * Login starts a session for a registered player.
+ * Route: POST /api/v1/auth/login
+ *
+ * Parameters:
+ *   - w (http.ResponseWriter): The HTTP response writer.
+ *   - r (*http.Request): The HTTP request with a dto.CredentialsRequest body.
+ *
+ * Returns:
+ *   - None. Writes a dto.SessionResponse.
+ */
+func (h *AuthHandler) Login(w http.ResponseWriter, r *http.Request) {
+	var req dto.CredentialsRequest
+	if !decodeAuthRequest(w, r, &req) {
+		return
+	}
+
+	session, err := h.authService.Login(req.PlayerName, req.Password)
+	h.respondWithSession(w, session, false, err)
+}
+
+/*
+ * Guest starts a name-only session for a name that is not registered.
+ * Route: POST /api/v1/auth/guest
+ *
+ * Parameters:
+ *   - w (http.ResponseWriter): The HTTP response writer.
+ *   - r (*http.Request): The HTTP request with a dto.GuestRequest body.
+ *
+ * Returns:
+ *   - None. Writes a dto.SessionResponse.
+ */
+func (h *AuthHandler) Guest(w http.ResponseWriter, r *http.Request) {
+	var req dto.GuestRequest
+	if !decodeAuthRequest(w, r, &req) {
+		return
+	}
+
+	session, err := h.authService.Guest(req.PlayerName)
+	h.respondWithSession(w, session, true, err)
+}
+
+/*
+ * respondWithSession writes a session, or maps an authentication error to its status code.
+ *
+ * Parameters:
+ *   - w (http.ResponseWriter): The HTTP response writer.
+ *   - session (*services.Session): The session, when err is nil.
+ *   - guest (bool): Whether the session is a guest session.
+ *   - err (error): The error returned by the service.
+ *
+ * Returns:
+ *   - None.
+ */
+func (h *AuthHandler) respondWithSession(w http.ResponseWriter, session *services.Session, guest bool, err error) {
+	switch {
+	case errors.Is(err, services.ErrInvalidCredentials):
+		respondWithError(w, http.StatusUnauthorized, err.Error())
+	case errors.Is(err, services.ErrNameTaken):
+		respondWithError(w, http.StatusConflict, err.Error())
+	case err != nil:
+		respondWithError(w, http.StatusBadRequest, err.Error())
+	default:
+		respondWithJSON(w, http.StatusOK, dto.SessionResponse{
+			Token:     session.Token,
+			ExpiresAt: session.ExpiresAt,
+			Guest:     guest,
+			Player:    dto.NewPlayer(session.Player),
+		})
+	}
+}
+
+/*
+ * decodeAuthRequest decodes the JSON body of an authentication request.
+ *
+ * Parameters:
+ *   - w (http.ResponseWriter): The HTTP response writer.
+ *   - r (*http.Request): The HTTP request.
+ *   - dst (interface{}): The request body to fill.
+ *
+ * Returns:
+ *   - bool: False if an error response has already been written.
+ */
+func decodeAuthRequest(w http.ResponseWriter, r *http.Request, dst interface{}) bool {
+	if err := json.NewDecoder(r.Body).Decode(dst); err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid request body.")
+		return false
+	}
+	return true
+}
+
+/*
+ * bearerToken extracts the session token from an "Authorization: Bearer" header.
+ *
+ * Parameters:
+ *   - r (*http.Request): The HTTP request.
+ *
+ * Returns:
+ *   - string: The token, or an empty string if the header is missing.
+ */
+func bearerToken(r *http.Request) string {
+	token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+	if !ok {
+		return ""
+	}
+	return strings.TrimSpace(token)
+}
+
+/*
+ * logAuthFailure logs unexpected errors raised while authenticating a request.
+ *
+ * Parameters:
+ *   - err (error): The error returned by AuthService.Authenticate.
+ *
+ * Returns:
+ *   - None.
+ */
+func logAuthFailure(err error) {
+	if !errors.Is(err, services.ErrUnauthorized) {
+		log.Printf("ERROR: Could not authenticate request: %v", err)
+	}
+}
@@ -0,0 +1,68 @@
+/*
+ * file: lobby_handlers.go
+ * package: handlers
+ * description:
+ *     Exposes the public lobby: the list of open rooms and the lobby WebSocket.
+ */
+
+package handlers
+
+import (
+	"log"
+	"net/http"
+
+	"github.com/juan10024/tictactoe-test/internal/adapters/dto"
+	"github.com/juan10024/tictactoe-test/internal/core/services"
+)
+
+/*
+ * LobbyHandler serves the public lobby.
+ *
+ * Fields:
+ *   - lobby (*services.Lobby): The lobby listing open rooms and streaming their events.
+ *
+ * Returns:
+ *   - *LobbyHandler: A new instance of LobbyHandler.
+ */
+type LobbyHandler struct {
+	lobby *services.Lobby
+}
+
+func NewLobbyHandler(l *services.Lobby) *LobbyHandler {
+	return &LobbyHandler{lobby: l}
+}
+
+/*
+ * ListRooms returns the rooms waiting for an opponent and the games in progress as JSON.
+ *
+ * Parameters:
+ *   - w (http.ResponseWriter): The HTTP response writer.
+ *   - r (*http.Request): The HTTP request.
+ *
+ * Returns:
+ *   - None. Writes a list of dto.LobbyRoom.
+ */
+func (h *LobbyHandler) ListRooms(w http.ResponseWriter, r *http.Request) {
+	rooms, err := h.lobby.Rooms()
+	if err != nil {
+		log.Printf("ERROR: Failed to list open rooms: %v", err)
+		respondWithError(w, http.StatusInternalServerError, "Could not retrieve open rooms.")
+		return
+	}
+	respondWithJSON(w, http.StatusOK, dto.NewLobbyRooms(rooms))
+}
+
+/*
+ * HandleConnection upgrades the request to the lobby WebSocket. The lobby is public,
+ * so no session token is required.
+ *
+ * Parameters:
+ *   - w (http.ResponseWriter): The HTTP response writer.
+ *   - r (*http.Request): The HTTP request.
+ *
+ * Returns:
+ *   - None.
+ */
+func (h *LobbyHandler) HandleConnection(w http.ResponseWriter, r *http.Request) {
+	services.ServeLobby(h.lobby, w, r)
+}
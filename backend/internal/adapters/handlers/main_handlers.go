@@ -10,10 +10,15 @@ package handlers
 
 import (
 	"encoding/json"
+	"errors"
+	"fmt"
 	"log"
 	"net/http"
-	"strings"
+	"net/url"
+	"strconv"
 
+	"github.com/juan10024/tictactoe-test/internal/adapters/dto"
+	"github.com/juan10024/tictactoe-test/internal/core/domain"
 	"github.com/juan10024/tictactoe-test/internal/core/services"
 )
 
@@ -36,6 +41,99 @@ func NewGameHandler(s *services.GameService, h *services.Hub) *GameHandler {
 	return &GameHandler{gameService: s, hub: h}
 }
 
+/*
+ * GetGameMoves returns the recorded moves of a game as JSON.
+ * Route: GET /api/v1/games/{gameId}/moves
+ *
+ * Parameters:
+ *   - w (http.ResponseWriter): The HTTP response writer.
+ *   - r (*http.Request): The HTTP request.
+ *
+ * Returns:
+ *   - None. Writes a dto.GameMovesResponse.
+ */
+func (h *GameHandler) GetGameMoves(w http.ResponseWriter, r *http.Request) {
+	gameID, ok := gameIDParam(w, r)
+	if !ok {
+		return
+	}
+
+	moves, err := h.gameService.GetGameMoves(gameID)
+	if !gameResourceFound(w, gameID, "moves", err) {
+		return
+	}
+	respondWithJSON(w, http.StatusOK, dto.NewGameMoves(moves.GameID, moves.Moves))
+}
+
+/*
+ * GetGameReplay returns the board after each ply of a game as JSON.
+ * Route: GET /api/v1/games/{gameId}/replay
+ *
+ * Parameters:
+ *   - w (http.ResponseWriter): The HTTP response writer.
+ *   - r (*http.Request): The HTTP request.
+ *
+ * Returns:
+ *   - None. Writes a dto.GameReplayResponse.
+ */
+func (h *GameHandler) GetGameReplay(w http.ResponseWriter, r *http.Request) {
+	gameID, ok := gameIDParam(w, r)
+	if !ok {
+		return
+	}
+
+	replay, err := h.gameService.GetGameReplay(gameID)
+	if !gameResourceFound(w, gameID, "replay", err) {
+		return
+	}
+	respondWithJSON(w, http.StatusOK, dto.NewGameReplay(replay.Game, dto.NewReplayFrames(replay.Frames)))
+}
+
+/*
+ * gameIDParam reads the {gameId} path parameter.
+ *
+ * Parameters:
+ *   - w (http.ResponseWriter): The HTTP response writer.
+ *   - r (*http.Request): The HTTP request.
+ *
+ * Returns:
+ *   - uint: The game ID.
+ *   - bool: False if an error response has already been written.
+ */
+func gameIDParam(w http.ResponseWriter, r *http.Request) (uint, bool) {
+	id, err := strconv.ParseUint(r.PathValue("gameId"), 10, 64)
+	if err != nil || id == 0 {
+		respondWithError(w, http.StatusBadRequest, "Game ID must be a positive integer.")
+		return 0, false
+	}
+	return uint(id), true
+}
+
+/*
+ * gameResourceFound writes the error response of a failed per-game lookup.
+ *
+ * Parameters:
+ *   - w (http.ResponseWriter): The HTTP response writer.
+ *   - gameID (uint): The game looked up.
+ *   - resource (string): What was looked up, "moves" or "replay".
+ *   - err (error): The lookup error, nil on success.
+ *
+ * Returns:
+ *   - bool: True if err is nil; otherwise an error response has been written.
+ */
+func gameResourceFound(w http.ResponseWriter, gameID uint, resource string, err error) bool {
+	if errors.Is(err, services.ErrGameNotFound) {
+		respondWithError(w, http.StatusNotFound, "Game not found.")
+		return false
+	}
+	if err != nil {
+		log.Printf("ERROR: Failed to get %s for game %d: %v", resource, gameID, err)
+		respondWithError(w, http.StatusInternalServerError, "Could not retrieve game "+resource+".")
+		return false
+	}
+	return true
+}
+
 /*
  * StatsHandler handles HTTP requests related to game statistics.
  *
@@ -87,7 +185,57 @@ func respondWithError(w http.ResponseWriter, code int, message string) {
 }
 
 /*
- * GetRanking returns the current player ranking as JSON.
+ * parseGameConfig reads the optional room creation options from query parameters.
+ *
+ * Parameters:
+ *   - q (url.Values): The request query parameters.
+ *
+ * Returns:
+ *   - domain.GameConfig: The parsed options; missing values are left at zero.
+ *   - error: An error if a numeric option is not a valid integer or swapSides is not a boolean.
+ */
+func parseGameConfig(q url.Values) (domain.GameConfig, error) {
+	config := domain.GameConfig{
+		Ruleset:   q.Get("ruleset"),
+		BotLevel:  q.Get("bot"),
+		BotSymbol: q.Get("botSymbol"),
+		Privacy:   q.Get("privacy"),
+	}
+
+	ints := map[string]*int{
+		"width":     &config.Width,
+		"height":    &config.Height,
+		"winLength": &config.WinLength,
+		"moveTime":  &config.MoveTime,
+		"baseTime":  &config.BaseTime,
+		"increment": &config.Increment,
+		"bestOf":    &config.BestOf,
+	}
+	for key, target := range ints {
+		raw := q.Get(key)
+		if raw == "" {
+			continue
+		}
+		v, err := strconv.Atoi(raw)
+		if err != nil {
+			return config, fmt.Errorf("%s must be an integer", key)
+		}
+		*target = v
+	}
+
+	if raw := q.Get("swapSides"); raw != "" {
+		v, err := strconv.ParseBool(raw)
+		if err != nil {
+			return config, errors.New("swapSides must be true or false")
+		}
+		config.SwapSides = v
+	}
+	return config, nil
+}
+
+/*
+ * GetRanking returns the current player ranking, ordered by rating, as JSON.
+ * The optional "minGames" query parameter overrides the minimum number of games to be ranked.
  *
  * Parameters:
  *   - w (http.ResponseWriter): The HTTP response writer.
@@ -97,13 +245,23 @@ func respondWithError(w http.ResponseWriter, code int, message string) {
  *   - None. Writes the ranking to the response.
  */
 func (h *StatsHandler) GetRanking(w http.ResponseWriter, r *http.Request) {
-	ranking, err := h.statsService.GetRanking()
+	minGames := services.DefaultRankingMinGames
+	if raw := r.URL.Query().Get("minGames"); raw != "" {
+		v, err := strconv.Atoi(raw)
+		if err != nil || v < 0 {
+			respondWithError(w, http.StatusBadRequest, "minGames must be a non-negative integer.")
+			return
+		}
+		minGames = v
+	}
+
+	ranking, err := h.statsService.GetRanking(minGames)
 	if err != nil {
 		log.Printf("ERROR: Failed to get ranking: %v", err)
 		respondWithError(w, http.StatusInternalServerError, "Could not retrieve player ranking.")
 		return
 	}
-	respondWithJSON(w, http.StatusOK, ranking)
+	respondWithJSON(w, http.StatusOK, dto.NewRanking(ranking.Players, ranking.MinGames))
 }
 
 /*
@@ -114,7 +272,7 @@ func (h *StatsHandler) GetRanking(w http.ResponseWriter, r *http.Request) {
  *   - r (*http.Request): The HTTP request.
  *
  * Returns:
- *   - None. Writes the statistics to the response.
+ *   - None. Writes the statistics to the response, or 404 if no player has the name.
  */
 func (h *StatsHandler) GetGeneralStats(w http.ResponseWriter, r *http.Request) {
 	stats, err := h.statsService.GetGeneralStats()
@@ -123,7 +281,7 @@ func (h *StatsHandler) GetGeneralStats(w http.ResponseWriter, r *http.Request) {
 		respondWithError(w, http.StatusInternalServerError, "Could not retrieve general statistics.")
 		return
 	}
-	respondWithJSON(w, http.StatusOK, stats)
+	respondWithJSON(w, http.StatusOK, dto.GeneralStatsResponse(*stats))
 }
 
 /*
@@ -134,15 +292,13 @@ func (h *StatsHandler) GetGeneralStats(w http.ResponseWriter, r *http.Request) {
  *   - r (*http.Request): The HTTP request.
  *
  * Returns:
- *   - None. Writes the statistics to the response.
+ *   - None. Writes the statistics to the response, or 404 if no player has the name.
  */
 func (h *StatsHandler) GetGameHistory(w http.ResponseWriter, r *http.Request) {
 
-	path := strings.TrimPrefix(r.URL.Path, "/api/rooms/history/")
-	roomID := path
-
+	roomID := r.PathValue("roomId")
 	if roomID == "" {
-		http.Error(w, "Room ID is required as path parameter", http.StatusBadRequest)
+		respondWithError(w, http.StatusBadRequest, "Room ID is required as path parameter.")
 		return
 	}
 
@@ -153,7 +309,7 @@ func (h *StatsHandler) GetGameHistory(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	respondWithJSON(w, http.StatusOK, history)
+	respondWithJSON(w, http.StatusOK, dto.NewGameHistory(history.RoomID, history.Games, history.Series))
 }
 
 /*
@@ -164,23 +320,75 @@ func (h *StatsHandler) GetGameHistory(w http.ResponseWriter, r *http.Request) {
  *   - r (*http.Request): The HTTP request.
  *
  * Returns:
- *   - None. Writes the statistics to the response.
+ *   - None. Writes the statistics to the response, or 404 if no player has the name.
  */
 func (h *StatsHandler) GetPlayerStats(w http.ResponseWriter, r *http.Request) {
-	playerName := r.URL.Query().Get("playerName")
+	playerName := playerNameParam(r)
 	if playerName == "" {
-		http.Error(w, "Player name is required as query parameter", http.StatusBadRequest)
+		respondWithError(w, http.StatusBadRequest, "Player name is required as query parameter.")
 		return
 	}
 
 	player, err := h.statsService.GetPlayerStats(playerName)
+	if errors.Is(err, services.ErrPlayerNotFound) {
+		respondWithError(w, http.StatusNotFound, "Player not found.")
+		return
+	}
 	if err != nil {
 		log.Printf("ERROR: Failed to get player stats for %s: %v", playerName, err)
 		respondWithError(w, http.StatusInternalServerError, "Could not retrieve player statistics.")
 		return
 	}
 
-	respondWithJSON(w, http.StatusOK, player)
+	respondWithJSON(w, http.StatusOK, dto.NewPlayer(player))
+}
+
+/*
+ * GetRatingHistory returns the rating history of a specific player
+ *
+ * Parameters:
+ *   - w (http.ResponseWriter): The HTTP response writer.
+ *   - r (*http.Request): The HTTP request.
+ *
+ * Returns:
+ *   - None. Writes the rating history to the response, or 404 if no player has the name.
+ */
+func (h *StatsHandler) GetRatingHistory(w http.ResponseWriter, r *http.Request) {
+	playerName := playerNameParam(r)
+	if playerName == "" {
+		respondWithError(w, http.StatusBadRequest, "Player name is required as query parameter.")
+		return
+	}
+
+	history, err := h.statsService.GetRatingHistory(playerName)
+	if errors.Is(err, services.ErrPlayerNotFound) {
+		respondWithError(w, http.StatusNotFound, "Player not found.")
+		return
+	}
+	if err != nil {
+		log.Printf("ERROR: Failed to get rating history for %s: %v", playerName, err)
+		respondWithError(w, http.StatusInternalServerError, "Could not retrieve rating history.")
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, dto.NewRatingHistory(history.PlayerName, history.History))
+}
+
+/*
+ * playerNameParam reads the player name from the {playerName} path parameter, or from
+ * the playerName query parameter of the deprecated /api/stats routes.
+ *
+ * Parameters:
+ *   - r (*http.Request): The HTTP request.
+ *
+ * Returns:
+ *   - string: The player name, empty if missing.
+ */
+func playerNameParam(r *http.Request) string {
+	if name := r.PathValue("playerName"); name != "" {
+		return name
+	}
+	return r.URL.Query().Get("playerName")
 }
 
 /*
@@ -189,6 +397,7 @@ func (h *StatsHandler) GetPlayerStats(w http.ResponseWriter, r *http.Request) {
  * Fields:
  *   - hub (*services.Hub): WebSocket hub for managing clients.
  *   - gameService (*services.GameService): Service used to handle game logic.
+ *   - authService (*services.AuthService): Service used to resolve the session token.
  *
  * Returns:
  *   - *WebSocketHandler: A new instance of WebSocketHandler.
@@ -196,10 +405,11 @@ func (h *StatsHandler) GetPlayerStats(w http.ResponseWriter, r *http.Request) {
 type WebSocketHandler struct {
 	hub         *services.Hub
 	gameService *services.GameService
+	authService *services.AuthService
 }
 
-func NewWebSocketHandler(h *services.Hub, gs *services.GameService) *WebSocketHandler {
-	return &WebSocketHandler{hub: h, gameService: gs}
+func NewWebSocketHandler(h *services.Hub, gs *services.GameService, as *services.AuthService) *WebSocketHandler {
+	return &WebSocketHandler{hub: h, gameService: gs, authService: as}
 }
 
 /*
@@ -207,19 +417,37 @@ func NewWebSocketHandler(h *services.Hub, gs *services.GameService) *WebSocketHa
  *
  * Parameters:
  *   - w (http.ResponseWriter): The HTTP response writer.
- *   - r (*http.Request): The HTTP request containing the room ID and player name.
+ *   - r (*http.Request): The HTTP request containing the room ID, the session token (?token=), an optional
+ *     seat resume token (?resume=), the password (?password=) or invite (?invite=) of a private room,
+ *     and optional room options.
  *
  * Returns:
  *   - None.
  */
 func (h *WebSocketHandler) HandleConnection(w http.ResponseWriter, r *http.Request) {
-	roomID := strings.TrimPrefix(r.URL.Path, "/ws/join/")
-	playerName := r.URL.Query().Get("playerName")
+	roomID := r.PathValue("roomId")
+	if roomID == "" {
+		http.Error(w, "Room ID is required", http.StatusBadRequest)
+		return
+	}
 
-	if roomID == "" || playerName == "" {
-		http.Error(w, "Room ID and Player Name are required", http.StatusBadRequest)
+	// Browsers cannot set headers on a WebSocket handshake, so the token travels in the query.
+	player, err := h.authService.Authenticate(r.URL.Query().Get("token"))
+	if err != nil {
+		logAuthFailure(err)
+		http.Error(w, "A valid session token is required", http.StatusUnauthorized)
+		return
+	}
+
+	config, err := parseGameConfig(r.URL.Query())
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
 		return
 	}
 
-	services.ServeWs(h.hub, h.gameService, w, r, roomID, playerName)
+	access := domain.RoomAccess{
+		Password: r.URL.Query().Get("password"),
+		Invite:   r.URL.Query().Get("invite"),
+	}
+	services.ServeWs(h.hub, h.gameService, w, r, roomID, player, r.URL.Query().Get("resume"), config, access)
 }
@@ -0,0 +1,54 @@
+/*
+ * file: matchmaking_handlers.go
+ * package: handlers
+ * description:
+ *     Exposes the quick play matchmaking WebSocket endpoint.
+ */
+
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/juan10024/tictactoe-test/internal/core/services"
+)
+
+/*
+ * MatchmakingHandler serves the matchmaking queue over WebSocket.
+ *
+ * Fields:
+ *   - matchmaker (*services.Matchmaker): The matchmaker holding the queue.
+ *   - authService (*services.AuthService): Service used to resolve the session token.
+ *
+ * Returns:
+ *   - *MatchmakingHandler: A new instance of MatchmakingHandler.
+ */
+type MatchmakingHandler struct {
+	matchmaker  *services.Matchmaker
+	authService *services.AuthService
+}
+
+func NewMatchmakingHandler(m *services.Matchmaker, as *services.AuthService) *MatchmakingHandler {
+	return &MatchmakingHandler{matchmaker: m, authService: as}
+}
+
+/*
+ * HandleConnection authenticates the player and upgrades the request to the matchmaking WebSocket.
+ *
+ * Parameters:
+ *   - w (http.ResponseWriter): The HTTP response writer.
+ *   - r (*http.Request): The HTTP request carrying the session token (?token=).
+ *
+ * Returns:
+ *   - None.
+ */
+func (h *MatchmakingHandler) HandleConnection(w http.ResponseWriter, r *http.Request) {
+	player, err := h.authService.Authenticate(r.URL.Query().Get("token"))
+	if err != nil {
+		logAuthFailure(err)
+		http.Error(w, "A valid session token is required", http.StatusUnauthorized)
+		return
+	}
+
+	services.ServeMatchmaking(h.matchmaker, w, r, player)
+}
@@ -0,0 +1,35 @@
+/*
+ * file: openapi_handlers.go
+ * package: handlers
+ * description:
+ *     Serves the OpenAPI 3 document describing the HTTP API. The document lives next
+ *     to the handlers in openapi.json and is embedded in the binary; openapi_test.go
+ *     checks it against the routes of router.go and the responses the handlers write.
+ */
+
+package handlers
+
+import (
+	_ "embed"
+	"net/http"
+)
+
+//go:embed openapi.json
+var openAPISpec []byte
+
+/*
+ * ServeOpenAPI writes the OpenAPI document.
+ * Route: GET /api/v1/openapi.json
+ *
+ * Parameters:
+ *   - w (http.ResponseWriter): The HTTP response writer.
+ *   - r (*http.Request): The HTTP request.
+ *
+ * Returns:
+ *   - None.
+ */
+func ServeOpenAPI(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	w.Write(openAPISpec)
+}
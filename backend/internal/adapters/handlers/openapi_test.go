@@ -0,0 +1,406 @@
+/*
+ * file: openapi_test.go
+ * package: handlers
+ * description:
+ *     Keeps openapi.json in step with the code: every route of router.go must be
+ *     documented, and the responses the handlers actually write, run against the real
+ *     services over an in-memory store, must match the documented status codes and schemas.
+ *     Schemas are closed (additionalProperties: false), so an undocumented field fails too.
+ */
+
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"math"
+	"mime"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/juan10024/tictactoe-test/internal/core/ports/portstest"
+	"github.com/juan10024/tictactoe-test/internal/core/rules"
+	"github.com/juan10024/tictactoe-test/internal/core/services"
+	"github.com/juan10024/tictactoe-test/internal/infra/auth"
+	"github.com/juan10024/tictactoe-test/internal/infra/broadcast"
+)
+
+type schema = map[string]interface{}
+
+// openAPI is the parsed document, with just enough of a JSON Schema validator for the
+// keywords openapi.json uses: $ref, type, nullable, enum, format date-time, properties,
+// required, additionalProperties: false and items.
+type openAPI struct {
+	doc schema
+}
+
+func loadOpenAPI(t *testing.T) *openAPI {
+	t.Helper()
+	var doc schema
+	if err := json.Unmarshal(openAPISpec, &doc); err != nil {
+		t.Fatalf("openapi.json is not valid JSON: %v", err)
+	}
+	return &openAPI{doc: doc}
+}
+
+// resolve follows a local "#/..." reference.
+func (o *openAPI) resolve(s schema) schema {
+	for {
+		ref, ok := s["$ref"].(string)
+		if !ok {
+			return s
+		}
+		var node interface{} = o.doc
+		for _, part := range strings.Split(strings.TrimPrefix(ref, "#/"), "/") {
+			node = node.(schema)[part]
+		}
+		s = node.(schema)
+	}
+}
+
+// operation finds the documented operation serving a request path, matching {params}.
+func (o *openAPI) operation(method, path string) (schema, bool) {
+	for template, item := range o.doc["paths"].(schema) {
+		want, got := strings.Split(template, "/"), strings.Split(path, "/")
+		if len(want) != len(got) {
+			continue
+		}
+		match := true
+		for i := range want {
+			if want[i] != got[i] && !strings.HasPrefix(want[i], "{") {
+				match = false
+				break
+			}
+		}
+		if op, ok := item.(schema)[strings.ToLower(method)].(schema); match && ok {
+			return op, true
+		}
+	}
+	return nil, false
+}
+
+// checkResponse validates a recorded response against the operation documented for the request.
+func (o *openAPI) checkResponse(method, path string, rec *httptest.ResponseRecorder) []string {
+	op, ok := o.operation(method, path)
+	if !ok && rec.Code == http.StatusMethodNotAllowed {
+		// The mux refuses methods a path does not serve, listing the ones it does.
+		if rec.Header().Get("Allow") == "" {
+			return []string{"405 without an Allow header"}
+		}
+		return nil
+	}
+	if !ok {
+		return []string{fmt.Sprintf("%s %s is not documented", method, path)}
+	}
+	response, ok := op["responses"].(schema)[fmt.Sprint(rec.Code)].(schema)
+	if !ok {
+		return []string{fmt.Sprintf("status %d is not documented", rec.Code)}
+	}
+	mediaType, _, _ := mime.ParseMediaType(rec.Header().Get("Content-Type"))
+	content, ok := o.resolve(response)["content"].(schema)[mediaType].(schema)
+	if !ok {
+		return []string{fmt.Sprintf("content type %q is not documented for status %d", mediaType, rec.Code)}
+	}
+
+	var body interface{}
+	if mediaType == "application/json" {
+		if err := json.Unmarshal(rec.Body.Bytes(), &body); err != nil {
+			return []string{fmt.Sprintf("body is not JSON: %v", err)}
+		}
+	} else {
+		body = rec.Body.String()
+	}
+	return o.validate("body", content["schema"].(schema), body)
+}
+
+// validate returns one message per place where value does not match s.
+func (o *openAPI) validate(at string, s schema, value interface{}) []string {
+	s = o.resolve(s)
+	if value == nil {
+		if s["nullable"] == true {
+			return nil
+		}
+		return []string{at + ": null is not allowed"}
+	}
+
+	if enum, ok := s["enum"].([]interface{}); ok {
+		found := false
+		for _, e := range enum {
+			found = found || reflect.DeepEqual(e, value)
+		}
+		if !found {
+			return []string{fmt.Sprintf("%s: %v is not one of %v", at, value, enum)}
+		}
+	}
+
+	var errs []string
+	switch s["type"] {
+	case "object":
+		obj, ok := value.(map[string]interface{})
+		if !ok {
+			return []string{at + ": expected an object"}
+		}
+		props, _ := s["properties"].(schema)
+		required, _ := s["required"].([]interface{})
+		for _, name := range required {
+			if _, ok := obj[name.(string)]; !ok {
+				errs = append(errs, fmt.Sprintf("%s: missing required field %q", at, name))
+			}
+		}
+		for name, v := range obj {
+			prop, ok := props[name].(schema)
+			if !ok {
+				if s["additionalProperties"] == false {
+					errs = append(errs, fmt.Sprintf("%s: undocumented field %q", at, name))
+				}
+				continue
+			}
+			errs = append(errs, o.validate(at+"."+name, prop, v)...)
+		}
+	case "array":
+		items, ok := value.([]interface{})
+		if !ok {
+			return []string{at + ": expected an array"}
+		}
+		for i, item := range items {
+			errs = append(errs, o.validate(fmt.Sprintf("%s[%d]", at, i), s["items"].(schema), item)...)
+		}
+	case "string":
+		str, ok := value.(string)
+		if !ok {
+			return []string{at + ": expected a string"}
+		}
+		if s["format"] == "date-time" {
+			if _, err := time.Parse(time.RFC3339Nano, str); err != nil {
+				errs = append(errs, fmt.Sprintf("%s: %q is not a date-time", at, str))
+			}
+		}
+	case "integer":
+		if n, ok := value.(float64); !ok || n != math.Trunc(n) {
+			return []string{at + ": expected an integer"}
+		}
+	case "number":
+		if _, ok := value.(float64); !ok {
+			return []string{at + ": expected a number"}
+		}
+	case "boolean":
+		if _, ok := value.(bool); !ok {
+			return []string{at + ": expected a boolean"}
+		}
+	}
+	return errs
+}
+
+// TestOpenAPIDocumentsEveryRoute checks that every route of the router appears in
+// openapi.json with its method. Deprecated aliases are described once, in info.
+func TestOpenAPIDocumentsEveryRoute(t *testing.T) {
+	paths := loadOpenAPI(t).doc["paths"].(schema)
+	for _, rt := range (Handlers{}).routes() {
+		item, ok := paths[rt.path].(schema)
+		if !ok {
+			t.Errorf("route %s is not documented in openapi.json", rt.path)
+			continue
+		}
+		if _, ok := item[strings.ToLower(rt.method)]; !ok {
+			t.Errorf("route %s %s is not documented in openapi.json", rt.method, rt.path)
+		}
+	}
+}
+
+// testAPI is the HTTP API served by the real handlers and services over an in-memory store.
+type testAPI struct {
+	t           *testing.T
+	spec        *openAPI
+	router      http.Handler
+	gameService *services.GameService
+}
+
+func newTestAPI(t *testing.T) *testAPI {
+	store := portstest.NewMemoryStore()
+	secret := []byte("openapi-test-secret")
+	hasher := auth.NewBcryptHasher()
+	broadcaster := broadcast.NewMemoryBroadcaster()
+	hub := services.NewHub(time.Minute, broadcaster)
+	go hub.Run()
+
+	roomService := services.NewRoomService(store, hasher, auth.NewHMACInviteIssuer(secret))
+	gameService := services.NewGameService(store, store, roomService, rules.NewClassic(), rules.NewGomoku(), rules.NewUltimate())
+	lobby := services.NewLobby(hub, gameService, broadcaster)
+	authService := services.NewAuthService(store, auth.NewHMACTokenIssuer(secret), hasher)
+
+	router := NewRouter(Handlers{
+		Auth:        NewAuthHandler(authService),
+		Stats:       NewStatsHandler(services.NewStatsService(store)),
+		Rooms:       NewRoomHandler(gameService, roomService, authService),
+		Games:       NewGameHandler(gameService, hub),
+		Lobby:       NewLobbyHandler(lobby),
+		WebSocket:   NewWebSocketHandler(hub, gameService, authService),
+		Matchmaking: NewMatchmakingHandler(services.NewMatchmaker(gameService), authService),
+	})
+
+	return &testAPI{t: t, spec: loadOpenAPI(t), router: router, gameService: gameService}
+}
+
+/*
+ * call sends a request, fails the test if the status is not the expected one or the
+ * response does not match the document, and returns the decoded JSON body.
+ */
+func (a *testAPI) call(method, target, token, body string, wantStatus int) map[string]interface{} {
+	a.t.Helper()
+	req := httptest.NewRequest(method, target, bytes.NewReader([]byte(body)))
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	rec := httptest.NewRecorder()
+	a.router.ServeHTTP(rec, req)
+
+	if rec.Code != wantStatus {
+		a.t.Errorf("%s %s: status %d, want %d: %s", method, target, rec.Code, wantStatus, rec.Body.String())
+	}
+	for _, msg := range a.spec.checkResponse(method, req.URL.Path, rec) {
+		a.t.Errorf("%s %s: %s", method, target, msg)
+	}
+
+	var decoded map[string]interface{}
+	json.Unmarshal(rec.Body.Bytes(), &decoded)
+	return decoded
+}
+
+// TestHandlerResponsesMatchOpenAPI drives every REST endpoint through its success and
+// error paths and validates each response against openapi.json.
+func TestHandlerResponsesMatchOpenAPI(t *testing.T) {
+	api := newTestAPI(t)
+
+	alice := api.call("POST", "/api/v1/auth/register", "", `{"playerName":"alice","password":"s3cret-pass"}`, http.StatusOK)
+	bob := api.call("POST", "/api/v1/auth/guest", "", `{"playerName":"bob"}`, http.StatusOK)
+	api.call("POST", "/api/v1/auth/login", "", `{"playerName":"alice","password":"s3cret-pass"}`, http.StatusOK)
+	api.call("POST", "/api/v1/auth/login", "", `{"playerName":"alice","password":"wrong-pass"}`, http.StatusUnauthorized)
+	api.call("POST", "/api/v1/auth/register", "", `{"playerName":"alice","password":"s3cret-pass"}`, http.StatusConflict)
+	api.call("POST", "/api/v1/auth/guest", "", `not json`, http.StatusBadRequest)
+	api.call("GET", "/api/v1/auth/guest", "", "", http.StatusMethodNotAllowed)
+
+	aliceToken, _ := alice["token"].(string)
+	bobToken, _ := bob["token"].(string)
+
+	// A guest name can only be registered with that guest's session.
+	carol := api.call("POST", "/api/v1/auth/guest", "", `{"playerName":"carol"}`, http.StatusOK)
+	carolToken, _ := carol["token"].(string)
+	api.call("POST", "/api/v1/auth/register", "", `{"playerName":"carol","password":"s3cret-pass"}`, http.StatusConflict)
+	api.call("POST", "/api/v1/auth/register", bobToken, `{"playerName":"carol","password":"s3cret-pass"}`, http.StatusConflict)
+	api.call("POST", "/api/v1/auth/register", carolToken, `{"playerName":"carol","password":"s3cret-pass"}`, http.StatusOK)
+	api.call("POST", "/api/v1/auth/register", carolToken, `{"playerName":"carol","password":"s3cret-pass"}`, http.StatusConflict)
+	aliceID := uint(alice["player"].(map[string]interface{})["id"].(float64))
+	bobID := uint(bob["player"].(map[string]interface{})["id"].(float64))
+
+	api.call("POST", "/api/v1/rooms/room-1/join", aliceToken, `{"bestOf":3}`, http.StatusOK)
+	api.call("GET", "/api/v1/rooms", "", "", http.StatusOK)
+	joined := api.call("POST", "/api/v1/rooms/room-1/join", bobToken, `{}`, http.StatusOK)
+	api.call("POST", "/api/v1/rooms/room-1/join", "", `{}`, http.StatusUnauthorized)
+	api.call("POST", "/api/v1/rooms/room-1/join", aliceToken, `not json`, http.StatusBadRequest)
+	api.call("POST", "/api/v1/rooms/room-2/join", aliceToken, `{"ruleset":"chess"}`, http.StatusBadRequest)
+	api.call("POST", "/api/v1/rooms/room-3/join", aliceToken, `{"privacy":"password","password":"letmein"}`, http.StatusOK)
+	api.call("POST", "/api/v1/rooms/room-3/join", bobToken, `{"password":"wrong"}`, http.StatusForbidden)
+
+	api.call("POST", "/api/v1/rooms/room-1/invites", aliceToken, "", http.StatusOK)
+	api.call("POST", "/api/v1/rooms/room-1/invites", aliceToken, `{"ttlMinutes":5}`, http.StatusOK)
+	api.call("POST", "/api/v1/rooms/room-1/invites", bobToken, "", http.StatusForbidden)
+	api.call("POST", "/api/v1/rooms/no-such-room/invites", aliceToken, "", http.StatusNotFound)
+	api.call("POST", "/api/v1/rooms/room-1/invites", "", "", http.StatusUnauthorized)
+	api.call("GET", "/api/v1/rooms/room-1/invites", aliceToken, "", http.StatusMethodNotAllowed)
+
+	// X (alice) wins along the top row, so the game has moves, ratings and a history.
+	if _, err := api.gameService.StartGame("room-1"); err != nil {
+		t.Fatalf("start game: %v", err)
+	}
+	for i, position := range []int{0, 3, 1, 4, 2} {
+		mover := aliceID
+		if i%2 == 1 {
+			mover = bobID
+		}
+		if _, err := api.gameService.MakeMove("room-1", mover, position); err != nil {
+			t.Fatalf("move %d: %v", position, err)
+		}
+	}
+	game := joined["game"].(map[string]interface{})
+	gameID := fmt.Sprint(game["id"])
+
+	api.call("GET", "/api/v1/games/"+gameID+"/moves", "", "", http.StatusOK)
+	api.call("GET", "/api/v1/games/"+gameID+"/replay", "", "", http.StatusOK)
+	api.call("GET", "/api/v1/games/9999/moves", "", "", http.StatusNotFound)
+	api.call("GET", "/api/v1/games/abc/replay", "", "", http.StatusBadRequest)
+	api.call("GET", "/api/v1/games/0/moves", "", "", http.StatusBadRequest)
+
+	api.call("GET", "/api/v1/rooms/room-1/history", "", "", http.StatusOK)
+	api.call("GET", "/api/v1/rooms/empty-room/history", "", "", http.StatusOK)
+
+	api.call("GET", "/api/v1/stats/ranking", "", "", http.StatusOK)
+	api.call("GET", "/api/v1/stats/ranking?minGames=0", "", "", http.StatusOK)
+	api.call("GET", "/api/v1/stats/ranking?minGames=-1", "", "", http.StatusBadRequest)
+	api.call("GET", "/api/v1/stats/general", "", "", http.StatusOK)
+	api.call("GET", "/api/v1/players/alice/stats", "", "", http.StatusOK)
+	api.call("GET", "/api/v1/players/alice/rating-history", "", "", http.StatusOK)
+	api.call("GET", "/api/v1/players/nobody/stats", "", "", http.StatusNotFound)
+	api.call("GET", "/api/v1/players/nobody/rating-history", "", "", http.StatusNotFound)
+
+	api.call("GET", "/api/v1/openapi.json", "", "", http.StatusOK)
+	api.call("DELETE", "/api/v1/stats/general", "", "", http.StatusMethodNotAllowed)
+}
+
+// TestLegacyPathsAreDeprecatedAliases checks that the pre-v1 paths answer like their v1
+// successors, flagged with Deprecation and Link headers, and that the router answers an
+// unsupported method with 405 and the allowed methods.
+func TestLegacyPathsAreDeprecatedAliases(t *testing.T) {
+	api := newTestAPI(t)
+	api.call("POST", "/api/v1/auth/guest", "", `{"playerName":"alice"}`, http.StatusOK)
+
+	serve := func(method, target string) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		api.router.ServeHTTP(rec, httptest.NewRequest(method, target, nil))
+		return rec
+	}
+
+	aliases := []struct{ legacy, successor string }{
+		{"/api/stats/player?playerName=alice", "/api/v1/players/alice/stats"},
+		{"/api/stats/rating-history?playerName=alice", "/api/v1/players/alice/rating-history"},
+		{"/api/rooms/history/room-1", "/api/v1/rooms/room-1/history"},
+		{"/api/games/9999/moves", "/api/v1/games/9999/moves"},
+		{"/api/stats/general", "/api/v1/stats/general"},
+	}
+	for _, a := range aliases {
+		old, current := serve("GET", a.legacy), serve("GET", a.successor)
+		if old.Code != current.Code || old.Body.String() != current.Body.String() {
+			t.Errorf("GET %s: got %d %s, want %d %s", a.legacy, old.Code, old.Body, current.Code, current.Body)
+		}
+		if got := old.Header().Get("Deprecation"); got != "true" {
+			t.Errorf("GET %s: Deprecation %q, want \"true\"", a.legacy, got)
+		}
+		successor, _, _ := strings.Cut(a.successor, "?")
+		if got, want := old.Header().Get("Link"), "<"+successor+`>; rel="successor-version"`; got != want {
+			t.Errorf("GET %s: Link %q, want %q", a.legacy, got, want)
+		}
+		if got := current.Header().Get("Deprecation"); got != "" {
+			t.Errorf("GET %s: unexpected Deprecation %q", a.successor, got)
+		}
+	}
+
+	notAllowed := []struct{ method, target, allow string }{
+		{"GET", "/api/rooms/join/room-1", "POST"},
+		{"GET", "/api/v1/rooms/room-1/join", "POST"},
+		{"POST", "/api/v1/stats/ranking", "GET, HEAD"},
+		{"DELETE", "/api/v1/games/1/replay", "GET, HEAD"},
+	}
+	for _, c := range notAllowed {
+		rec := serve(c.method, c.target)
+		if rec.Code != http.StatusMethodNotAllowed {
+			t.Errorf("%s %s: status %d, want 405", c.method, c.target, rec.Code)
+		}
+		if got := rec.Header().Get("Allow"); got != c.allow {
+			t.Errorf("%s %s: Allow %q, want %q", c.method, c.target, got, c.allow)
+		}
+	}
+}
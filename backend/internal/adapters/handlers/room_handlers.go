@@ -2,70 +2,160 @@
  * file: room_handler.go
  * package: handlers
  * description:
- *     Exposes HTTP endpoints for room-related operations, including joining a room.
+ *     Exposes HTTP endpoints for room-related operations, including joining a room
+ *     and inviting players to a private room.
  */
 
 package handlers
 
 import (
 	"encoding/json"
+	"errors"
+	"io"
 	"net/http"
-	"strings"
+	"time"
 
 	"github.com/juan10024/tictactoe-test/internal/adapters/dto"
+	"github.com/juan10024/tictactoe-test/internal/core/domain"
 	"github.com/juan10024/tictactoe-test/internal/core/services"
 )
 
 type RoomHandler struct {
 	gameService *services.GameService
+	roomService *services.RoomService
+	authService *services.AuthService
 }
 
-func NewRoomHandler(gameService *services.GameService) *RoomHandler {
+func NewRoomHandler(gameService *services.GameService, roomService *services.RoomService, authService *services.AuthService) *RoomHandler {
 	return &RoomHandler{
 		gameService: gameService,
+		roomService: roomService,
+		authService: authService,
 	}
 }
 
 func (h *RoomHandler) JoinRoom(w http.ResponseWriter, r *http.Request) {
-	path := strings.TrimPrefix(r.URL.Path, "/api/rooms/join/")
-	roomID := path
-
+	roomID := r.PathValue("roomId")
 	if roomID == "" {
-		http.Error(w, "Room ID is required", http.StatusBadRequest)
+		respondWithJoinError(w, http.StatusBadRequest, "Room ID is required")
 		return
 	}
 
 	var req dto.JoinRoomRequest
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		respondWithJoinError(w, http.StatusBadRequest, "Invalid request body")
 		return
 	}
 
-	if req.PlayerName == "" {
-		http.Error(w, "Player name is required", http.StatusBadRequest)
+	player, err := h.authService.Authenticate(bearerToken(r))
+	if err != nil {
+		logAuthFailure(err)
+		respondWithJoinError(w, http.StatusUnauthorized, "A valid session token is required")
 		return
 	}
 
-	game, player, err := h.gameService.HandleJoinRoom(roomID, req.PlayerName)
+	game, player, err := h.gameService.HandleJoinRoom(roomID, player, domain.GameConfig{
+		Ruleset:   req.Ruleset,
+		Width:     req.Width,
+		Height:    req.Height,
+		WinLength: req.WinLength,
+		BotLevel:  req.BotLevel,
+		BotSymbol: req.BotSymbol,
+		MoveTime:  req.MoveTime,
+		BaseTime:  req.BaseTime,
+		Increment: req.Increment,
+		Privacy:   req.Privacy,
+		BestOf:    req.BestOf,
+		SwapSides: req.SwapSides,
+	}, domain.RoomAccess{Password: req.Password, Invite: req.Invite})
 	if err != nil {
-		w.Header().Set("Content-Type", "application/json")
-		w.WriteHeader(http.StatusBadRequest)
-		json.NewEncoder(w).Encode(dto.JoinRoomResponse{
-			Error:   true,
-			Message: err.Error(),
-		})
+		status := http.StatusBadRequest
+		if errors.Is(err, services.ErrGameConflict) {
+			status = http.StatusConflict
+		}
+		if errors.Is(err, services.ErrRoomAccessDenied) {
+			status = http.StatusForbidden
+		}
+		if errors.Is(err, services.ErrRoomCommandFailed) {
+			status = http.StatusInternalServerError
+		}
+		respondWithJoinError(w, status, err.Error())
 		return
 	}
 
-	w.Header().Set("Content-Type", "application/json")
-	w.WriteHeader(http.StatusOK)
-	json.NewEncoder(w).Encode(dto.JoinRoomResponse{
-		Error:      false,
-		Message:    "Successfully joined room",
-		Game:       game,
-		Player:     player,
-		RoomID:     roomID,
-		PlayerID:   player.ID,
-		PlayerName: player.Name,
+	respondWithJSON(w, http.StatusOK, dto.JoinRoomResponse{
+		Error:       false,
+		Message:     "Successfully joined room",
+		Game:        dto.NewGame(game),
+		Player:      dto.NewPlayer(player),
+		RoomID:      roomID,
+		PlayerID:    player.ID,
+		PlayerName:  player.Name,
+		Seat:        game.SeatOf(player.ID),
+		ResumeToken: game.ResumeTokenOf(player.ID),
 	})
 }
+
+/*
+ * respondWithJoinError sends a failed join, in the same shape as a successful one.
+ *
+ * Parameters:
+ *   - w (http.ResponseWriter): The HTTP response writer.
+ *   - code (int): The HTTP status code.
+ *   - message (string): The reason the join failed.
+ *
+ * Returns:
+ *   - None.
+ */
+func respondWithJoinError(w http.ResponseWriter, code int, message string) {
+	respondWithJSON(w, code, dto.JoinRoomResponse{Error: true, Message: message})
+}
+
+/*
+ * CreateInvite issues a signed, expiring invite to a room. Only the room owner may invite.
+ * Route: POST /api/v1/rooms/{roomId}/invites
+ *
+ * Parameters:
+ *   - w (http.ResponseWriter): The HTTP response writer.
+ *   - r (*http.Request): The HTTP request with an optional dto.CreateInviteRequest body and
+ *     the owner's session token (Authorization: Bearer).
+ *
+ * Returns:
+ *   - None. Writes a dto.InviteResponse.
+ */
+func (h *RoomHandler) CreateInvite(w http.ResponseWriter, r *http.Request) {
+	roomID := r.PathValue("roomId")
+	if roomID == "" {
+		respondWithError(w, http.StatusBadRequest, "Room ID is required.")
+		return
+	}
+
+	var req dto.CreateInviteRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil && !errors.Is(err, io.EOF) {
+		respondWithError(w, http.StatusBadRequest, "Invalid request body.")
+		return
+	}
+
+	player, err := h.authService.Authenticate(bearerToken(r))
+	if err != nil {
+		logAuthFailure(err)
+		respondWithError(w, http.StatusUnauthorized, "A valid session token is required.")
+		return
+	}
+
+	invite, err := h.roomService.CreateInvite(roomID, player, time.Duration(req.TTLMinutes)*time.Minute)
+	switch {
+	case errors.Is(err, services.ErrRoomNotFound):
+		respondWithError(w, http.StatusNotFound, err.Error())
+	case errors.Is(err, services.ErrNotRoomOwner):
+		respondWithError(w, http.StatusForbidden, err.Error())
+	case err != nil:
+		respondWithError(w, http.StatusBadRequest, err.Error())
+	default:
+		respondWithJSON(w, http.StatusOK, dto.InviteResponse{
+			RoomID:    invite.RoomID,
+			Invite:    invite.Token,
+			ExpiresAt: invite.ExpiresAt,
+		})
+	}
+}
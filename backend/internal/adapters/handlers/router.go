@@ -0,0 +1,138 @@
+/*
+ * file: router.go
+ * package: handlers
+ * description:
+ *     Builds the HTTP router: the versioned REST API under /api/v1, the WebSocket
+ *     endpoints, and the pre-v1 paths kept as deprecated aliases. Routes use method and
+ *     path patterns with {parameters}; the mux itself answers a path requested with a
+ *     method it does not serve with a 405 listing the allowed methods.
+ */
+
+package handlers
+
+import (
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+)
+
+// APIPrefix is the path prefix of the current REST API version.
+const APIPrefix = "/api/v1"
+
+/*
+ * Handlers groups the handlers served by the router.
+ *
+ * Fields:
+ *   - Auth, Stats, Rooms, Games, Lobby (*...Handler): The REST handlers.
+ *   - WebSocket, Matchmaking (*...Handler): The WebSocket handshakes; Lobby also serves one.
+ */
+type Handlers struct {
+	Auth        *AuthHandler
+	Stats       *StatsHandler
+	Rooms       *RoomHandler
+	Games       *GameHandler
+	Lobby       *LobbyHandler
+	WebSocket   *WebSocketHandler
+	Matchmaking *MatchmakingHandler
+}
+
+/*
+ * route is one endpoint of the router.
+ *
+ * Fields:
+ *   - method (string): The HTTP method served; GET also serves HEAD.
+ *   - path (string): The path pattern, with {parameters}.
+ *   - handler (http.HandlerFunc): The handler.
+ *   - legacy ([]string): Pre-v1 path patterns served as deprecated aliases of path.
+ */
+type route struct {
+	method  string
+	path    string
+	handler http.HandlerFunc
+	legacy  []string
+}
+
+/*
+ * routes lists every endpoint of the API.
+ *
+ * Returns:
+ *   - []route: The routes, grouped by resource.
+ */
+func (h Handlers) routes() []route {
+	return []route{
+		{http.MethodPost, APIPrefix + "/auth/register", h.Auth.Register, []string{"/api/auth/register"}},
+		{http.MethodPost, APIPrefix + "/auth/login", h.Auth.Login, []string{"/api/auth/login"}},
+		{http.MethodPost, APIPrefix + "/auth/guest", h.Auth.Guest, []string{"/api/auth/guest"}},
+
+		{http.MethodGet, APIPrefix + "/stats/ranking", h.Stats.GetRanking, []string{"/api/stats/ranking"}},
+		{http.MethodGet, APIPrefix + "/stats/general", h.Stats.GetGeneralStats, []string{"/api/stats/general"}},
+		{http.MethodGet, APIPrefix + "/players/{playerName}/stats", h.Stats.GetPlayerStats, []string{"/api/stats/player"}},
+		{http.MethodGet, APIPrefix + "/players/{playerName}/rating-history", h.Stats.GetRatingHistory, []string{"/api/stats/rating-history"}},
+
+		{http.MethodGet, APIPrefix + "/rooms", h.Lobby.ListRooms, []string{"/api/rooms"}},
+		{http.MethodGet, APIPrefix + "/rooms/{roomId}/history", h.Stats.GetGameHistory, []string{"/api/rooms/history/{roomId}"}},
+		{http.MethodPost, APIPrefix + "/rooms/{roomId}/join", h.Rooms.JoinRoom, []string{"/api/rooms/join/{roomId}"}},
+		{http.MethodPost, APIPrefix + "/rooms/{roomId}/invites", h.Rooms.CreateInvite, []string{"/api/rooms/invite/{roomId}"}},
+
+		{http.MethodGet, APIPrefix + "/games/{gameId}/moves", h.Games.GetGameMoves, []string{"/api/games/{gameId}/moves"}},
+		{http.MethodGet, APIPrefix + "/games/{gameId}/replay", h.Games.GetGameReplay, []string{"/api/games/{gameId}/replay"}},
+
+		{http.MethodGet, APIPrefix + "/openapi.json", ServeOpenAPI, []string{"/api/openapi.json"}},
+
+		{http.MethodGet, "/ws/join/{roomId}", h.WebSocket.HandleConnection, nil},
+		{http.MethodGet, "/ws/matchmaking", h.Matchmaking.HandleConnection, nil},
+		{http.MethodGet, "/ws/lobby", h.Lobby.HandleConnection, nil},
+	}
+}
+
+/*
+ * NewRouter registers every route and its deprecated aliases.
+ *
+ * Parameters:
+ *   - h (Handlers): The handlers to route to.
+ *
+ * Returns:
+ *   - *http.ServeMux: The router.
+ */
+func NewRouter(h Handlers) *http.ServeMux {
+	mux := http.NewServeMux()
+	for _, rt := range h.routes() {
+		mux.HandleFunc(rt.method+" "+rt.path, rt.handler)
+		for _, alias := range rt.legacy {
+			mux.HandleFunc(rt.method+" "+alias, deprecated(rt.path, rt.handler))
+		}
+	}
+	return mux
+}
+
+// pathParam matches a {parameter} of a path pattern.
+var pathParam = regexp.MustCompile(`\{(\w+)\}`)
+
+/*
+ * deprecated serves a pre-v1 path: it runs the v1 handler and marks the response with a
+ * Deprecation header and a Link to the v1 path (RFC 8594).
+ *
+ * Parameters:
+ *   - successor (string): The v1 path pattern replacing the alias.
+ *   - next (http.HandlerFunc): The v1 handler.
+ *
+ * Returns:
+ *   - http.HandlerFunc: The alias handler.
+ */
+func deprecated(successor string, next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		link := pathParam.ReplaceAllStringFunc(successor, func(param string) string {
+			name := strings.Trim(param, "{}")
+			value := r.PathValue(name)
+			if value == "" {
+				// The deprecated stats routes carry the player name in the query.
+				value = r.URL.Query().Get(name)
+			}
+			return url.PathEscape(value)
+		})
+		w.Header().Set("Deprecation", "true")
+		w.Header().Set("Link", "<"+link+`>; rel="successor-version"`)
+		next(w, r)
+	}
+}
@@ -10,6 +10,7 @@
 package domain
 
 import (
+	"strings"
 	"time"
 
 	"gorm.io/gorm"
@@ -22,34 +23,356 @@ type Player struct {
 	Wins   int    `gorm:"default:0" json:"wins"`
 	Draws  int    `gorm:"default:0" json:"draws"`
 	Losses int    `gorm:"default:0" json:"losses"`
+	IsBot  bool   `gorm:"default:false" json:"isBot"`
+
+	// PasswordHash is empty for guest accounts, which only exist through name-only guest play.
+	PasswordHash string `gorm:"size:100" json:"-"`
+
+	// Glicko-2 rating, updated after every finished game.
+	Rating           float64 `gorm:"default:1500" json:"rating"`
+	RatingDeviation  float64 `gorm:"default:350" json:"ratingDeviation"`
+	RatingVolatility float64 `gorm:"default:0.06" json:"ratingVolatility"`
+
+	// Version is bumped on every update; updates based on an older version are rejected.
+	Version int64 `gorm:"not null;default:1" json:"-"`
 
 	CreatedAt time.Time `json:"-"`
 	UpdatedAt time.Time `json:"-"`
 }
 
+// IsRegistered reports whether the player has claimed their name with a password.
+func (p *Player) IsRegistered() bool {
+	return p.PasswordHash != ""
+}
+
+// AuthClaims is the identity carried by a signed session token.
+type AuthClaims struct {
+	PlayerID   uint      `json:"pid"`
+	PlayerName string    `json:"name"`
+	Guest      bool      `json:"guest"`
+	ExpiresAt  time.Time `json:"exp"`
+}
+
+// Room privacy settings, stored in Room.Privacy.
+const (
+	RoomPublic     = "public"   // Anyone who knows the room ID may join or watch.
+	RoomPassword   = "password" // Joining or watching requires the room password or an invite.
+	RoomInviteOnly = "invite"   // Joining or watching requires an invite.
+)
+
+// Room is the place where a series of games is played. It owns its settings, chosen
+// by the player who created it, its two seats, the series score and its current game.
+type Room struct {
+	ID           string `gorm:"primaryKey;size:50" json:"id"`
+	OwnerID      *uint  `json:"ownerID"`
+	Privacy      string `gorm:"size:10;not null;default:public" json:"privacy"`
+	PasswordHash string `gorm:"size:100" json:"-"`
+
+	// The room's two players, in the order they sat down. They keep their seats across
+	// the room's games, whichever symbol they play.
+	PlayerOneID *uint `json:"playerOneID"`
+	PlayerTwoID *uint `json:"playerTwoID"`
+
+	// Series score over the room's finished games. BestOf is 1 for rooms playing
+	// independent games, or 3, 5 or 7 for a series; the score restarts with each series.
+	BestOf        int `gorm:"not null;default:1" json:"bestOf"`
+	PlayerOneWins int `gorm:"not null;default:0" json:"playerOneWins"`
+	PlayerTwoWins int `gorm:"not null;default:0" json:"playerTwoWins"`
+	Draws         int `gorm:"not null;default:0" json:"draws"`
+
+	// SwapSides makes the players exchange X and O on every rematch. Series always alternate.
+	SwapSides bool `gorm:"not null;default:false" json:"swapSides"`
+
+	// CurrentGameID is the game being played in the room, or the last one played.
+	CurrentGameID *uint `json:"currentGameID"`
+
+	// Version is bumped on every update; updates based on an older version are rejected.
+	Version int64 `gorm:"not null;default:1" json:"-"`
+
+	CreatedAt time.Time  `json:"createdAt"`
+	UpdatedAt time.Time  `json:"-"`
+	ClosedAt  *time.Time `json:"closedAt"` // Set when the room expires; a new game reopens it.
+}
+
+// IsPrivate reports whether entering the room requires a password or an invite.
+func (r *Room) IsPrivate() bool {
+	return r.Privacy != RoomPublic
+}
+
+// IsSeated reports whether the player holds one of the room's seats.
+func (r *Room) IsSeated(playerID uint) bool {
+	return (r.PlayerOneID != nil && *r.PlayerOneID == playerID) ||
+		(r.PlayerTwoID != nil && *r.PlayerTwoID == playerID)
+}
+
+// TakeSeats gives the game's players, X first, the room's free seats.
+func (r *Room) TakeSeats(game *Game) {
+	for _, id := range []*uint{game.PlayerXID, game.PlayerOID} {
+		if id == nil || r.IsSeated(*id) {
+			continue
+		}
+		playerID := *id
+		switch {
+		case r.PlayerOneID == nil:
+			r.PlayerOneID = &playerID
+		case r.PlayerTwoID == nil:
+			r.PlayerTwoID = &playerID
+		}
+	}
+}
+
+// IsSeries reports whether the room plays best-of-N series.
+func (r *Room) IsSeries() bool {
+	return r.BestOf > 1
+}
+
+// SwapsOnRematch reports whether the players exchange X and O when the room starts its next game.
+func (r *Room) SwapsOnRematch() bool {
+	return r.SwapSides || r.IsSeries()
+}
+
+// SeriesOver reports whether a player has won the current series.
+func (r *Room) SeriesOver() bool {
+	need := r.BestOf/2 + 1
+	return r.IsSeries() && (r.PlayerOneWins >= need || r.PlayerTwoWins >= need)
+}
+
+// SeriesWinnerID returns the winner of the current series, or nil while it is undecided.
+func (r *Room) SeriesWinnerID() *uint {
+	if !r.SeriesOver() {
+		return nil
+	}
+	if r.PlayerOneWins > r.PlayerTwoWins {
+		return r.PlayerOneID
+	}
+	return r.PlayerTwoID
+}
+
+// GamesPlayed returns how many games of the current series have finished.
+func (r *Room) GamesPlayed() int {
+	return r.PlayerOneWins + r.PlayerTwoWins + r.Draws
+}
+
+// WinsOf returns the series wins of the given seated player, 0 for anybody else.
+func (r *Room) WinsOf(playerID *uint) int {
+	switch {
+	case playerID == nil:
+		return 0
+	case r.PlayerOneID != nil && *r.PlayerOneID == *playerID:
+		return r.PlayerOneWins
+	case r.PlayerTwoID != nil && *r.PlayerTwoID == *playerID:
+		return r.PlayerTwoWins
+	}
+	return 0
+}
+
+// ResetScore starts a new series between the seated players.
+func (r *Room) ResetScore() {
+	r.PlayerOneWins, r.PlayerTwoWins, r.Draws = 0, 0, 0
+}
+
+// Reopen starts a new session in a closed room: its seats and series score are cleared.
+func (r *Room) Reopen() {
+	r.ClosedAt = nil
+	r.PlayerOneID, r.PlayerTwoID = nil, nil
+	r.ResetScore()
+}
+
+// RecordResult adds a finished game's result to the series score.
+// winnerID is nil for a draw; a winner without a room seat is ignored.
+func (r *Room) RecordResult(winnerID *uint) {
+	switch {
+	case winnerID == nil:
+		r.Draws++
+	case r.PlayerOneID != nil && *r.PlayerOneID == *winnerID:
+		r.PlayerOneWins++
+	case r.PlayerTwoID != nil && *r.PlayerTwoID == *winnerID:
+		r.PlayerTwoWins++
+	}
+}
+
+// RoomAccess holds the credentials a player presents to enter a private room.
+// When the player creates a password-protected room, Password becomes its password.
+type RoomAccess struct {
+	Password string
+	Invite   string // A signed invite token for the room.
+}
+
+// InviteClaims is what a signed room invite grants: entry to one room until it expires.
+type InviteClaims struct {
+	RoomID    string    `json:"room"`
+	ExpiresAt time.Time `json:"exp"`
+}
+
 // Game represents a single Tic-Tac-Toe match.
 type Game struct {
 	gorm.Model
-	RoomID      string `gorm:"size:50;not null" json:"roomID"`
+	RoomID      string `gorm:"size:50;not null" json:"roomID"` // References rooms.id.
 	PlayerXID   *uint  `json:"playerXID"`
 	PlayerX     Player `json:"playerX" gorm:"foreignKey:PlayerXID"`
 	PlayerOID   *uint  `json:"playerOID"`
 	PlayerO     Player `json:"playerO" gorm:"foreignKey:PlayerOID"`
 	WinnerID    *uint  `json:"winnerID"`
 	Winner      Player `json:"winner" gorm:"foreignKey:WinnerID"`
+	Ruleset     string `gorm:"size:20;not null;default:classic" json:"ruleset"`
 	Status      string `gorm:"size:20;not null" json:"status"`
-	Board       string `gorm:"type:char(9);not null" json:"board"`
+	Board       string `gorm:"type:text;not null" json:"board"`
+	BoardWidth  int    `gorm:"not null;default:3" json:"boardWidth"`
+	BoardHeight int    `gorm:"not null;default:3" json:"boardHeight"`
+	WinLength   int    `gorm:"not null;default:3" json:"winLength"`
 	CurrentTurn string `gorm:"type:char(1);not null" json:"currentTurn"`
+
+	// Ultimate ruleset state: the sub-board the next move is forced into (nil when free)
+	// and the result of each sub-board ('X', 'O', 'D' for a draw, ' ' while undecided).
+	ActiveBoard     *int   `json:"activeBoard"`
+	SubBoardWinners string `gorm:"size:9" json:"subBoardWinners,omitempty"`
+
+	// Computer opponent: its difficulty and the symbol it plays. Empty for human-only games.
+	BotLevel  string `gorm:"size:10" json:"botLevel,omitempty"`
+	BotSymbol string `gorm:"size:1" json:"botSymbol,omitempty"`
+
+	// Time control, in seconds: either MoveTime per move, or a BaseTime bank plus Increment per move.
+	MoveTime  int `gorm:"not null;default:0" json:"moveTime"`
+	BaseTime  int `gorm:"not null;default:0" json:"baseTime"`
+	Increment int `gorm:"not null;default:0" json:"increment"`
+
+	// Clock state, owned by the server: milliseconds left for each player when the
+	// current turn started, and when it started (nil while the clock is stopped).
+	TimeLeftX     int64      `gorm:"not null;default:0" json:"timeLeftX"`
+	TimeLeftO     int64      `gorm:"not null;default:0" json:"timeLeftO"`
+	TurnStartedAt *time.Time `json:"turnStartedAt"`
+
+	EndReason string `gorm:"size:20" json:"endReason,omitempty"`
+
+	// Per-seat secrets handed only to the seated player, so a client that reconnects
+	// mid-game (e.g. after a page refresh) can reclaim its seat instead of observing.
+	ResumeTokenX string `gorm:"size:64" json:"-"`
+	ResumeTokenO string `gorm:"size:64" json:"-"`
+
+	// Version is bumped on every update; updates based on an older version are rejected.
+	Version int64 `gorm:"not null;default:1" json:"version"`
+}
+
+// Reasons a game can end with, stored in Game.EndReason.
+const (
+	EndReasonWin       = "win"
+	EndReasonDraw      = "draw"
+	EndReasonTimeout   = "timeout"
+	EndReasonAbandoned = "abandoned" // A player disconnected and did not return within the grace period.
+	EndReasonExpired   = "expired"   // A waiting game never got an opponent.
+)
+
+// MovesPlayed counts the cells filled on the board.
+func (g *Game) MovesPlayed() int {
+	return len(g.Board) - strings.Count(g.Board, " ")
+}
+
+// HasClock reports whether the game is played with a time control.
+func (g *Game) HasClock() bool {
+	return g.MoveTime > 0 || g.BaseTime > 0
+}
+
+// SeatOf returns the symbol the given player plays in this game, or "" if they are not seated.
+func (g *Game) SeatOf(playerID uint) string {
+	switch {
+	case g.PlayerXID != nil && *g.PlayerXID == playerID:
+		return "X"
+	case g.PlayerOID != nil && *g.PlayerOID == playerID:
+		return "O"
+	}
+	return ""
+}
+
+// ResumeTokenOf returns the resume token of the given player's seat, or "" if they are not seated.
+func (g *Game) ResumeTokenOf(playerID uint) string {
+	switch g.SeatOf(playerID) {
+	case "X":
+		return g.ResumeTokenX
+	case "O":
+		return g.ResumeTokenO
+	}
+	return ""
+}
+
+// IsBotTurn reports whether the game is waiting for the computer opponent to move.
+func (g *Game) IsBotTurn() bool {
+	return g.BotLevel != "" && g.Status == "in_progress" && g.CurrentTurn == g.BotSymbol
+}
+
+// GameConfig holds the options chosen by the player who creates a room.
+// They only apply when a new game is created; joining an existing room ignores them.
+// Zero values let the ruleset choose its defaults.
+type GameConfig struct {
+	Ruleset   string `json:"ruleset,omitempty"`
+	Width     int    `json:"width,omitempty"`
+	Height    int    `json:"height,omitempty"`
+	WinLength int    `json:"winLength,omitempty"`
+	BotLevel  string `json:"botLevel,omitempty"`  // "random", "greedy" or "perfect"; empty for a human opponent.
+	BotSymbol string `json:"botSymbol,omitempty"` // Symbol the bot plays; defaults to "O".
+	MoveTime  int    `json:"moveTime,omitempty"`  // Seconds per move; cannot be combined with BaseTime.
+	BaseTime  int    `json:"baseTime,omitempty"`  // Seconds per player for the whole game.
+	Increment int    `json:"increment,omitempty"` // Seconds added to the bank after each move (with BaseTime).
+	Privacy   string `json:"privacy,omitempty"`   // RoomPublic (default), RoomPassword or RoomInviteOnly; kept by the room, not the game.
+	BestOf    int    `json:"bestOf,omitempty"`    // 3, 5 or 7 to play a series; kept by the room, not the game.
+	SwapSides bool   `json:"swapSides,omitempty"` // Exchange X and O on every rematch; kept by the room, not the game.
+}
+
+// Config returns the options a game was created with, so a new game can reuse them.
+func (g *Game) Config() GameConfig {
+	return GameConfig{
+		Ruleset:   g.Ruleset,
+		Width:     g.BoardWidth,
+		Height:    g.BoardHeight,
+		WinLength: g.WinLength,
+		BotLevel:  g.BotLevel,
+		BotSymbol: g.BotSymbol,
+		MoveTime:  g.MoveTime,
+		BaseTime:  g.BaseTime,
+		Increment: g.Increment,
+	}
+}
+
+// RatingHistory records a player's rating after a rated game, for charts and auditing.
+type RatingHistory struct {
+	ID               uint      `gorm:"primaryKey" json:"id"`
+	PlayerID         uint      `gorm:"not null" json:"playerID"`
+	GameID           *uint     `json:"gameID"`
+	Rating           float64   `gorm:"not null" json:"rating"`
+	RatingDeviation  float64   `gorm:"not null" json:"ratingDeviation"`
+	RatingVolatility float64   `gorm:"not null" json:"ratingVolatility"`
+	CreatedAt        time.Time `json:"createdAt"`
+}
+
+// TableName keeps the history in a single "rating_history" table.
+func (RatingHistory) TableName() string {
+	return "rating_history"
+}
+
+// SeriesResult records a finished best-of-N series of a room.
+type SeriesResult struct {
+	ID            uint      `gorm:"primaryKey" json:"id"`
+	RoomID        string    `gorm:"size:50;not null" json:"roomID"`
+	BestOf        int       `gorm:"not null" json:"bestOf"`
+	PlayerOneID   *uint     `json:"playerOneID"`
+	PlayerOne     Player    `gorm:"foreignKey:PlayerOneID" json:"playerOne"`
+	PlayerTwoID   *uint     `json:"playerTwoID"`
+	PlayerTwo     Player    `gorm:"foreignKey:PlayerTwoID" json:"playerTwo"`
+	PlayerOneWins int       `gorm:"not null" json:"playerOneWins"`
+	PlayerTwoWins int       `gorm:"not null" json:"playerTwoWins"`
+	Draws         int       `gorm:"not null" json:"draws"`
+	WinnerID      *uint     `json:"winnerID"`
+	CreatedAt     time.Time `json:"createdAt"`
 }
 
 // GameMove represents a single move made during a game.
-// Useful for auditing or implementing a replay feature.
+// Every accepted move is recorded, which powers auditing and the replay feature.
 type GameMove struct {
 	gorm.Model
-	GameID   uint
-	Game     Game `gorm:"foreignKey:GameID"`
-	PlayerID uint
-	Player   Player `gorm:"foreignKey:PlayerID"`
-	Position int    `gorm:"not null"`
-	Symbol   string `gorm:"type:char(1);not null"`
+	GameID     uint   `json:"gameID"`
+	Game       Game   `gorm:"foreignKey:GameID" json:"-"`
+	PlayerID   uint   `json:"playerID"`
+	Player     Player `gorm:"foreignKey:PlayerID" json:"player"`
+	MoveNumber int    `gorm:"not null" json:"moveNumber"`
+	Position   int    `gorm:"not null" json:"position"`
+	Symbol     string `gorm:"type:char(1);not null" json:"symbol"`
 }
@@ -0,0 +1,145 @@
+/*
+ * file: game_test.go
+ * package: domain
+ * description:
+ *     Tests for the Room aggregate: seats taken in order and kept across games, the
+ *     series score and its winner, and reopening a closed room.
+ */
+
+package domain
+
+import (
+	"testing"
+	"time"
+)
+
+// ids returns pointers to the given player IDs.
+func ids(values ...uint) []*uint {
+	pointers := make([]*uint, len(values))
+	for i := range values {
+		pointers[i] = &values[i]
+	}
+	return pointers
+}
+
+func TestTakeSeats(t *testing.T) {
+	players := ids(1, 2, 3)
+	alice, bob, carol := players[0], players[1], players[2]
+	tests := []struct {
+		name     string
+		room     Room
+		game     Game
+		wantOne  *uint
+		wantTwo  *uint
+		seatedID uint
+	}{
+		{"creator takes the first seat", Room{}, Game{PlayerXID: alice}, alice, nil, 1},
+		{"opponent takes the second", Room{PlayerOneID: alice}, Game{PlayerXID: alice, PlayerOID: bob}, alice, bob, 2},
+		{"seats survive a side swap", Room{PlayerOneID: alice, PlayerTwoID: bob}, Game{PlayerXID: bob, PlayerOID: alice}, alice, bob, 1},
+		{"a full room seats nobody else", Room{PlayerOneID: alice, PlayerTwoID: bob}, Game{PlayerXID: carol, PlayerOID: alice}, alice, bob, 2},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			room := tt.room
+			room.TakeSeats(&tt.game)
+			if !sameID(room.PlayerOneID, tt.wantOne) || !sameID(room.PlayerTwoID, tt.wantTwo) {
+				t.Errorf("seats = %v, %v; want %v, %v", deref(room.PlayerOneID), deref(room.PlayerTwoID), deref(tt.wantOne), deref(tt.wantTwo))
+			}
+			if !room.IsSeated(tt.seatedID) {
+				t.Errorf("IsSeated(%d) = false", tt.seatedID)
+			}
+			if room.IsSeated(3) {
+				t.Errorf("IsSeated(3) = true for a player without a seat")
+			}
+		})
+	}
+}
+
+func TestSeriesScore(t *testing.T) {
+	players := ids(1, 2, 3)
+	one, two, other := players[0], players[1], players[2]
+	tests := []struct {
+		name       string
+		bestOf     int
+		results    []*uint // Winner of each game; nil for a draw.
+		wantOne    int
+		wantTwo    int
+		wantDraws  int
+		wantOver   bool
+		wantWinner *uint
+	}{
+		{"independent games never end a series", 1, []*uint{one, one, one}, 3, 0, 0, false, nil},
+		{"best of 3 undecided", 3, []*uint{one, two}, 1, 1, 0, false, nil},
+		{"best of 3 decided", 3, []*uint{one, two, two}, 1, 2, 0, true, two},
+		{"draws do not count towards the series", 3, []*uint{nil, one, nil, nil}, 1, 0, 3, false, nil},
+		{"best of 5 needs three wins", 5, []*uint{one, one, two, one}, 3, 1, 0, true, one},
+		{"winner without a seat is ignored", 3, []*uint{other, one}, 1, 0, 0, false, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			room := Room{BestOf: tt.bestOf, PlayerOneID: one, PlayerTwoID: two}
+			for _, winner := range tt.results {
+				room.RecordResult(winner)
+			}
+			if room.PlayerOneWins != tt.wantOne || room.PlayerTwoWins != tt.wantTwo || room.Draws != tt.wantDraws {
+				t.Errorf("score = %d-%d (%d draws), want %d-%d (%d draws)", room.PlayerOneWins, room.PlayerTwoWins, room.Draws, tt.wantOne, tt.wantTwo, tt.wantDraws)
+			}
+			if room.SeriesOver() != tt.wantOver || !sameID(room.SeriesWinnerID(), tt.wantWinner) {
+				t.Errorf("over = %v won by %v, want %v won by %v", room.SeriesOver(), deref(room.SeriesWinnerID()), tt.wantOver, deref(tt.wantWinner))
+			}
+			if room.WinsOf(one) != tt.wantOne || room.WinsOf(two) != tt.wantTwo || room.WinsOf(other) != 0 || room.WinsOf(nil) != 0 {
+				t.Errorf("WinsOf() disagrees with the score")
+			}
+			if room.GamesPlayed() != tt.wantOne+tt.wantTwo+tt.wantDraws {
+				t.Errorf("GamesPlayed() = %d", room.GamesPlayed())
+			}
+		})
+	}
+}
+
+func TestSwapsOnRematch(t *testing.T) {
+	tests := []struct {
+		name      string
+		room      Room
+		wantSwaps bool
+	}{
+		{"independent games", Room{BestOf: 1}, false},
+		{"independent games, swapping sides", Room{BestOf: 1, SwapSides: true}, true},
+		{"series always alternate", Room{BestOf: 3}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.room.SwapsOnRematch(); got != tt.wantSwaps {
+				t.Errorf("SwapsOnRematch() = %v, want %v", got, tt.wantSwaps)
+			}
+		})
+	}
+}
+
+func TestReopenClearsSeatsAndScore(t *testing.T) {
+	closedAt := time.Now()
+	room := Room{ID: "room-1", Privacy: RoomPassword, BestOf: 3, PlayerOneID: ids(1)[0], PlayerTwoID: ids(2)[0], PlayerOneWins: 1, Draws: 1, ClosedAt: &closedAt}
+
+	room.Reopen()
+	if room.ClosedAt != nil || room.PlayerOneID != nil || room.PlayerTwoID != nil || room.GamesPlayed() != 0 {
+		t.Errorf("reopened room = %+v, want open with no seats and no score", room)
+	}
+	if room.Privacy != RoomPassword || room.BestOf != 3 {
+		t.Errorf("reopened room lost its settings: %+v", room)
+	}
+}
+
+// sameID reports whether two optional IDs are equal.
+func sameID(a, b *uint) bool {
+	return (a == nil && b == nil) || (a != nil && b != nil && *a == *b)
+}
+
+// deref returns an optional ID for printing.
+func deref(id *uint) interface{} {
+	if id == nil {
+		return nil
+	}
+	return *id
+}
@@ -8,27 +8,139 @@
 
 package ports
 
-import "github.com/juan10024/tictactoe-test/internal/core/domain"
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/juan10024/tictactoe-test/internal/core/domain"
+)
+
+// ErrNotFound is returned by repositories when the requested record does not exist.
+var ErrNotFound = errors.New("record not found")
+
+// ErrConflict matches every *ConflictError with errors.Is.
+var ErrConflict = errors.New("record was modified concurrently")
+
+/* ConflictError is returned by versioned updates when the record changed since it was read
+ * (its version no longer matches). Nothing is written; the caller must reload and decide
+ * whether to retry.
+ */
+type ConflictError struct {
+	Entity  string      // "game", "player" or "room".
+	ID      interface{} // The record that changed.
+	Version int64       // The version the caller read.
+}
+
+func (e *ConflictError) Error() string {
+	return fmt.Sprintf("%s %v was modified concurrently (read version %d)", e.Entity, e.ID, e.Version)
+}
+
+// Is makes errors.Is(err, ErrConflict) true for any conflict.
+func (e *ConflictError) Is(target error) bool {
+	return target == ErrConflict
+}
 
 /* GameRepository defines the contract for game data persistence.
  * Any data storage solution must implement this interface to be used by the core service.
+ * Update, RecordMove, UpdatePlayer and UpdateRatings are versioned: they only write if the
+ * record's Version is unchanged, bump it on success, and return a *ConflictError otherwise.
+ * GetByRoomID returns the game the room's CurrentGameID points to.
  */
 type GameRepository interface {
 	Create(game *domain.Game) error
 	Update(game *domain.Game) error
+	RecordMove(game *domain.Game, move *domain.GameMove) error
+	GetByID(id uint) (*domain.Game, error)
 	GetByRoomID(roomID string) (*domain.Game, error)
+	GetMovesByGameID(gameID uint) ([]domain.GameMove, error)
 	GetFinishedGamesByRoomID(roomID string) ([]domain.Game, error)
+	GetWaitingGamesBefore(before time.Time) ([]domain.Game, error)
+	GetOpenGames() ([]domain.Game, error)
 	GetOrCreatePlayerByName(name string) (*domain.Player, error)
+	GetOrCreateBotPlayer(name string) (*domain.Player, error)
+	GetPlayerByName(name string) (*domain.Player, error)
+	CreatePlayer(player *domain.Player) error
 	GetPlayerByID(id uint) (*domain.Player, error)
 	UpdatePlayer(player *domain.Player) error
+	UpdateRatings(players []*domain.Player, history []domain.RatingHistory) error
+}
+
+/* RoomRepository defines the contract for room persistence.
+ * UpdateRoom is versioned like the game and player updates of GameRepository.
+ */
+type RoomRepository interface {
+	// CreateRoom inserts a room unless one with the same ID exists, and returns the stored room.
+	CreateRoom(room *domain.Room) (*domain.Room, error)
+	// GetRoom returns ErrNotFound if the room does not exist.
+	GetRoom(id string) (*domain.Room, error)
+	GetRooms(ids []string) ([]domain.Room, error)
+	UpdateRoom(room *domain.Room) error
+	CreateSeriesResult(result *domain.SeriesResult) error
+}
+
+/* UnitOfWork runs a group of repository writes as a single transaction.
+ * The repositories passed to fn are bound to the transaction: if fn returns an error
+ * (or panics) every write made through them is rolled back, otherwise all are committed.
+ */
+type UnitOfWork interface {
+	Do(fn func(games GameRepository, rooms RoomRepository) error) error
+}
+
+/* RoomBroadcaster is the pub/sub channel that carries room messages between backend instances.
+ * Every message published for a room is handed to the subscribers of every instance, the
+ * publishing one included, so it reaches clients wherever they are connected.
+ */
+type RoomBroadcaster interface {
+	// Publish sends a message to the subscribers of all instances.
+	Publish(roomID string, message []byte) error
+	// Subscribe registers a handler for the messages published by any instance.
+	Subscribe(handler func(roomID string, message []byte))
 }
 
 // StatsRepository defines the contract for retrieving game statistics.
 type StatsRepository interface {
-	GetTopPlayers(limit int) ([]domain.Player, error)
+	GetTopPlayers(limit, minGames int) ([]domain.Player, error)
+	GetRatingHistory(playerID uint) ([]domain.RatingHistory, error)
 	GetGamesByRoomID(roomID string) ([]domain.Game, error)
+	GetSeriesByRoomID(roomID string) ([]domain.SeriesResult, error)
 	GetPlayerByName(name string) (*domain.Player, error)
 
 	CountGames() (int64, error)
 	CountPlayers() (int64, error)
 }
+
+/* GameRules defines the contract for a game variant (ruleset).
+ * Implementations own the board encoding, decide which moves are legal and
+ * detect when a game is over, so GameService stays independent of any variant.
+ */
+type GameRules interface {
+	// Name returns the identifier stored in domain.Game.Ruleset.
+	Name() string
+	// Setup validates the room options and initializes the board and turn of a freshly created game.
+	Setup(game *domain.Game, config domain.GameConfig) error
+	// LegalMoves returns every position the player to move may play.
+	LegalMoves(game *domain.Game) []int
+	// ApplyMove places the current turn's symbol at position, or fails if the move is illegal.
+	ApplyMove(game *domain.Game, position int) error
+	// Outcome reports whether the game is over and, if so, the winning symbol ("" for a draw).
+	Outcome(game *domain.Game) (finished bool, winner string)
+}
+
+// TokenIssuer defines the contract for issuing and verifying signed session tokens.
+type TokenIssuer interface {
+	Issue(claims domain.AuthClaims) (string, error)
+	Verify(token string) (*domain.AuthClaims, error)
+}
+
+// InviteIssuer defines the contract for issuing and verifying signed room invites.
+type InviteIssuer interface {
+	IssueInvite(claims domain.InviteClaims) (string, error)
+	VerifyInvite(token string) (*domain.InviteClaims, error)
+}
+
+// PasswordHasher defines the contract for hashing player passwords and checking them at login.
+type PasswordHasher interface {
+	Hash(password string) (string, error)
+	Compare(hash, password string) error
+}
@@ -0,0 +1,408 @@
+/*
+ * file: memory_store.go
+ * package: portstest
+ * description:
+ *     An in-memory implementation of the repository ports, so tests run the real
+ *     services without a database. Updates are versioned like the GORM repositories,
+ *     and a unit of work that fails is rolled back.
+ */
+
+package portstest
+
+import (
+	"maps"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/juan10024/tictactoe-test/internal/core/domain"
+	"github.com/juan10024/tictactoe-test/internal/core/ports"
+)
+
+/*
+ * MemoryStore implements ports.GameRepository, ports.RoomRepository, ports.StatsRepository
+ * and ports.UnitOfWork. Records are stored by value, so callers never share them.
+ * Units of work run one at a time; writes made outside one are not isolated from it.
+ */
+type MemoryStore struct {
+	mu      sync.Mutex
+	txMu    sync.Mutex
+	nextID  uint
+	now     time.Time
+	players map[uint]domain.Player
+	games   map[uint]domain.Game
+	rooms   map[string]domain.Room
+	moves   []domain.GameMove
+	ratings []domain.RatingHistory
+	series  []domain.SeriesResult
+}
+
+// NewMemoryStore returns an empty store whose clock starts at 2024-05-01 12:00 UTC.
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{
+		now:     time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC),
+		players: make(map[uint]domain.Player),
+		games:   make(map[uint]domain.Game),
+		rooms:   make(map[string]domain.Room),
+	}
+}
+
+// id and tick must be called with mu held. tick advances the clock so records sort by creation.
+func (s *MemoryStore) id() uint {
+	s.nextID++
+	return s.nextID
+}
+
+func (s *MemoryStore) tick() time.Time {
+	s.now = s.now.Add(time.Second)
+	return s.now
+}
+
+// withPlayers fills the players embedded in a game, as the GORM repository preloads them.
+func (s *MemoryStore) withPlayers(g domain.Game) domain.Game {
+	for _, seat := range []struct {
+		id     *uint
+		player *domain.Player
+	}{{g.PlayerXID, &g.PlayerX}, {g.PlayerOID, &g.PlayerO}, {g.WinnerID, &g.Winner}} {
+		*seat.player = domain.Player{}
+		if seat.id != nil {
+			*seat.player = s.players[*seat.id]
+		}
+	}
+	return g
+}
+
+// Do runs fn against the store and restores every record if fn fails or panics.
+func (s *MemoryStore) Do(fn func(games ports.GameRepository, rooms ports.RoomRepository) error) (err error) {
+	s.txMu.Lock()
+	defer s.txMu.Unlock()
+
+	saved := s.snapshot()
+	defer func() {
+		if p := recover(); p != nil {
+			s.restore(saved)
+			panic(p)
+		}
+		if err != nil {
+			s.restore(saved)
+		}
+	}()
+	return fn(s, s)
+}
+
+// snapshot copies the store's records, to be put back by restore.
+func (s *MemoryStore) snapshot() *MemoryStore {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return &MemoryStore{
+		nextID:  s.nextID,
+		now:     s.now,
+		players: maps.Clone(s.players),
+		games:   maps.Clone(s.games),
+		rooms:   maps.Clone(s.rooms),
+		moves:   slices.Clone(s.moves),
+		ratings: slices.Clone(s.ratings),
+		series:  slices.Clone(s.series),
+	}
+}
+
+func (s *MemoryStore) restore(saved *MemoryStore) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.nextID, s.now = saved.nextID, saved.now
+	s.players, s.games, s.rooms = saved.players, saved.games, saved.rooms
+	s.moves, s.ratings, s.series = saved.moves, saved.ratings, saved.series
+}
+
+func (s *MemoryStore) Create(game *domain.Game) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	game.ID = s.id()
+	game.CreatedAt = s.tick()
+	game.Version = 1
+	s.games[game.ID] = *game
+	return nil
+}
+
+func (s *MemoryStore) Update(game *domain.Game) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.games[game.ID].Version != game.Version {
+		return &ports.ConflictError{Entity: "game", ID: game.ID, Version: game.Version}
+	}
+	game.Version++
+	s.games[game.ID] = *game
+	return nil
+}
+
+func (s *MemoryStore) RecordMove(game *domain.Game, move *domain.GameMove) error {
+	if err := s.Update(game); err != nil {
+		return err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	move.ID = s.id()
+	move.GameID = game.ID
+	move.CreatedAt = s.tick()
+	s.moves = append(s.moves, *move)
+	return nil
+}
+
+func (s *MemoryStore) GetByID(id uint) (*domain.Game, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	g, ok := s.games[id]
+	if !ok {
+		return nil, ports.ErrNotFound
+	}
+	g = s.withPlayers(g)
+	return &g, nil
+}
+
+func (s *MemoryStore) GetByRoomID(roomID string) (*domain.Game, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	room, ok := s.rooms[roomID]
+	if !ok || room.CurrentGameID == nil {
+		return nil, ports.ErrNotFound
+	}
+	g := s.withPlayers(s.games[*room.CurrentGameID])
+	return &g, nil
+}
+
+func (s *MemoryStore) GetMovesByGameID(gameID uint) ([]domain.GameMove, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var moves []domain.GameMove
+	for _, m := range s.moves {
+		if m.GameID == gameID {
+			m.Player = s.players[m.PlayerID]
+			moves = append(moves, m)
+		}
+	}
+	sort.Slice(moves, func(i, j int) bool { return moves[i].MoveNumber < moves[j].MoveNumber })
+	return moves, nil
+}
+
+// findGames returns the games matching keep, newest first, with their players.
+func (s *MemoryStore) findGames(keep func(g domain.Game) bool) []domain.Game {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var games []domain.Game
+	for _, g := range s.games {
+		if keep(g) {
+			games = append(games, s.withPlayers(g))
+		}
+	}
+	sort.Slice(games, func(i, j int) bool { return games[i].CreatedAt.After(games[j].CreatedAt) })
+	return games
+}
+
+func (s *MemoryStore) GetFinishedGamesByRoomID(roomID string) ([]domain.Game, error) {
+	return s.findGames(func(g domain.Game) bool { return g.RoomID == roomID && g.Status == "finished" }), nil
+}
+
+func (s *MemoryStore) GetWaitingGamesBefore(before time.Time) ([]domain.Game, error) {
+	return s.findGames(func(g domain.Game) bool { return g.Status == "waiting" && g.CreatedAt.Before(before) }), nil
+}
+
+func (s *MemoryStore) GetOpenGames() ([]domain.Game, error) {
+	return s.findGames(func(g domain.Game) bool { return g.Status == "waiting" || g.Status == "in_progress" }), nil
+}
+
+func (s *MemoryStore) GetGamesByRoomID(roomID string) ([]domain.Game, error) {
+	return s.findGames(func(g domain.Game) bool { return g.RoomID == roomID }), nil
+}
+
+func (s *MemoryStore) GetOrCreatePlayerByName(name string) (*domain.Player, error) {
+	if p, err := s.GetPlayerByName(name); err == nil {
+		return p, nil
+	}
+	p := &domain.Player{Name: name, Rating: 1500, RatingDeviation: 350, RatingVolatility: 0.06}
+	return p, s.CreatePlayer(p)
+}
+
+func (s *MemoryStore) GetOrCreateBotPlayer(name string) (*domain.Player, error) {
+	if p, err := s.GetPlayerByName(name); err == nil {
+		return p, nil
+	}
+	p := &domain.Player{Name: name, IsBot: true, Rating: 1500, RatingDeviation: 350, RatingVolatility: 0.06}
+	return p, s.CreatePlayer(p)
+}
+
+func (s *MemoryStore) GetPlayerByName(name string) (*domain.Player, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, p := range s.players {
+		if strings.EqualFold(p.Name, name) {
+			return &p, nil
+		}
+	}
+	return nil, ports.ErrNotFound
+}
+
+func (s *MemoryStore) CreatePlayer(player *domain.Player) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	player.ID = s.id()
+	player.Version = 1
+	player.CreatedAt = s.tick()
+	s.players[player.ID] = *player
+	return nil
+}
+
+func (s *MemoryStore) GetPlayerByID(id uint) (*domain.Player, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	p, ok := s.players[id]
+	if !ok {
+		return nil, nil
+	}
+	return &p, nil
+}
+
+func (s *MemoryStore) UpdatePlayer(player *domain.Player) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.players[player.ID].Version != player.Version {
+		return &ports.ConflictError{Entity: "player", ID: player.ID, Version: player.Version}
+	}
+	player.Version++
+	s.players[player.ID] = *player
+	return nil
+}
+
+func (s *MemoryStore) UpdateRatings(players []*domain.Player, history []domain.RatingHistory) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, p := range players {
+		if s.players[p.ID].Version != p.Version {
+			return &ports.ConflictError{Entity: "player", ID: p.ID, Version: p.Version}
+		}
+	}
+	for _, p := range players {
+		stored := s.players[p.ID]
+		stored.Rating, stored.RatingDeviation, stored.RatingVolatility = p.Rating, p.RatingDeviation, p.RatingVolatility
+		stored.Version++
+		p.Version = stored.Version
+		s.players[p.ID] = stored
+	}
+	for _, h := range history {
+		h.ID = s.id()
+		h.CreatedAt = s.tick()
+		s.ratings = append(s.ratings, h)
+	}
+	return nil
+}
+
+func (s *MemoryStore) CreateRoom(room *domain.Room) (*domain.Room, error) {
+	s.mu.Lock()
+	if _, ok := s.rooms[room.ID]; !ok {
+		room.Version = 1
+		s.rooms[room.ID] = *room
+	}
+	s.mu.Unlock()
+	return s.GetRoom(room.ID)
+}
+
+func (s *MemoryStore) GetRoom(id string) (*domain.Room, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	room, ok := s.rooms[id]
+	if !ok {
+		return nil, ports.ErrNotFound
+	}
+	return &room, nil
+}
+
+func (s *MemoryStore) GetRooms(ids []string) ([]domain.Room, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var rooms []domain.Room
+	for _, id := range ids {
+		if room, ok := s.rooms[id]; ok {
+			rooms = append(rooms, room)
+		}
+	}
+	return rooms, nil
+}
+
+func (s *MemoryStore) UpdateRoom(room *domain.Room) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.rooms[room.ID].Version != room.Version {
+		return &ports.ConflictError{Entity: "room", ID: room.ID, Version: room.Version}
+	}
+	room.Version++
+	s.rooms[room.ID] = *room
+	return nil
+}
+
+func (s *MemoryStore) CreateSeriesResult(result *domain.SeriesResult) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	result.ID = s.id()
+	result.CreatedAt = s.tick()
+	s.series = append(s.series, *result)
+	return nil
+}
+
+func (s *MemoryStore) GetTopPlayers(limit, minGames int) ([]domain.Player, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var players []domain.Player
+	for _, p := range s.players {
+		if p.Wins+p.Draws+p.Losses >= minGames {
+			players = append(players, p)
+		}
+	}
+	sort.Slice(players, func(i, j int) bool { return players[i].Rating > players[j].Rating })
+	if len(players) > limit {
+		players = players[:limit]
+	}
+	return players, nil
+}
+
+func (s *MemoryStore) GetRatingHistory(playerID uint) ([]domain.RatingHistory, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var history []domain.RatingHistory
+	for _, h := range s.ratings {
+		if h.PlayerID == playerID {
+			history = append(history, h)
+		}
+	}
+	return history, nil
+}
+
+func (s *MemoryStore) GetSeriesByRoomID(roomID string) ([]domain.SeriesResult, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var results []domain.SeriesResult
+	for i := len(s.series) - 1; i >= 0; i-- {
+		if r := s.series[i]; r.RoomID == roomID {
+			if r.PlayerOneID != nil {
+				r.PlayerOne = s.players[*r.PlayerOneID]
+			}
+			if r.PlayerTwoID != nil {
+				r.PlayerTwo = s.players[*r.PlayerTwoID]
+			}
+			results = append(results, r)
+		}
+	}
+	return results, nil
+}
+
+func (s *MemoryStore) CountGames() (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return int64(len(s.games)), nil
+}
+
+func (s *MemoryStore) CountPlayers() (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return int64(len(s.players)), nil
+}
@@ -0,0 +1,186 @@
+/*
+ * file: client.go
+ * package: protocol
+ * description:
+ *     Defines the messages a client sends on the game room socket and decodes them,
+ *     translating the version 1 names older clients use.
+ */
+
+package protocol
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+)
+
+// Client to server message types.
+const (
+	TypeMove             = "move"             // Payload: MovePayload.
+	TypeConfirmGameStart = "confirmGameStart" // Payload: ConfirmGameStartPayload.
+	TypeRematchOffer     = "rematchOffer"     // No payload.
+	TypeRematchAccept    = "rematchAccept"    // No payload.
+	TypeRematchDecline   = "rematchDecline"   // No payload.
+	TypeResync           = "resync"           // Payload: ResyncPayload.
+)
+
+// Version 1 names of the rematch messages, translated by DecodeClientMessage.
+const (
+	legacyPlayAgainRequest     = "playAgainRequest"
+	legacyPlayAgainMenuRequest = "play_again_menu_request"
+	legacyReset                = "reset"
+	legacyPlayAgainResponse    = "play_again_response" // Payload: {"accepted": bool}.
+)
+
+var (
+	// ErrMalformedMessage is returned for a message that is not a JSON object with a type.
+	ErrMalformedMessage = errors.New("malformed message: expected a JSON object with a type")
+	// ErrUnknownType is returned for a message type the negotiated version does not define.
+	ErrUnknownType = errors.New("unknown message type")
+)
+
+/*
+ * ClientMessage is a message received from a client.
+ *
+ * Fields:
+ *   - Type (string): One of the Type* constants.
+ *   - RequestID (string): Optional client-chosen ID, echoed in the ack or error answering the message.
+ *   - Payload (json.RawMessage): The type's payload, decoded with the payload methods.
+ */
+type ClientMessage struct {
+	Type      string          `json:"type"`
+	RequestID string          `json:"requestId,omitempty"`
+	Payload   json.RawMessage `json:"payload,omitempty"`
+}
+
+// MovePayload is the payload of a move: the cell to play, counted row by row from 0.
+type MovePayload struct {
+	Position int `json:"position"`
+}
+
+// ConfirmGameStartPayload is the payload of a confirmGameStart message.
+type ConfirmGameStartPayload struct {
+	Confirmed bool `json:"confirmed"`
+}
+
+// ResyncPayload is the payload of a resync: the seq of the last message the client applied.
+// The server answers with a gameStateUpdate snapshot carrying the resync's requestId.
+type ResyncPayload struct {
+	LastSeq uint64 `json:"lastSeq"`
+}
+
+/*
+ * DecodeClientMessage decodes a client message and checks its type against the
+ * negotiated version. Version 1 names are translated to their current type.
+ *
+ * Parameters:
+ *   - data ([]byte): The raw WebSocket message.
+ *   - version (int): The connection's protocol version.
+ *
+ * Returns:
+ *   - ClientMessage: The message; its RequestID is set whenever it could be read, even on error.
+ *   - error: ErrMalformedMessage, ErrUnknownType, or a payload decoding error.
+ */
+func DecodeClientMessage(data []byte, version int) (ClientMessage, error) {
+	var msg ClientMessage
+	trimmed := bytes.TrimSpace(data)
+
+	if version == Version1 && len(trimmed) > 0 && trimmed[0] != '{' {
+		var position int
+		if err := json.Unmarshal(trimmed, &position); err != nil {
+			return msg, ErrMalformedMessage
+		}
+		payload, _ := json.Marshal(MovePayload{Position: position})
+		return ClientMessage{Type: TypeMove, Payload: payload}, nil
+	}
+
+	if err := json.Unmarshal(trimmed, &msg); err != nil || msg.Type == "" {
+		return msg, ErrMalformedMessage
+	}
+
+	switch msg.Type {
+	case TypeMove, TypeConfirmGameStart, TypeRematchOffer, TypeRematchAccept, TypeRematchDecline, TypeResync:
+		return msg, nil
+	}
+
+	if version == Version1 {
+		switch msg.Type {
+		case legacyPlayAgainRequest, legacyPlayAgainMenuRequest, legacyReset:
+			msg.Type, msg.Payload = TypeRematchOffer, nil
+			return msg, nil
+		case legacyPlayAgainResponse:
+			var answer struct {
+				Accepted bool `json:"accepted"`
+			}
+			if err := decodePayload(msg.Payload, &answer); err != nil {
+				return msg, err
+			}
+			msg.Type, msg.Payload = TypeRematchDecline, nil
+			if answer.Accepted {
+				msg.Type = TypeRematchAccept
+			}
+			return msg, nil
+		}
+	}
+	return msg, fmt.Errorf("%w: %s", ErrUnknownType, msg.Type)
+}
+
+/*
+ * Move decodes the payload of a move message.
+ *
+ * Returns:
+ *   - MovePayload: The move.
+ *   - error: An error if the payload is missing or invalid.
+ */
+func (m ClientMessage) Move() (MovePayload, error) {
+	var payload MovePayload
+	if len(m.Payload) == 0 {
+		return payload, errors.New("a move requires a payload with a position")
+	}
+	return payload, decodePayload(m.Payload, &payload)
+}
+
+/*
+ * ConfirmGameStart decodes the payload of a confirmGameStart message.
+ *
+ * Returns:
+ *   - ConfirmGameStartPayload: The confirmation.
+ *   - error: An error if the payload is invalid.
+ */
+func (m ClientMessage) ConfirmGameStart() (ConfirmGameStartPayload, error) {
+	var payload ConfirmGameStartPayload
+	return payload, decodePayload(m.Payload, &payload)
+}
+
+/*
+ * Resync decodes the payload of a resync message.
+ *
+ * Returns:
+ *   - ResyncPayload: The client's last applied sequence number; 0 if it sent none.
+ *   - error: An error if the payload is invalid.
+ */
+func (m ClientMessage) Resync() (ResyncPayload, error) {
+	var payload ResyncPayload
+	return payload, decodePayload(m.Payload, &payload)
+}
+
+/*
+ * decodePayload decodes an optional payload into target.
+ *
+ * Parameters:
+ *   - raw (json.RawMessage): The payload; empty leaves target unchanged.
+ *   - target (interface{}): Pointer to the payload type.
+ *
+ * Returns:
+ *   - error: An error if the payload does not match the type.
+ */
+func decodePayload(raw json.RawMessage, target interface{}) error {
+	if len(raw) == 0 {
+		return nil
+	}
+	if err := json.Unmarshal(raw, target); err != nil {
+		return fmt.Errorf("invalid payload: %v", err)
+	}
+	return nil
+}
@@ -0,0 +1,95 @@
+/*
+ * file: lobby.go
+ * package: protocol
+ * description:
+ *     Defines the messages the server sends on the lobby socket (/ws/lobby): a snapshot
+ *     of the open rooms on connect, then an event each time a room appears, starts or ends.
+ *     The lobby socket is not versioned and its messages carry no seq.
+ */
+
+package protocol
+
+import (
+	"time"
+
+	"github.com/juan10024/tictactoe-test/internal/core/domain"
+)
+
+// Lobby message types.
+const (
+	TypeLobbySnapshot = "lobbySnapshot" // First message: every open room.
+	TypeRoomCreated   = "roomCreated"   // A room is waiting for an opponent.
+	TypeRoomStarted   = "roomStarted"   // A room's game started.
+	TypeRoomFinished  = "roomFinished"  // A room's game ended.
+)
+
+// LobbyRoom describes an open room as shown in the lobby.
+type LobbyRoom struct {
+	RoomID      string    `json:"roomId"`
+	GameID      uint      `json:"gameId"`
+	Status      string    `json:"status"`
+	Privacy     string    `json:"privacy"` // Public or password-protected; invite-only rooms are never listed.
+	EndReason   string    `json:"endReason,omitempty"`
+	Ruleset     string    `json:"ruleset"`
+	BoardWidth  int       `json:"boardWidth"`
+	BoardHeight int       `json:"boardHeight"`
+	WinLength   int       `json:"winLength"`
+	PlayerX     string    `json:"playerX,omitempty"`
+	PlayerO     string    `json:"playerO,omitempty"`
+	BotLevel    string    `json:"botLevel,omitempty"`
+	MoveTime    int       `json:"moveTime"`   // Seconds per move; 0 without a per-move clock.
+	BaseTime    int       `json:"baseTime"`   // Seconds per player for the whole game; 0 without a bank.
+	Increment   int       `json:"increment"`  // Seconds added to the bank after each move.
+	Spectators  int       `json:"spectators"` // Observers connected to any instance.
+	CreatedAt   time.Time `json:"createdAt"`
+}
+
+// LobbyEvent is sent to lobby subscribers when a room appears, starts or ends.
+type LobbyEvent struct {
+	Type string    `json:"type"` // TypeRoomCreated, TypeRoomStarted or TypeRoomFinished.
+	Room LobbyRoom `json:"room"`
+}
+
+// LobbySnapshot is the first message a lobby subscriber receives: every open room.
+type LobbySnapshot struct {
+	Type  string      `json:"type"` // TypeLobbySnapshot.
+	Rooms []LobbyRoom `json:"rooms"`
+}
+
+/*
+ * NewLobbyRoom maps a room's current game to its lobby view.
+ *
+ * Parameters:
+ *   - game (*domain.Game): The room's current game, with its players preloaded.
+ *   - privacy (string): The room's privacy setting.
+ *   - spectators (int): The observers connected to the room.
+ *
+ * Returns:
+ *   - LobbyRoom: The room as shown in the lobby.
+ */
+func NewLobbyRoom(game *domain.Game, privacy string, spectators int) LobbyRoom {
+	room := LobbyRoom{
+		RoomID:      game.RoomID,
+		GameID:      game.ID,
+		Status:      game.Status,
+		Privacy:     privacy,
+		EndReason:   game.EndReason,
+		Ruleset:     game.Ruleset,
+		BoardWidth:  game.BoardWidth,
+		BoardHeight: game.BoardHeight,
+		WinLength:   game.WinLength,
+		BotLevel:    game.BotLevel,
+		MoveTime:    game.MoveTime,
+		BaseTime:    game.BaseTime,
+		Increment:   game.Increment,
+		Spectators:  spectators,
+		CreatedAt:   game.CreatedAt,
+	}
+	if game.PlayerXID != nil {
+		room.PlayerX = game.PlayerX.Name
+	}
+	if game.PlayerOID != nil {
+		room.PlayerO = game.PlayerO.Name
+	}
+	return room
+}
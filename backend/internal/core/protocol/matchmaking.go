@@ -0,0 +1,95 @@
+/*
+ * file: matchmaking.go
+ * package: protocol
+ * description:
+ *     Defines the messages of the quick play socket (/ws/matchmaking): the client
+ *     enqueues with its preferences or cancels, and the server confirms, refuses or
+ *     tells the player where their match is. The socket is not versioned and its
+ *     messages carry no seq.
+ */
+
+package protocol
+
+import "github.com/juan10024/tictactoe-test/internal/core/domain"
+
+// Matchmaking message types sent by the client.
+const (
+	TypeEnqueue = "enqueue" // Payload: MatchPreferences.
+	TypeCancel  = "cancel"  // No payload.
+)
+
+// Matchmaking message types sent by the server; refusals use TypeError.
+const (
+	TypeQueued     = "queued"     // The player entered the queue.
+	TypeCancelled  = "cancelled"  // The player left the queue.
+	TypeMatchFound = "matchFound" // The player was paired; the socket closes after it.
+)
+
+/*
+ * MatchPreferences are the options a player enters the queue with.
+ *
+ * Fields:
+ *   - Ruleset (string): The ruleset to play; "" accepts the opponent's choice (classic if neither has one).
+ *   - MinRating (float64): The lowest opponent rating accepted; 0 for no lower bound.
+ *   - MaxRating (float64): The highest opponent rating accepted; 0 for no upper bound.
+ */
+type MatchPreferences struct {
+	Ruleset   string  `json:"ruleset"`
+	MinRating float64 `json:"minRating"`
+	MaxRating float64 `json:"maxRating"`
+}
+
+// MatchmakingRequest is a message sent by a client of the matchmaking socket.
+type MatchmakingRequest struct {
+	Type    string           `json:"type"` // TypeEnqueue or TypeCancel.
+	Payload MatchPreferences `json:"payload"`
+}
+
+// Queued confirms that the player entered the queue with the given preferences.
+type Queued struct {
+	Type        string           `json:"type"` // TypeQueued.
+	Preferences MatchPreferences `json:"preferences"`
+}
+
+// Cancelled confirms that the player left the queue.
+type Cancelled struct {
+	Type string `json:"type"` // TypeCancelled.
+}
+
+// MatchmakingError tells the player why their message was refused.
+type MatchmakingError struct {
+	Type    string `json:"type"` // TypeError.
+	Message string `json:"message"`
+}
+
+// MatchFound tells a queued player where their game is.
+type MatchFound struct {
+	Type        string  `json:"type"` // TypeMatchFound.
+	RoomID      string  `json:"roomId"`
+	Symbol      string  `json:"symbol"`
+	ResumeToken string  `json:"resumeToken,omitempty"`
+	Ruleset     string  `json:"ruleset"`
+	Opponent    *Player `json:"opponent"`
+}
+
+/*
+ * NewMatchFound builds the message telling a player about their match.
+ *
+ * Parameters:
+ *   - game (*domain.Game): The game created for the match.
+ *   - player (*domain.Player): The player the message is for.
+ *   - opponent (*domain.Player): The other player.
+ *
+ * Returns:
+ *   - *MatchFound: The message.
+ */
+func NewMatchFound(game *domain.Game, player, opponent *domain.Player) *MatchFound {
+	return &MatchFound{
+		Type:        TypeMatchFound,
+		RoomID:      game.RoomID,
+		Symbol:      game.SeatOf(player.ID),
+		ResumeToken: game.ResumeTokenOf(player.ID),
+		Ruleset:     game.Ruleset,
+		Opponent:    NewPlayer(opponent),
+	}
+}
@@ -0,0 +1,109 @@
+/*
+ * file: protocol.go
+ * package: protocol
+ * description:
+ *     Defines the game room WebSocket protocol (/ws/join/{roomId}): the versions the
+ *     server speaks, how one is negotiated and the framing shared by every message.
+ *
+ *     Negotiation: the client lists the versions it speaks as WebSocket subprotocols
+ *     ("tictactoe.v2", "tictactoe.v1"); the server picks the newest one it supports and
+ *     confirms it in the handshake and in the first message, "welcome". A client that
+ *     asks for no subprotocol speaks version 1.
+ *
+ *     Framing: every message is a JSON object with a "type". Client messages may carry
+ *     a "requestId", echoed in the "ack" or "error" answering them. Every server message
+ *     carries a "seq" that increases by one with each message written on the connection,
+ *     starting at 1 with "welcome", so a gap means a message was lost.
+ *
+ *     Version 1 also accepts the names older clients use and a bare JSON integer as a
+ *     move; version 2 only accepts the message types defined in client.go.
+ */
+
+package protocol
+
+import (
+	"bytes"
+	"strconv"
+	"strings"
+)
+
+// Protocol versions.
+const (
+	Version1 = 1
+	Version2 = 2
+
+	// Current is the newest version the server speaks.
+	Current = Version2
+)
+
+// subprotocolPrefix names a version as a WebSocket subprotocol: "tictactoe.v2".
+const subprotocolPrefix = "tictactoe.v"
+
+/*
+ * Subprotocols lists the WebSocket subprotocols the server accepts, newest first,
+ * which is the order the server prefers them in.
+ *
+ * Returns:
+ *   - []string: The subprotocol names.
+ */
+func Subprotocols() []string {
+	return []string{Subprotocol(Version2), Subprotocol(Version1)}
+}
+
+/*
+ * Subprotocol returns the WebSocket subprotocol name of a version.
+ *
+ * Parameters:
+ *   - version (int): The protocol version.
+ *
+ * Returns:
+ *   - string: The subprotocol, such as "tictactoe.v2".
+ */
+func Subprotocol(version int) string {
+	return subprotocolPrefix + strconv.Itoa(version)
+}
+
+/*
+ * VersionOf returns the version a negotiated subprotocol stands for.
+ *
+ * Parameters:
+ *   - subprotocol (string): The subprotocol selected in the handshake, "" if none.
+ *
+ * Returns:
+ *   - int: The version; Version1 when no supported subprotocol was selected.
+ */
+func VersionOf(subprotocol string) int {
+	v, err := strconv.Atoi(strings.TrimPrefix(subprotocol, subprotocolPrefix))
+	if err != nil || !strings.HasPrefix(subprotocol, subprotocolPrefix) || v < Version1 || v > Current {
+		return Version1
+	}
+	return v
+}
+
+/*
+ * Stamp sets the sequence number of an encoded server message. Messages are encoded
+ * with Seq left at zero (and so omitted) and stamped when they are written, since the
+ * same broadcast reaches connections that are at different points of their sequence.
+ *
+ * Parameters:
+ *   - message ([]byte): An encoded server message, a JSON object without "seq".
+ *   - seq (uint64): The connection's sequence number for this message.
+ *
+ * Returns:
+ *   - []byte: The message with "seq" as its first field; anything but an object is returned unchanged.
+ */
+func Stamp(message []byte, seq uint64) []byte {
+	trimmed := bytes.TrimSpace(message)
+	if len(trimmed) < 2 || trimmed[0] != '{' {
+		return message
+	}
+
+	body := bytes.TrimSpace(trimmed[1:])
+	stamped := make([]byte, 0, len(body)+24)
+	stamped = append(stamped, `{"seq":`...)
+	stamped = strconv.AppendUint(stamped, seq, 10)
+	if body[0] != '}' {
+		stamped = append(stamped, ',')
+	}
+	return append(stamped, body...)
+}
@@ -0,0 +1,166 @@
+/*
+ * file: protocol_test.go
+ * package: protocol
+ * description:
+ *     Tests for the protocol framing: version negotiation, seq stamping, decoding of
+ *     client messages in both versions, and the JSON shape of the lobby and
+ *     matchmaking messages.
+ */
+
+package protocol
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	"gorm.io/gorm"
+
+	"github.com/juan10024/tictactoe-test/internal/core/domain"
+)
+
+func TestVersionOf(t *testing.T) {
+	tests := []struct {
+		subprotocol string
+		want        int
+	}{
+		{"tictactoe.v2", Version2},
+		{"tictactoe.v1", Version1},
+		{"", Version1},
+		{"tictactoe.v3", Version1},
+		{"tictactoe.v0", Version1},
+		{"chess.v2", Version1},
+		{"tictactoe.vx", Version1},
+	}
+
+	for _, tt := range tests {
+		if got := VersionOf(tt.subprotocol); got != tt.want {
+			t.Errorf("VersionOf(%q) = %d, want %d", tt.subprotocol, got, tt.want)
+		}
+	}
+}
+
+func TestStamp(t *testing.T) {
+	tests := []struct {
+		name    string
+		message string
+		want    string
+	}{
+		{"object with fields", `{"type":"ack","for":"move"}`, `{"seq":7,"type":"ack","for":"move"}`},
+		{"empty object", `{}`, `{"seq":7}`},
+		{"surrounding whitespace", " {\"type\":\"ack\"}\n", `{"seq":7,"type":"ack"}`},
+		{"not an object", `[1,2]`, `[1,2]`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := string(Stamp([]byte(tt.message), 7)); got != tt.want {
+				t.Errorf("Stamp(%s) = %s, want %s", tt.message, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDecodeClientMessage(t *testing.T) {
+	tests := []struct {
+		name     string
+		data     string
+		version  int
+		wantType string
+		wantErr  error
+	}{
+		{"move", `{"type":"move","payload":{"position":4}}`, Version2, TypeMove, nil},
+		{"resync", `{"type":"resync","requestId":"r1"}`, Version2, TypeResync, nil},
+		{"bare integer in version 1", `4`, Version1, TypeMove, nil},
+		{"bare integer in version 2", `4`, Version2, "", ErrMalformedMessage},
+		{"legacy rematch in version 1", `{"type":"playAgainRequest"}`, Version1, TypeRematchOffer, nil},
+		{"legacy answer in version 1", `{"type":"play_again_response","payload":{"accepted":true}}`, Version1, TypeRematchAccept, nil},
+		{"legacy rematch in version 2", `{"type":"playAgainRequest"}`, Version2, "", ErrUnknownType},
+		{"unknown type", `{"type":"chat"}`, Version2, "", ErrUnknownType},
+		{"missing type", `{"payload":{}}`, Version2, "", ErrMalformedMessage},
+		{"not JSON", `move 4`, Version2, "", ErrMalformedMessage},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			msg, err := DecodeClientMessage([]byte(tt.data), tt.version)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("DecodeClientMessage() error = %v, want %v", err, tt.wantErr)
+			}
+			if err == nil && msg.Type != tt.wantType {
+				t.Errorf("DecodeClientMessage() type = %s, want %s", msg.Type, tt.wantType)
+			}
+		})
+	}
+
+	msg, err := DecodeClientMessage([]byte(`4`), Version1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if move, err := msg.Move(); err != nil || move.Position != 4 {
+		t.Errorf("Move() of a bare integer = %v, %v, want position 4", move, err)
+	}
+}
+
+func TestLobbyAndMatchmakingMessages(t *testing.T) {
+	created := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	alice := domain.Player{ID: 1, Name: "alice", Rating: 1500}
+	bob := domain.Player{ID: 2, Name: "bob", Rating: 1520}
+	game := &domain.Game{
+		Model:     gorm.Model{ID: 10, CreatedAt: created},
+		RoomID:    "room-1",
+		PlayerXID: &alice.ID, PlayerX: alice,
+		Ruleset: "classic", Status: "waiting", BoardWidth: 3, BoardHeight: 3, WinLength: 3,
+		ResumeTokenX: "token-x",
+	}
+
+	tests := []struct {
+		name    string
+		message interface{}
+		want    string
+	}{
+		{
+			"lobby event",
+			LobbyEvent{Type: TypeRoomCreated, Room: NewLobbyRoom(game, domain.RoomPublic, 2)},
+			`{"type":"roomCreated","room":{"roomId":"room-1","gameId":10,"status":"waiting","privacy":"public","ruleset":"classic","boardWidth":3,"boardHeight":3,"winLength":3,"playerX":"alice","moveTime":0,"baseTime":0,"increment":0,"spectators":2,"createdAt":"2024-05-01T12:00:00Z"}}`,
+		},
+		{
+			"empty lobby snapshot",
+			LobbySnapshot{Type: TypeLobbySnapshot, Rooms: []LobbyRoom{}},
+			`{"type":"lobbySnapshot","rooms":[]}`,
+		},
+		{
+			"queued",
+			Queued{Type: TypeQueued, Preferences: MatchPreferences{Ruleset: "gomoku", MinRating: 1400}},
+			`{"type":"queued","preferences":{"ruleset":"gomoku","minRating":1400,"maxRating":0}}`,
+		},
+		{
+			"cancelled",
+			Cancelled{Type: TypeCancelled},
+			`{"type":"cancelled"}`,
+		},
+		{
+			"error",
+			MatchmakingError{Type: TypeError, Message: "no"},
+			`{"type":"error","message":"no"}`,
+		},
+		{
+			"match found",
+			NewMatchFound(game, &alice, &bob),
+			`{"type":"matchFound","roomId":"room-1","symbol":"X","resumeToken":"token-x","ruleset":"classic","opponent":{"id":2,"name":"bob","wins":0,"draws":0,"losses":0,"isBot":false,"rating":1520,"ratingDeviation":0,"ratingVolatility":0}}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := json.Marshal(tt.message)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(got) != tt.want {
+				t.Errorf("encoded as\n%s\nwant\n%s", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,184 @@
+/*
+ * file: server.go
+ * package: protocol
+ * description:
+ *     Defines the messages the server sends on the game room socket.
+ */
+
+package protocol
+
+import "time"
+
+// Server to client message types.
+const (
+	TypeWelcome         = "welcome"         // First message: the negotiated version.
+	TypeAck             = "ack"             // A client message carrying a requestId was applied.
+	TypeError           = "error"           // A client message was refused.
+	TypeGameStateUpdate = "gameStateUpdate" // The room's game: the snapshot sent on join, on resync and when a game starts or ends.
+	TypeMoveApplied     = "moveApplied"     // A move, to apply on the last snapshot (version 2; version 1 gets a snapshot instead).
+	TypeGameOver        = "gameOver"        // The game ended for a reason other than the last move.
+	TypeSeriesOver      = "seriesOver"      // A player won the room's series.
+	TypeRematchUpdate   = "rematchUpdate"   // The rematch negotiation changed.
+)
+
+// Error codes.
+const (
+	CodeMalformed   = "malformed"    // The message could not be decoded.
+	CodeUnknownType = "unknown_type" // The message type is not part of the negotiated version.
+	CodeForbidden   = "forbidden"    // The sender may not do this, such as an observer moving.
+	CodeRejected    = "rejected"     // The command was refused, such as an illegal move.
+	CodeTimeUp      = "time_up"      // The move arrived after the mover's clock ran out.
+	CodeConflict    = "conflict"     // The game changed concurrently; the message can be sent again.
+)
+
+/*
+ * Header is the framing shared by every server message.
+ *
+ * Fields:
+ *   - Type (string): One of the server Type* constants.
+ *   - Seq (uint64): The connection's sequence number, set by Stamp when the message is written.
+ *   - RequestID (string): The requestId of the client message answered, for acks and errors.
+ */
+type Header struct {
+	Type      string `json:"type"`
+	Seq       uint64 `json:"seq,omitempty"`
+	RequestID string `json:"requestId,omitempty"`
+}
+
+// Welcome confirms the negotiated protocol version.
+type Welcome struct {
+	Header
+	Version  int   `json:"protocolVersion"`
+	Versions []int `json:"supportedVersions"`
+}
+
+// Ack confirms that a client message carrying a requestId was applied.
+type Ack struct {
+	Header
+	For string `json:"for"` // The type of the acknowledged message.
+}
+
+// Error tells a client why its message was refused.
+type Error struct {
+	Header
+	Code    string `json:"code"`
+	Message string `json:"message"`
+}
+
+// Players are the players seated in a game, nil for an empty seat.
+type Players struct {
+	X *Player `json:"X"`
+	O *Player `json:"O"`
+}
+
+// GameState carries the room's game, to the whole room or to a client that just joined.
+type GameState struct {
+	Header
+	GameState   *Game          `json:"gameState"`
+	Players     Players        `json:"players"`
+	IsObserver  bool           `json:"isObserver"`
+	ActiveBoard *int           `json:"activeBoard"`      // Sub-board the next move is forced into (Ultimate only; null when free).
+	Clock       *ClockSnapshot `json:"clock,omitempty"`  // Remaining time of both players, for games with a time control.
+	Series      *SeriesScore   `json:"series,omitempty"` // Running score, for rooms playing a best-of-N series.
+
+	// Only set in the state answering a resync: every message up to this seq is reflected
+	// in the snapshot, so the client can drop whatever it kept from them.
+	AsOfSeq uint64 `json:"asOfSeq,omitempty"`
+
+	// Only set in the state sent privately to a seated player when they join.
+	Seat        string `json:"seat,omitempty"`        // The symbol the player plays.
+	ResumeToken string `json:"resumeToken,omitempty"` // Present it as ?resume= to reclaim the seat after reconnecting.
+}
+
+/*
+ * MoveApplied describes one move, so clients update their last snapshot instead of
+ * receiving the whole game again. A client that misses one (a gap in seq, or a
+ * moveNumber that does not follow its last one) asks for a full snapshot with resync.
+ *
+ * Fields:
+ *   - GameID (uint): The game the move belongs to.
+ *   - MoveNumber (int): 1-based number of the move in the game.
+ *   - Position (int): The cell played, as encoded by the game's ruleset.
+ *   - Symbol (string): The symbol placed, "X" or "O".
+ *   - NextTurn (string): The symbol to move next; empty once the game is over.
+ *   - ActiveBoard (*int): Sub-board the next move is forced into (Ultimate only; null when free).
+ *   - SubBoardWinners (string): Winner of each sub-board after the move (Ultimate only).
+ *   - Clock (*ClockSnapshot): Remaining time of both players, for games with a time control.
+ *   - Outcome (*Outcome): Set when the move ended the game.
+ */
+type MoveApplied struct {
+	Header
+	GameID          uint           `json:"gameId"`
+	MoveNumber      int            `json:"moveNumber"`
+	Position        int            `json:"position"`
+	Symbol          string         `json:"symbol"`
+	NextTurn        string         `json:"nextTurn,omitempty"`
+	ActiveBoard     *int           `json:"activeBoard"`
+	SubBoardWinners string         `json:"subBoardWinners,omitempty"`
+	Clock           *ClockSnapshot `json:"clock,omitempty"`
+	Outcome         *Outcome       `json:"outcome,omitempty"`
+}
+
+// Outcome is how a game ended.
+type Outcome struct {
+	Status   string `json:"status"`           // "finished".
+	Reason   string `json:"reason"`           // One of the domain EndReason* values.
+	Winner   string `json:"winner,omitempty"` // The winner's symbol; empty for a draw.
+	WinnerID *uint  `json:"winnerId,omitempty"`
+}
+
+// GameOver is sent to the room when a game ends for a reason other than the last move,
+// such as a player running out of time.
+type GameOver struct {
+	Header
+	Reason    string `json:"reason"`
+	Winner    string `json:"winner,omitempty"`
+	GameState *Game  `json:"gameState"`
+}
+
+/*
+ * ClockSnapshot represents the remaining time of both players at a given instant.
+ *
+ * Fields:
+ *   - TimeLeftX (int64): Milliseconds left on X's clock.
+ *   - TimeLeftO (int64): Milliseconds left on O's clock.
+ *   - Running (string): The symbol whose clock is running, empty while stopped.
+ */
+type ClockSnapshot struct {
+	TimeLeftX int64  `json:"timeLeftX"`
+	TimeLeftO int64  `json:"timeLeftO"`
+	Running   string `json:"running,omitempty"`
+}
+
+// SeriesScore is the running score of a series, from the point of view of the current game.
+type SeriesScore struct {
+	BestOf     int  `json:"bestOf"`
+	GameNumber int  `json:"gameNumber"` // 1-based number of the current game in the series.
+	WinsX      int  `json:"winsX"`      // Series wins of the player playing X in the current game.
+	WinsO      int  `json:"winsO"`      // Series wins of the player playing O in the current game.
+	Draws      int  `json:"draws"`
+	Over       bool `json:"over"`
+}
+
+// SeriesOver is sent to the room when a player wins the series.
+type SeriesOver struct {
+	Header
+	Winner     string       `json:"winner"` // The symbol the series winner played in the last game.
+	WinnerName string       `json:"winnerName"`
+	Series     *SeriesScore `json:"series"`
+}
+
+// RematchUpdate tells the room, observers included, where its rematch negotiation stands.
+type RematchUpdate struct {
+	Header
+	GameID         uint      `json:"gameId"`    // The finished game the rematch follows.
+	State          string    `json:"state"`     // "offered", "accepted", "declined" or "expired".
+	OfferedBy      string    `json:"offeredBy"` // Symbol of the player who offered.
+	OfferedByName  string    `json:"offeredByName"`
+	AcceptedX      bool      `json:"acceptedX"`
+	AcceptedO      bool      `json:"acceptedO"`
+	DeclinedBy     string    `json:"declinedBy,omitempty"`
+	DeclinedByName string    `json:"declinedByName,omitempty"`
+	SwapSides      bool      `json:"swapSides"` // Whether the players exchange X and O in the next game.
+	ExpiresAt      time.Time `json:"expiresAt"`
+}
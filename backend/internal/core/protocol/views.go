@@ -0,0 +1,143 @@
+/*
+ * file: views.go
+ * package: protocol
+ * description:
+ *     Provides the public JSON shape of players and games shared by the socket
+ *     messages and the REST API, and the mappers that build them from the domain models.
+ */
+
+package protocol
+
+import (
+	"time"
+
+	"github.com/juan10024/tictactoe-test/internal/core/domain"
+)
+
+/*
+ * Player is the public view of a player.
+ *
+ * Fields:
+ *   - ID (uint): The player identifier.
+ *   - Name (string): The player's unique name.
+ *   - Wins, Draws, Losses (int): The player's record.
+ *   - IsBot (bool): Whether the player is a computer opponent.
+ *   - Rating, RatingDeviation, RatingVolatility (float64): The player's Glicko-2 rating.
+ */
+type Player struct {
+	ID               uint    `json:"id"`
+	Name             string  `json:"name"`
+	Wins             int     `json:"wins"`
+	Draws            int     `json:"draws"`
+	Losses           int     `json:"losses"`
+	IsBot            bool    `json:"isBot"`
+	Rating           float64 `json:"rating"`
+	RatingDeviation  float64 `json:"ratingDeviation"`
+	RatingVolatility float64 `json:"ratingVolatility"`
+}
+
+/*
+ * Game is the public view of a game. Seats that are empty have no player.
+ *
+ * Fields mirror domain.Game, without its storage bookkeeping (such as the optimistic
+ * locking version) and resume tokens.
+ */
+type Game struct {
+	ID              uint       `json:"id"`
+	RoomID          string     `json:"roomID"`
+	PlayerXID       *uint      `json:"playerXID"`
+	PlayerX         *Player    `json:"playerX,omitempty"`
+	PlayerOID       *uint      `json:"playerOID"`
+	PlayerO         *Player    `json:"playerO,omitempty"`
+	WinnerID        *uint      `json:"winnerID"`
+	Winner          *Player    `json:"winner,omitempty"`
+	Ruleset         string     `json:"ruleset"`
+	Status          string     `json:"status"`
+	Board           string     `json:"board"`
+	BoardWidth      int        `json:"boardWidth"`
+	BoardHeight     int        `json:"boardHeight"`
+	WinLength       int        `json:"winLength"`
+	CurrentTurn     string     `json:"currentTurn"`
+	ActiveBoard     *int       `json:"activeBoard"`
+	SubBoardWinners string     `json:"subBoardWinners,omitempty"`
+	BotLevel        string     `json:"botLevel,omitempty"`
+	BotSymbol       string     `json:"botSymbol,omitempty"`
+	MoveTime        int        `json:"moveTime"`
+	BaseTime        int        `json:"baseTime"`
+	Increment       int        `json:"increment"`
+	TimeLeftX       int64      `json:"timeLeftX"`
+	TimeLeftO       int64      `json:"timeLeftO"`
+	TurnStartedAt   *time.Time `json:"turnStartedAt"`
+	EndReason       string     `json:"endReason,omitempty"`
+	CreatedAt       time.Time  `json:"createdAt"`
+}
+
+/*
+ * NewPlayer maps a domain player to its public view.
+ *
+ * Parameters:
+ *   - p (*domain.Player): The player; nil or an unsaved player (ID 0) stands for an empty seat.
+ *
+ * Returns:
+ *   - *Player: The public view, nil for an empty seat.
+ */
+func NewPlayer(p *domain.Player) *Player {
+	if p == nil || p.ID == 0 {
+		return nil
+	}
+	return &Player{
+		ID:               p.ID,
+		Name:             p.Name,
+		Wins:             p.Wins,
+		Draws:            p.Draws,
+		Losses:           p.Losses,
+		IsBot:            p.IsBot,
+		Rating:           p.Rating,
+		RatingDeviation:  p.RatingDeviation,
+		RatingVolatility: p.RatingVolatility,
+	}
+}
+
+/*
+ * NewGame maps a domain game to its public view.
+ *
+ * Parameters:
+ *   - g (*domain.Game): The game, with its players preloaded if they should be included.
+ *
+ * Returns:
+ *   - *Game: The public view, nil if g is nil.
+ */
+func NewGame(g *domain.Game) *Game {
+	if g == nil {
+		return nil
+	}
+	return &Game{
+		ID:              g.ID,
+		RoomID:          g.RoomID,
+		PlayerXID:       g.PlayerXID,
+		PlayerX:         NewPlayer(&g.PlayerX),
+		PlayerOID:       g.PlayerOID,
+		PlayerO:         NewPlayer(&g.PlayerO),
+		WinnerID:        g.WinnerID,
+		Winner:          NewPlayer(&g.Winner),
+		Ruleset:         g.Ruleset,
+		Status:          g.Status,
+		Board:           g.Board,
+		BoardWidth:      g.BoardWidth,
+		BoardHeight:     g.BoardHeight,
+		WinLength:       g.WinLength,
+		CurrentTurn:     g.CurrentTurn,
+		ActiveBoard:     g.ActiveBoard,
+		SubBoardWinners: g.SubBoardWinners,
+		BotLevel:        g.BotLevel,
+		BotSymbol:       g.BotSymbol,
+		MoveTime:        g.MoveTime,
+		BaseTime:        g.BaseTime,
+		Increment:       g.Increment,
+		TimeLeftX:       g.TimeLeftX,
+		TimeLeftO:       g.TimeLeftO,
+		TurnStartedAt:   g.TurnStartedAt,
+		EndReason:       g.EndReason,
+		CreatedAt:       g.CreatedAt,
+	}
+}
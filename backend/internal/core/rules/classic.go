@@ -0,0 +1,111 @@
+/*
+ * file: classic.go
+ * package: rules
+ * description:
+ *     Implements the classic 3x3 Tic-Tac-Toe ruleset behind the ports.GameRules port.
+ */
+
+package rules
+
+import (
+	"errors"
+
+	"github.com/juan10024/tictactoe-test/internal/core/domain"
+)
+
+// ClassicName is the identifier of the classic 3x3 ruleset.
+const ClassicName = "classic"
+
+const classicSize = 3
+
+/*
+ * Classic implements the traditional 3x3 Tic-Tac-Toe game.
+ *
+ * The board is encoded as a string of nine cells in row-major order,
+ * where ' ' marks an empty cell and 'X' / 'O' mark taken cells.
+ */
+type Classic struct{}
+
+/*
+ * NewClassic creates a new instance of the classic ruleset.
+ *
+ * Returns:
+ *   - *Classic: The ruleset instance.
+ */
+func NewClassic() *Classic {
+	return &Classic{}
+}
+
+/*
+ * Name returns the ruleset identifier.
+ *
+ * Returns:
+ *   - string: Always ClassicName.
+ */
+func (c *Classic) Name() string {
+	return ClassicName
+}
+
+/*
+ * Setup initializes an empty 3x3 board with X to move.
+ * Board size options are ignored: the classic game is always 3x3, three in a row.
+ *
+ * Parameters:
+ *   - game (*domain.Game): The game to initialize.
+ *   - config (domain.GameConfig): The room options.
+ *
+ * Returns:
+ *   - error: Always nil.
+ */
+func (c *Classic) Setup(game *domain.Game, config domain.GameConfig) error {
+	game.Ruleset = ClassicName
+	resetGrid(game, classicSize, classicSize, classicSize)
+	return nil
+}
+
+/*
+ * LegalMoves returns every empty position on the board.
+ *
+ * Parameters:
+ *   - game (*domain.Game): The game to inspect.
+ *
+ * Returns:
+ *   - []int: The playable positions, in ascending order.
+ */
+func (c *Classic) LegalMoves(game *domain.Game) []int {
+	if finished, _ := c.Outcome(game); finished {
+		return nil
+	}
+	return emptyCells(game.Board)
+}
+
+/*
+ * ApplyMove places the symbol of the player to move at the given position.
+ *
+ * Parameters:
+ *   - game (*domain.Game): The game to update.
+ *   - position (int): The board position (0-8).
+ *
+ * Returns:
+ *   - error: An error if the position is out of bounds or already taken.
+ */
+func (c *Classic) ApplyMove(game *domain.Game, position int) error {
+	if position < 0 || position >= len(game.Board) {
+		return errors.New("invalid move: position is out of bounds")
+	}
+	return placeSymbol(game, position)
+}
+
+/*
+ * Outcome checks for three in a row and whether the board is full.
+ *
+ * Parameters:
+ *   - game (*domain.Game): The game to inspect.
+ *
+ * Returns:
+ *   - bool: True if the game is over.
+ *   - string: The winner symbol, or an empty string for a draw or an unfinished game.
+ */
+func (c *Classic) Outcome(game *domain.Game) (bool, string) {
+	return gridOutcome(game.Board, classicSize, classicSize, classicSize)
+}
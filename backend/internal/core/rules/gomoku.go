@@ -0,0 +1,135 @@
+/*
+ * file: gomoku.go
+ * package: rules
+ * description:
+ *     Implements the configurable N x M, K-in-a-row (Gomoku-style) ruleset.
+ */
+
+package rules
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/juan10024/tictactoe-test/internal/core/domain"
+)
+
+// GomokuName is the identifier of the configurable K-in-a-row ruleset.
+const GomokuName = "gomoku"
+
+const (
+	MinBoardSize = 3  // Smallest supported board side.
+	MaxBoardSize = 25 // Largest supported board side.
+
+	defaultGomokuSize      = 15
+	defaultGomokuWinLength = 5
+)
+
+/*
+ * Gomoku implements K-in-a-row on a board of arbitrary width and height.
+ *
+ * Positions are row-major indexes (y*width + x) into the board string.
+ */
+type Gomoku struct{}
+
+/*
+ * NewGomoku creates a new instance of the K-in-a-row ruleset.
+ *
+ * Returns:
+ *   - *Gomoku: The ruleset instance.
+ */
+func NewGomoku() *Gomoku {
+	return &Gomoku{}
+}
+
+/*
+ * Name returns the ruleset identifier.
+ *
+ * Returns:
+ *   - string: Always GomokuName.
+ */
+func (g *Gomoku) Name() string {
+	return GomokuName
+}
+
+/*
+ * Setup validates the requested board size and win length and initializes an empty board.
+ * Missing options default to a 15x15 board with five in a row.
+ *
+ * Parameters:
+ *   - game (*domain.Game): The game to initialize.
+ *   - config (domain.GameConfig): The room options.
+ *
+ * Returns:
+ *   - error: An error if the dimensions or win length are out of range.
+ */
+func (g *Gomoku) Setup(game *domain.Game, config domain.GameConfig) error {
+	width, height, winLength := config.Width, config.Height, config.WinLength
+	if width == 0 {
+		width = defaultGomokuSize
+	}
+	if height == 0 {
+		height = width
+	}
+	if winLength == 0 {
+		winLength = min(defaultGomokuWinLength, max(width, height))
+	}
+
+	if width < MinBoardSize || width > MaxBoardSize || height < MinBoardSize || height > MaxBoardSize {
+		return fmt.Errorf("board dimensions must be between %d and %d", MinBoardSize, MaxBoardSize)
+	}
+	if winLength < MinBoardSize || winLength > max(width, height) {
+		return fmt.Errorf("win length must be between %d and the longest board side", MinBoardSize)
+	}
+
+	game.Ruleset = GomokuName
+	resetGrid(game, width, height, winLength)
+	return nil
+}
+
+/*
+ * LegalMoves returns every empty position on the board while the game is undecided.
+ *
+ * Parameters:
+ *   - game (*domain.Game): The game to inspect.
+ *
+ * Returns:
+ *   - []int: The playable positions, in ascending order.
+ */
+func (g *Gomoku) LegalMoves(game *domain.Game) []int {
+	if finished, _ := g.Outcome(game); finished {
+		return nil
+	}
+	return emptyCells(game.Board)
+}
+
+/*
+ * ApplyMove places the symbol of the player to move at the given position.
+ *
+ * Parameters:
+ *   - game (*domain.Game): The game to update.
+ *   - position (int): The row-major board position.
+ *
+ * Returns:
+ *   - error: An error if the position is out of bounds or already taken.
+ */
+func (g *Gomoku) ApplyMove(game *domain.Game, position int) error {
+	if position < 0 || position >= game.BoardWidth*game.BoardHeight || position >= len(game.Board) {
+		return errors.New("invalid move: position is out of bounds")
+	}
+	return placeSymbol(game, position)
+}
+
+/*
+ * Outcome checks for WinLength symbols in a row and whether the board is full.
+ *
+ * Parameters:
+ *   - game (*domain.Game): The game to inspect.
+ *
+ * Returns:
+ *   - bool: True if the game is over.
+ *   - string: The winner symbol, or an empty string for a draw or an unfinished game.
+ */
+func (g *Gomoku) Outcome(game *domain.Game) (bool, string) {
+	return gridOutcome(game.Board, game.BoardWidth, game.BoardHeight, game.WinLength)
+}
@@ -0,0 +1,169 @@
+/*
+ * file: gomoku_test.go
+ * package: rules
+ * description:
+ *     Table-driven tests for the K-in-a-row rulesets: line detection in every direction,
+ *     lines touching the board edges, lines that would wrap across rows, draws, and the
+ *     board size and win length limits.
+ */
+
+package rules
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/juan10024/tictactoe-test/internal/core/domain"
+)
+
+// run is a straight line of n symbols starting at (x, y) and advancing by (dx, dy).
+type run struct {
+	x, y, dx, dy, n int
+	symbol          byte
+}
+
+// gridBoard draws runs on an empty width x height board.
+func gridBoard(width, height int, runs ...run) string {
+	board := []byte(strings.Repeat(" ", width*height))
+	for _, r := range runs {
+		for i := 0; i < r.n; i++ {
+			board[(r.y+r.dy*i)*width+r.x+r.dx*i] = r.symbol
+		}
+	}
+	return string(board)
+}
+
+func TestGomokuOutcome(t *testing.T) {
+	tests := []struct {
+		name                     string
+		width, height, winLength int
+		board                    string
+		wantFinished             bool
+		wantWinner               string
+	}{
+		{"empty board", 15, 15, 5, gridBoard(15, 15), false, ""},
+		{"row", 15, 15, 5, gridBoard(15, 15, run{3, 7, 1, 0, 5, 'X'}), true, "X"},
+		{"column", 15, 15, 5, gridBoard(15, 15, run{7, 2, 0, 1, 5, 'O'}), true, "O"},
+		{"diagonal", 15, 15, 5, gridBoard(15, 15, run{2, 2, 1, 1, 5, 'X'}), true, "X"},
+		{"anti-diagonal", 15, 15, 5, gridBoard(15, 15, run{9, 1, -1, 1, 5, 'O'}), true, "O"},
+		{"one short", 15, 15, 5, gridBoard(15, 15, run{3, 7, 1, 0, 4, 'X'}), false, ""},
+		{"line broken by the opponent", 15, 15, 5, gridBoard(15, 15, run{0, 0, 1, 0, 6, 'X'}, run{3, 0, 0, 0, 1, 'O'}), false, ""},
+		{"longer than k", 15, 15, 5, gridBoard(15, 15, run{0, 4, 1, 0, 7, 'X'}), true, "X"},
+		{"row at the right edge", 15, 15, 5, gridBoard(15, 15, run{10, 0, 1, 0, 5, 'X'}), true, "X"},
+		{"column at the bottom edge", 15, 15, 5, gridBoard(15, 15, run{14, 10, 0, 1, 5, 'O'}), true, "O"},
+		{"diagonal into the bottom-right corner", 15, 15, 5, gridBoard(15, 15, run{10, 10, 1, 1, 5, 'X'}), true, "X"},
+		{"anti-diagonal into the bottom-left corner", 15, 15, 5, gridBoard(15, 15, run{4, 10, -1, 1, 5, 'O'}), true, "O"},
+		{"row wrapping onto the next row", 15, 15, 5, gridBoard(15, 15, run{12, 0, 1, 0, 3, 'X'}, run{0, 1, 1, 0, 2, 'X'}), false, ""},
+		{"diagonal wrapping around the side", 15, 15, 5, gridBoard(15, 15, run{12, 0, 1, 1, 3, 'X'}, run{0, 4, 1, 1, 2, 'X'}), false, ""},
+		{"smallest board", 3, 3, 3, gridBoard(3, 3, run{2, 0, -1, 1, 3, 'X'}), true, "X"},
+		{"wide board, short side", 25, 3, 3, gridBoard(25, 3, run{24, 0, 0, 1, 3, 'O'}), true, "O"},
+		{"tall board, full column", 3, 25, 25, gridBoard(3, 25, run{1, 0, 0, 1, 25, 'X'}), true, "X"},
+		{"tall board, column one short", 3, 25, 25, gridBoard(3, 25, run{1, 1, 0, 1, 24, 'X'}), false, ""},
+		{"largest board, main diagonal", 25, 25, 25, gridBoard(25, 25, run{0, 0, 1, 1, 25, 'O'}), true, "O"},
+		{"largest board, anti-diagonal", 25, 25, 25, gridBoard(25, 25, run{24, 0, -1, 1, 25, 'X'}), true, "X"},
+		{"full board without a line", 4, 4, 3, "XXOO" + "OOXX" + "XXOO" + "OOXX", true, ""},
+		{"full classic draw", 3, 3, 3, "XOX" + "XOO" + "OXX", true, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			game := &domain.Game{Board: tt.board, BoardWidth: tt.width, BoardHeight: tt.height, WinLength: tt.winLength}
+			finished, winner := NewGomoku().Outcome(game)
+			if finished != tt.wantFinished || winner != tt.wantWinner {
+				t.Errorf("Outcome() = (%v, %q), want (%v, %q)", finished, winner, tt.wantFinished, tt.wantWinner)
+			}
+			if finished && len(NewGomoku().LegalMoves(game)) != 0 {
+				t.Errorf("LegalMoves() is not empty on a finished board")
+			}
+		})
+	}
+}
+
+func TestClassicOutcome(t *testing.T) {
+	tests := []struct {
+		name         string
+		board        string
+		wantFinished bool
+		wantWinner   string
+	}{
+		{"empty", "         ", false, ""},
+		{"top row", "XXX" + "OO " + "   ", true, "X"},
+		{"bottom row", "XX " + "X  " + "OOO", true, "O"},
+		{"left column", "XO " + "XO " + "X  ", true, "X"},
+		{"right column", "X O" + "X O" + " XO", true, "O"},
+		{"diagonal", "XO " + "OX " + "  X", true, "X"},
+		{"anti-diagonal", "XXO" + "XO " + "O  ", true, "O"},
+		{"in progress", "XO " + " X " + "O  ", false, ""},
+		{"draw", "XOX" + "XOO" + "OXX", true, ""},
+		{"won on the last cell", "XOX" + "OXO" + "OXX", true, "X"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			finished, winner := NewClassic().Outcome(&domain.Game{Board: tt.board})
+			if finished != tt.wantFinished || winner != tt.wantWinner {
+				t.Errorf("Outcome() = (%v, %q), want (%v, %q)", finished, winner, tt.wantFinished, tt.wantWinner)
+			}
+		})
+	}
+}
+
+func TestGomokuSetup(t *testing.T) {
+	tests := []struct {
+		name                                 string
+		config                               domain.GameConfig
+		wantErr                              bool
+		wantWidth, wantHeight, wantWinLength int
+	}{
+		{"defaults", domain.GameConfig{}, false, 15, 15, 5},
+		{"square from width", domain.GameConfig{Width: 7}, false, 7, 7, 5},
+		{"small board caps the default win length", domain.GameConfig{Width: 4, Height: 3}, false, 4, 3, 4},
+		{"smallest", domain.GameConfig{Width: 3, Height: 3, WinLength: 3}, false, 3, 3, 3},
+		{"largest", domain.GameConfig{Width: 25, Height: 25, WinLength: 25}, false, 25, 25, 25},
+		{"too narrow", domain.GameConfig{Width: 2, Height: 5, WinLength: 3}, true, 0, 0, 0},
+		{"too tall", domain.GameConfig{Width: 5, Height: 26, WinLength: 3}, true, 0, 0, 0},
+		{"win length too short", domain.GameConfig{Width: 5, Height: 5, WinLength: 2}, true, 0, 0, 0},
+		{"win length longer than the board", domain.GameConfig{Width: 5, Height: 4, WinLength: 6}, true, 0, 0, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			game := &domain.Game{}
+			err := NewGomoku().Setup(game, tt.config)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Setup() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			if game.BoardWidth != tt.wantWidth || game.BoardHeight != tt.wantHeight || game.WinLength != tt.wantWinLength {
+				t.Errorf("Setup() = %dx%d k=%d, want %dx%d k=%d", game.BoardWidth, game.BoardHeight, game.WinLength, tt.wantWidth, tt.wantHeight, tt.wantWinLength)
+			}
+			if len(game.Board) != tt.wantWidth*tt.wantHeight || strings.TrimSpace(game.Board) != "" || game.CurrentTurn != "X" {
+				t.Errorf("Setup() board %q, turn %q: want an empty board with X to move", game.Board, game.CurrentTurn)
+			}
+		})
+	}
+}
+
+func TestGomokuApplyMove(t *testing.T) {
+	game := &domain.Game{}
+	if err := NewGomoku().Setup(game, domain.GameConfig{Width: 5, Height: 4, WinLength: 4}); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, position := range []int{-1, 20} {
+		if err := NewGomoku().ApplyMove(game, position); err == nil {
+			t.Errorf("ApplyMove(%d) accepted a position outside the 5x4 board", position)
+		}
+	}
+	if err := NewGomoku().ApplyMove(game, 19); err != nil {
+		t.Fatalf("ApplyMove(19) = %v", err)
+	}
+	if game.Board[19] != 'X' {
+		t.Errorf("ApplyMove(19) left cell %q, want 'X'", game.Board[19])
+	}
+	if err := NewGomoku().ApplyMove(game, 19); err == nil {
+		t.Errorf("ApplyMove(19) accepted a taken cell")
+	}
+}
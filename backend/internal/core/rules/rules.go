@@ -0,0 +1,141 @@
+/*
+ * file: rules.go
+ * package: rules
+ * description:
+ *     Provides the game variants (rulesets) available to the core service,
+ *     together with the board helpers shared between them.
+ */
+
+package rules
+
+import (
+	"errors"
+	"strings"
+
+	"github.com/juan10024/tictactoe-test/internal/core/domain"
+)
+
+// lineDirections are the four directions a winning line can run in: right, down and both diagonals.
+var lineDirections = [4][2]int{{1, 0}, {0, 1}, {1, 1}, {-1, 1}}
+
+/*
+ * resetGrid fills a game with an empty width x height board and gives X the first move.
+ *
+ * Parameters:
+ *   - game (*domain.Game): The game to initialize.
+ *   - width, height (int): The board dimensions.
+ *   - winLength (int): The number of symbols in a row needed to win.
+ *
+ * Returns:
+ *   - None.
+ */
+func resetGrid(game *domain.Game, width, height, winLength int) {
+	game.BoardWidth = width
+	game.BoardHeight = height
+	game.WinLength = winLength
+	game.Board = strings.Repeat(" ", width*height)
+	game.CurrentTurn = "X"
+}
+
+/*
+ * emptyCells returns the indexes of every empty cell on a board.
+ *
+ * Parameters:
+ *   - board (string): The encoded board.
+ *
+ * Returns:
+ *   - []int: The empty positions, in ascending order.
+ */
+func emptyCells(board string) []int {
+	cells := make([]int, 0, len(board))
+	for i := 0; i < len(board); i++ {
+		if board[i] == ' ' {
+			cells = append(cells, i)
+		}
+	}
+	return cells
+}
+
+/*
+ * placeSymbol writes the current turn's symbol into an empty cell.
+ *
+ * Parameters:
+ *   - game (*domain.Game): The game to update.
+ *   - position (int): A position already checked to be within the board.
+ *
+ * Returns:
+ *   - error: An error if the cell is already taken.
+ */
+func placeSymbol(game *domain.Game, position int) error {
+	if game.Board[position] != ' ' {
+		return errors.New("invalid move: position is already taken")
+	}
+
+	board := []byte(game.Board)
+	board[position] = game.CurrentTurn[0]
+	game.Board = string(board)
+	return nil
+}
+
+/*
+ * lineWinner scans a row-major board for winLength identical symbols in a row,
+ * horizontally, vertically or diagonally.
+ *
+ * Parameters:
+ *   - board (string): The encoded board.
+ *   - width, height (int): The board dimensions.
+ *   - winLength (int): The number of symbols in a row needed to win.
+ *
+ * Returns:
+ *   - string: The winner symbol, or an empty string if nobody has a line.
+ */
+func lineWinner(board string, width, height, winLength int) string {
+	if width <= 0 || height <= 0 || winLength <= 0 || len(board) != width*height {
+		return ""
+	}
+
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			symbol := board[y*width+x]
+			if symbol == ' ' {
+				continue
+			}
+			for _, d := range lineDirections {
+				endX, endY := x+d[0]*(winLength-1), y+d[1]*(winLength-1)
+				if endX < 0 || endX >= width || endY >= height {
+					continue
+				}
+				count := 1
+				for count < winLength && board[(y+d[1]*count)*width+x+d[0]*count] == symbol {
+					count++
+				}
+				if count == winLength {
+					return string(symbol)
+				}
+			}
+		}
+	}
+	return ""
+}
+
+/*
+ * gridOutcome reports whether a K-in-a-row board is won or full.
+ *
+ * Parameters:
+ *   - board (string): The encoded board.
+ *   - width, height (int): The board dimensions.
+ *   - winLength (int): The number of symbols in a row needed to win.
+ *
+ * Returns:
+ *   - bool: True if the game is over.
+ *   - string: The winner symbol, or an empty string for a draw or an unfinished game.
+ */
+func gridOutcome(board string, width, height, winLength int) (bool, string) {
+	if winner := lineWinner(board, width, height, winLength); winner != "" {
+		return true, winner
+	}
+	if len(board) > 0 && !strings.Contains(board, " ") {
+		return true, ""
+	}
+	return false, ""
+}
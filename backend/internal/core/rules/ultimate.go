@@ -0,0 +1,232 @@
+/*
+ * file: ultimate.go
+ * package: rules
+ * description:
+ *     Implements Ultimate (meta) Tic-Tac-Toe: nine classic sub-boards arranged in a 3x3 grid,
+ *     where the cell a player picks decides the sub-board the opponent must play next.
+ */
+
+package rules
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/juan10024/tictactoe-test/internal/core/domain"
+)
+
+// UltimateName is the identifier of the Ultimate Tic-Tac-Toe ruleset.
+const UltimateName = "ultimate"
+
+const (
+	ultimateSize = 9 // The full board is 9x9 cells.
+	subBoardSize = 3 // Each sub-board is 3x3 cells.
+	drawnBoard   = 'D'
+)
+
+/*
+ * Ultimate implements the Ultimate Tic-Tac-Toe ruleset.
+ *
+ * The board is a row-major 9x9 grid, like any other board, so position = row*9 + col.
+ * Sub-boards are numbered 0-8 in row-major order; the cell played inside a sub-board
+ * (also 0-8) is the sub-board the opponent is sent to. If that sub-board is already
+ * decided, the opponent may play in any undecided sub-board.
+ */
+type Ultimate struct{}
+
+/*
+ * NewUltimate creates a new instance of the Ultimate ruleset.
+ *
+ * Returns:
+ *   - *Ultimate: The ruleset instance.
+ */
+func NewUltimate() *Ultimate {
+	return &Ultimate{}
+}
+
+/*
+ * Name returns the ruleset identifier.
+ *
+ * Returns:
+ *   - string: Always UltimateName.
+ */
+func (u *Ultimate) Name() string {
+	return UltimateName
+}
+
+/*
+ * Setup initializes an empty 9x9 board with every sub-board open and X to move.
+ * Board size options are ignored.
+ *
+ * Parameters:
+ *   - game (*domain.Game): The game to initialize.
+ *   - config (domain.GameConfig): The room options.
+ *
+ * Returns:
+ *   - error: Always nil.
+ */
+func (u *Ultimate) Setup(game *domain.Game, config domain.GameConfig) error {
+	game.Ruleset = UltimateName
+	resetGrid(game, ultimateSize, ultimateSize, subBoardSize)
+	game.SubBoardWinners = strings.Repeat(" ", subBoardSize*subBoardSize)
+	game.ActiveBoard = nil
+	return nil
+}
+
+/*
+ * LegalMoves returns the empty cells of the active sub-board, or of every
+ * undecided sub-board when the player is free to choose.
+ *
+ * Parameters:
+ *   - game (*domain.Game): The game to inspect.
+ *
+ * Returns:
+ *   - []int: The playable positions, in ascending order.
+ */
+func (u *Ultimate) LegalMoves(game *domain.Game) []int {
+	if len(game.Board) != ultimateSize*ultimateSize || len(game.SubBoardWinners) != subBoardSize*subBoardSize {
+		return nil
+	}
+	if metaWinner(game.SubBoardWinners) != "" {
+		return nil
+	}
+
+	moves := make([]int, 0, len(game.Board))
+	for _, position := range emptyCells(game.Board) {
+		sub, _ := splitPosition(position)
+		if game.SubBoardWinners[sub] != ' ' {
+			continue
+		}
+		if game.ActiveBoard != nil && *game.ActiveBoard != sub {
+			continue
+		}
+		moves = append(moves, position)
+	}
+	return moves
+}
+
+/*
+ * ApplyMove validates the forced sub-board, places the symbol, records the
+ * sub-board result and selects the sub-board the opponent must play next.
+ *
+ * Parameters:
+ *   - game (*domain.Game): The game to update.
+ *   - position (int): The row-major position on the 9x9 board.
+ *
+ * Returns:
+ *   - error: An error if the position is out of bounds, taken, or outside the active sub-board,
+ *     or if the game has no result for each sub-board.
+ */
+func (u *Ultimate) ApplyMove(game *domain.Game, position int) error {
+	if position < 0 || position >= ultimateSize*ultimateSize || len(game.Board) != ultimateSize*ultimateSize {
+		return errors.New("invalid move: position is out of bounds")
+	}
+	if len(game.SubBoardWinners) != subBoardSize*subBoardSize {
+		return errors.New("invalid game state: sub-board results are missing")
+	}
+
+	sub, cell := splitPosition(position)
+	if game.SubBoardWinners[sub] != ' ' {
+		return errors.New("invalid move: that sub-board is already decided")
+	}
+	if game.ActiveBoard != nil && *game.ActiveBoard != sub {
+		return fmt.Errorf("invalid move: you must play in sub-board %d", *game.ActiveBoard)
+	}
+
+	if err := placeSymbol(game, position); err != nil {
+		return err
+	}
+
+	if finished, winner := gridOutcome(subBoard(game.Board, sub), subBoardSize, subBoardSize, subBoardSize); finished {
+		result := byte(drawnBoard)
+		if winner != "" {
+			result = winner[0]
+		}
+		winners := []byte(game.SubBoardWinners)
+		winners[sub] = result
+		game.SubBoardWinners = string(winners)
+	}
+
+	if game.SubBoardWinners[cell] != ' ' {
+		game.ActiveBoard = nil
+	} else {
+		next := cell
+		game.ActiveBoard = &next
+	}
+	return nil
+}
+
+/*
+ * Outcome checks for three won sub-boards in a row, or a position with no legal moves left.
+ *
+ * Parameters:
+ *   - game (*domain.Game): The game to inspect.
+ *
+ * Returns:
+ *   - bool: True if the game is over.
+ *   - string: The winner symbol, or an empty string for a draw or an unfinished game.
+ */
+func (u *Ultimate) Outcome(game *domain.Game) (bool, string) {
+	if len(game.SubBoardWinners) != subBoardSize*subBoardSize {
+		return false, ""
+	}
+	if winner := metaWinner(game.SubBoardWinners); winner != "" {
+		return true, winner
+	}
+	if !strings.Contains(game.SubBoardWinners, " ") {
+		return true, ""
+	}
+	return false, ""
+}
+
+/*
+ * splitPosition converts a 9x9 board position into its sub-board and the cell inside it.
+ *
+ * Parameters:
+ *   - position (int): The row-major position on the 9x9 board.
+ *
+ * Returns:
+ *   - int: The sub-board index (0-8).
+ *   - int: The cell index inside the sub-board (0-8).
+ */
+func splitPosition(position int) (int, int) {
+	row, col := position/ultimateSize, position%ultimateSize
+	sub := (row/subBoardSize)*subBoardSize + col/subBoardSize
+	cell := (row%subBoardSize)*subBoardSize + col%subBoardSize
+	return sub, cell
+}
+
+/*
+ * subBoard extracts the nine cells of a sub-board as a 3x3 board string.
+ *
+ * Parameters:
+ *   - board (string): The full 9x9 board.
+ *   - sub (int): The sub-board index (0-8).
+ *
+ * Returns:
+ *   - string: The sub-board cells in row-major order.
+ */
+func subBoard(board string, sub int) string {
+	top, left := (sub/subBoardSize)*subBoardSize, (sub%subBoardSize)*subBoardSize
+	cells := make([]byte, 0, subBoardSize*subBoardSize)
+	for r := 0; r < subBoardSize; r++ {
+		start := (top+r)*ultimateSize + left
+		cells = append(cells, board[start:start+subBoardSize]...)
+	}
+	return string(cells)
+}
+
+/*
+ * metaWinner checks the sub-board results for three won sub-boards in a row.
+ * Drawn sub-boards count for nobody.
+ *
+ * Parameters:
+ *   - winners (string): The nine sub-board results.
+ *
+ * Returns:
+ *   - string: The winner symbol, or an empty string if nobody has a line.
+ */
+func metaWinner(winners string) string {
+	return lineWinner(strings.ReplaceAll(winners, string(drawnBoard), " "), subBoardSize, subBoardSize, subBoardSize)
+}
@@ -0,0 +1,179 @@
+/*
+ * file: ultimate_test.go
+ * package: rules
+ * description:
+ *     Tests for the Ultimate ruleset: the forced sub-board, sub-board wins and draws,
+ *     the free choice after a decided sub-board, the meta-board result, and games whose
+ *     stored state is incomplete.
+ */
+
+package rules
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/juan10024/tictactoe-test/internal/core/domain"
+)
+
+// ultimatePosition converts a sub-board and a cell inside it into a 9x9 board position.
+func ultimatePosition(sub, cell int) int {
+	row := (sub/subBoardSize)*subBoardSize + cell/subBoardSize
+	col := (sub%subBoardSize)*subBoardSize + cell%subBoardSize
+	return row*ultimateSize + col
+}
+
+// newUltimateGame returns a freshly set up Ultimate game.
+func newUltimateGame(t *testing.T) *domain.Game {
+	t.Helper()
+	game := &domain.Game{}
+	if err := NewUltimate().Setup(game, domain.GameConfig{}); err != nil {
+		t.Fatal(err)
+	}
+	return game
+}
+
+// play applies moves given as (sub-board, cell) pairs, alternating turns, and fails on any error.
+func play(t *testing.T, game *domain.Game, moves ...[2]int) {
+	t.Helper()
+	for _, m := range moves {
+		if err := NewUltimate().ApplyMove(game, ultimatePosition(m[0], m[1])); err != nil {
+			t.Fatalf("move %v: %v", m, err)
+		}
+		if game.CurrentTurn == "X" {
+			game.CurrentTurn = "O"
+		} else {
+			game.CurrentTurn = "X"
+		}
+	}
+}
+
+func TestSplitPosition(t *testing.T) {
+	for sub := 0; sub < 9; sub++ {
+		for cell := 0; cell < 9; cell++ {
+			gotSub, gotCell := splitPosition(ultimatePosition(sub, cell))
+			if gotSub != sub || gotCell != cell {
+				t.Errorf("splitPosition(%d) = (%d, %d), want (%d, %d)", ultimatePosition(sub, cell), gotSub, gotCell, sub, cell)
+			}
+		}
+	}
+}
+
+func TestUltimateForcedSubBoard(t *testing.T) {
+	game := newUltimateGame(t)
+	if got := len(NewUltimate().LegalMoves(game)); got != 81 {
+		t.Fatalf("LegalMoves() on an empty board = %d moves, want 81", got)
+	}
+
+	// X plays cell 5 of sub-board 4, so O is sent to sub-board 5.
+	play(t, game, [2]int{4, 5})
+	if game.ActiveBoard == nil || *game.ActiveBoard != 5 {
+		t.Fatalf("ActiveBoard = %v, want 5", game.ActiveBoard)
+	}
+	for _, position := range NewUltimate().LegalMoves(game) {
+		if sub, _ := splitPosition(position); sub != 5 {
+			t.Errorf("LegalMoves() includes %d in sub-board %d, want only sub-board 5", position, sub)
+		}
+	}
+	if err := NewUltimate().ApplyMove(game, ultimatePosition(3, 0)); err == nil {
+		t.Errorf("ApplyMove() accepted a move outside the forced sub-board")
+	}
+	play(t, game, [2]int{5, 4})
+	if game.ActiveBoard == nil || *game.ActiveBoard != 4 {
+		t.Errorf("ActiveBoard = %v, want 4", game.ActiveBoard)
+	}
+}
+
+func TestUltimateSubBoardWin(t *testing.T) {
+	game := newUltimateGame(t)
+	// X takes the top row of sub-board 0; O answers in the sub-boards X sends it to.
+	play(t, game,
+		[2]int{0, 1}, [2]int{1, 0},
+		[2]int{0, 2}, [2]int{2, 0},
+		[2]int{0, 0},
+	)
+	if game.SubBoardWinners[0] != 'X' {
+		t.Fatalf("SubBoardWinners = %q, want sub-board 0 won by X", game.SubBoardWinners)
+	}
+	// X's last move sent O to sub-board 0, which is decided, so O may play anywhere undecided.
+	if game.ActiveBoard != nil {
+		t.Errorf("ActiveBoard = %d, want nil after being sent to a decided sub-board", *game.ActiveBoard)
+	}
+	for _, position := range NewUltimate().LegalMoves(game) {
+		if sub, _ := splitPosition(position); sub == 0 {
+			t.Errorf("LegalMoves() includes %d in the decided sub-board 0", position)
+		}
+	}
+	if err := NewUltimate().ApplyMove(game, ultimatePosition(0, 4)); err == nil {
+		t.Errorf("ApplyMove() accepted a move in a decided sub-board")
+	}
+}
+
+func TestUltimateSubBoardDraw(t *testing.T) {
+	game := newUltimateGame(t)
+	game.Board = strings.Repeat(" ", 81)
+	// Sub-board 4 one move from a draw: X to play its last empty cell (8).
+	for cell, symbol := range "XOX" + "XOO" + "OX " {
+		if symbol != ' ' {
+			board := []byte(game.Board)
+			board[ultimatePosition(4, cell)] = byte(symbol)
+			game.Board = string(board)
+		}
+	}
+	four := 4
+	game.ActiveBoard = &four
+
+	play(t, game, [2]int{4, 8})
+	if game.SubBoardWinners[4] != drawnBoard {
+		t.Errorf("SubBoardWinners = %q, want sub-board 4 drawn", game.SubBoardWinners)
+	}
+	if game.ActiveBoard == nil || *game.ActiveBoard != 8 {
+		t.Errorf("ActiveBoard = %v, want 8", game.ActiveBoard)
+	}
+}
+
+func TestUltimateOutcome(t *testing.T) {
+	tests := []struct {
+		name         string
+		winners      string
+		wantFinished bool
+		wantWinner   string
+	}{
+		{"undecided", "         ", false, ""},
+		{"top row", "XXX" + "O O" + "   ", true, "X"},
+		{"diagonal", "O X" + "XO " + "D O", true, "O"},
+		{"drawn boards count for nobody", "DDD" + "XOX" + "OXO", true, ""},
+		{"all decided, no line", "XOX" + "XOO" + "OXX", true, ""},
+		{"missing state", "", false, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			game := newUltimateGame(t)
+			game.SubBoardWinners = tt.winners
+			finished, winner := NewUltimate().Outcome(game)
+			if finished != tt.wantFinished || winner != tt.wantWinner {
+				t.Errorf("Outcome() = (%v, %q), want (%v, %q)", finished, winner, tt.wantFinished, tt.wantWinner)
+			}
+		})
+	}
+}
+
+func TestUltimateRejectsIncompleteState(t *testing.T) {
+	for _, winners := range []string{"", "   "} {
+		game := newUltimateGame(t)
+		game.SubBoardWinners = winners
+		if err := NewUltimate().ApplyMove(game, ultimatePosition(8, 8)); err == nil {
+			t.Errorf("ApplyMove() with sub-board results %q succeeded, want an error", winners)
+		}
+		if moves := NewUltimate().LegalMoves(game); len(moves) != 0 {
+			t.Errorf("LegalMoves() with sub-board results %q = %v, want none", winners, moves)
+		}
+	}
+
+	game := newUltimateGame(t)
+	game.Board = ""
+	if err := NewUltimate().ApplyMove(game, 0); err == nil {
+		t.Errorf("ApplyMove() on an empty board string succeeded, want an error")
+	}
+}
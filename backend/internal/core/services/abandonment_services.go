@@ -0,0 +1,209 @@
+/*
+ * file: abandonment_services.go
+ * package: services
+ * description:
+ *     Handles players who leave: a player who disconnects from a game in progress
+ *     has a grace period to reconnect before the game is forfeited, and waiting
+ *     games nobody is connected to are expired.
+ */
+
+package services
+
+import (
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/juan10024/tictactoe-test/internal/core/domain"
+	"github.com/juan10024/tictactoe-test/internal/core/ports"
+)
+
+/*
+ * forfeitKey builds the Hub timer key of a player's reconnection grace period.
+ *
+ * Parameters:
+ *   - roomID (string): The unique identifier of the room.
+ *   - playerID (uint): The disconnected player's ID.
+ *
+ * Returns:
+ *   - string: The timer key.
+ */
+func forfeitKey(roomID string, playerID uint) string {
+	return fmt.Sprintf("%s#forfeit:%d", roomID, playerID)
+}
+
+/*
+ * scheduleForfeit starts the reconnection grace period of a player who just disconnected.
+ * If the player has not returned when it ends, their game is forfeited.
+ *
+ * Parameters:
+ *   - hub (*Hub): Reference to the Hub managing rooms and clients.
+ *   - gs (*GameService): Service used to forfeit the game.
+ *   - roomID (string): The unique identifier of the room.
+ *   - playerID (uint): The disconnected player's ID.
+ *
+ * Returns:
+ *   - None.
+ */
+func scheduleForfeit(hub *Hub, gs *GameService, roomID string, playerID uint) {
+	hub.scheduleTimer(forfeitKey(roomID, playerID), hub.reconnectGrace, func() {
+		if hub.hasPlayer(roomID, playerID) {
+			return
+		}
+
+		game, err := gs.ForfeitGame(roomID, playerID)
+		if err != nil {
+			log.Printf("ERROR: Could not forfeit game of player %d in room %s: %v", playerID, roomID, err)
+			return
+		}
+		if game != nil {
+			log.Printf("INFO: Player %d abandoned the game in room %s", playerID, roomID)
+			announceGameOver(hub, gs, game)
+		}
+	})
+}
+
+/*
+ * cancelForfeit stops a player's pending grace period because they reconnected.
+ *
+ * Parameters:
+ *   - hub (*Hub): Reference to the Hub holding the timers.
+ *   - roomID (string): The unique identifier of the room.
+ *   - playerID (uint): The returning player's ID.
+ *
+ * Returns:
+ *   - bool: True if the player was within their grace period.
+ */
+func cancelForfeit(hub *Hub, roomID string, playerID uint) bool {
+	return hub.stopTimer(forfeitKey(roomID, playerID))
+}
+
+/*
+ * ForfeitGame ends a room's game in progress as a loss for a player who abandoned it.
+ *
+ * Parameters:
+ *   - roomID (string): The unique identifier of the room.
+ *   - playerID (uint): The player who left.
+ *
+ * Returns:
+ *   - *domain.Game: The finished game, or nil if there was no game in progress for that player.
+ *   - error: An error if the game cannot be loaded or saved.
+ */
+func (s *GameService) ForfeitGame(roomID string, playerID uint) (*domain.Game, error) {
+	var forfeited *domain.Game
+	err := s.inRoom(roomID, func(room *roomActor) error {
+		game, err := room.load(s.repo)
+		if err != nil {
+			return ErrGameNotFound
+		}
+		if game.Status != "in_progress" {
+			return nil
+		}
+
+		seat := game.SeatOf(playerID)
+		if seat == "" {
+			return nil
+		}
+
+		if err := s.saveFinishedGame(room, game, opponentOf(seat), domain.EndReasonAbandoned, nil); err != nil {
+			return err
+		}
+		room.commit(game)
+		forfeited = game
+		return nil
+	})
+	return forfeited, err
+}
+
+/*
+ * ExpireStaleGames marks waiting games older than ttl as expired and closes their
+ * rooms, unless somebody is still connected to the room. Each game is expired on its room's actor, so
+ * a player joining at the same moment is never lost.
+ *
+ * Parameters:
+ *   - ttl (time.Duration): How long a game may wait for an opponent.
+ *   - isActive (func(string) bool): Reports whether a room still has connected clients.
+ *
+ * Returns:
+ *   - int: The number of games expired.
+ *   - error: An error if the stale games cannot be loaded.
+ */
+func (s *GameService) ExpireStaleGames(ttl time.Duration, isActive func(roomID string) bool) (int, error) {
+	games, err := s.repo.GetWaitingGamesBefore(time.Now().Add(-ttl))
+	if err != nil {
+		return 0, err
+	}
+
+	expired := 0
+	for _, stale := range games {
+		if isActive(stale.RoomID) {
+			continue
+		}
+
+		err := s.inRoom(stale.RoomID, func(room *roomActor) error {
+			game, err := room.load(s.repo)
+			if err != nil || game.ID != stale.ID || game.Status != "waiting" {
+				return nil
+			}
+
+			roomRec, err := room.loadRoom(s.roomService)
+			if err != nil {
+				return err
+			}
+
+			game.Status = "expired"
+			game.EndReason = domain.EndReasonExpired
+			err = s.uow.Do(func(games ports.GameRepository, rooms ports.RoomRepository) error {
+				if err := games.Update(game); err != nil {
+					return err
+				}
+				closedAt := time.Now()
+				roomRec.ClosedAt = &closedAt
+				return rooms.UpdateRoom(roomRec)
+			})
+			if err != nil {
+				return err
+			}
+			room.commitRoom(roomRec)
+			room.commit(game)
+			expired++
+			return nil
+		})
+		if err != nil {
+			log.Printf("ERROR: Could not expire game %d in room %s: %v", stale.ID, stale.RoomID, err)
+		}
+	}
+	return expired, nil
+}
+
+/*
+ * RunStaleGameJanitor periodically expires waiting games that nobody is connected to.
+ * It blocks forever and is meant to be started on its own goroutine; a non-positive ttl disables it.
+ *
+ * Parameters:
+ *   - hub (*Hub): Reference to the Hub, used to check for connected clients.
+ *   - gs (*GameService): Service used to expire the games.
+ *   - ttl (time.Duration): How long a game may wait for an opponent.
+ *
+ * Returns:
+ *   - None.
+ */
+func RunStaleGameJanitor(hub *Hub, gs *GameService, ttl time.Duration) {
+	if ttl <= 0 {
+		return
+	}
+
+	ticker := time.NewTicker(min(ttl, time.Minute))
+	defer ticker.Stop()
+
+	for range ticker.C {
+		expired, err := gs.ExpireStaleGames(ttl, hub.hasClients)
+		if err != nil {
+			log.Printf("ERROR: Could not expire stale games: %v", err)
+			continue
+		}
+		if expired > 0 {
+			log.Printf("INFO: Expired %d stale waiting games", expired)
+		}
+	}
+}
@@ -0,0 +1,186 @@
+/*
+ * file: abandonment_services_test.go
+ * package: services
+ * description:
+ *     Tests for abandoned games: the reconnection grace period that forfeits a game
+ *     in progress, and the janitor that expires waiting rooms nobody is connected to.
+ */
+
+package services
+
+import (
+	"testing"
+	"time"
+
+	"github.com/juan10024/tictactoe-test/internal/core/domain"
+	"github.com/juan10024/tictactoe-test/internal/infra/broadcast"
+)
+
+// testGrace is the reconnection grace period of the test hubs.
+const testGrace = 20 * time.Millisecond
+
+// newTestHub starts a hub on the given broadcaster.
+func newTestHub(broadcaster *broadcast.MemoryBroadcaster) *Hub {
+	hub := NewHub(testGrace, broadcaster)
+	go hub.Run()
+	return hub
+}
+
+// connect registers a client without a socket, as ServeWs does once the upgrade succeeded.
+func connect(hub *Hub, roomID string, player *domain.Player, observer bool) *Client {
+	client := &Client{hub: hub, send: make(chan []byte, 256), room: roomID, playerID: player.ID, playerName: player.Name, isObserver: observer}
+	hub.register <- client
+	return client
+}
+
+// waitFor fails the test if cond does not become true within a second.
+func waitFor(t *testing.T, what string, cond func() bool) {
+	t.Helper()
+	for deadline := time.Now().Add(time.Second); !cond(); time.Sleep(time.Millisecond) {
+		if time.Now().After(deadline) {
+			t.Fatalf("timed out waiting until %s", what)
+		}
+	}
+}
+
+// gameStatus returns the status of the room's game as stored.
+func (e *testEnv) gameStatus(t *testing.T, roomID string) string {
+	t.Helper()
+	game, err := e.store.GetByRoomID(roomID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return game.Status
+}
+
+func TestForfeitAfterGracePeriod(t *testing.T) {
+	env := newTestEnv(t)
+	hub := newTestHub(broadcast.NewMemoryBroadcaster())
+	env.startRoom(t, "room-1", domain.GameConfig{})
+
+	scheduleForfeit(hub, env.gs, "room-1", env.alice.ID)
+	waitFor(t, "the game is forfeited", func() bool { return env.gameStatus(t, "room-1") == "finished" })
+
+	game, _ := env.store.GetByRoomID("room-1")
+	if game.EndReason != domain.EndReasonAbandoned || game.WinnerID == nil || *game.WinnerID != env.bob.ID {
+		t.Errorf("forfeited game = %s won by %v, want abandoned and won by bob", game.EndReason, game.WinnerID)
+	}
+	alice, _ := env.store.GetPlayerByID(env.alice.ID)
+	if alice.Losses != 1 {
+		t.Errorf("alice's losses = %d, want 1", alice.Losses)
+	}
+}
+
+func TestNoForfeitWhenThePlayerReturns(t *testing.T) {
+	tests := []struct {
+		name       string
+		connected  bool
+		reconnects bool
+	}{
+		{"reconnects within the grace period", false, true},
+		{"still connected on another socket", true, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			env := newTestEnv(t)
+			hub := newTestHub(broadcast.NewMemoryBroadcaster())
+			env.startRoom(t, "room-1", domain.GameConfig{})
+
+			if tt.connected {
+				connect(hub, "room-1", env.alice, false)
+				waitFor(t, "alice is connected", func() bool { return hub.hasPlayer("room-1", env.alice.ID) })
+			}
+			scheduleForfeit(hub, env.gs, "room-1", env.alice.ID)
+			if tt.reconnects && !cancelForfeit(hub, "room-1", env.alice.ID) {
+				t.Errorf("cancelForfeit() = false within the grace period")
+			}
+
+			time.Sleep(5 * testGrace)
+			if status := env.gameStatus(t, "room-1"); status != "in_progress" {
+				t.Errorf("game status = %s, want in_progress", status)
+			}
+		})
+	}
+}
+
+func TestCancelForfeitAfterExpiry(t *testing.T) {
+	env := newTestEnv(t)
+	hub := newTestHub(broadcast.NewMemoryBroadcaster())
+	env.startRoom(t, "room-1", domain.GameConfig{})
+
+	scheduleForfeit(hub, env.gs, "room-1", env.alice.ID)
+	waitFor(t, "the game is forfeited", func() bool { return env.gameStatus(t, "room-1") == "finished" })
+	if cancelForfeit(hub, "room-1", env.alice.ID) {
+		t.Errorf("cancelForfeit() = true after the grace period ended")
+	}
+}
+
+func TestForfeitGameOnlyEndsTheAbandonersGame(t *testing.T) {
+	tests := []struct {
+		name      string
+		start     bool
+		playerID  func(env *testEnv) uint
+		wantEnded bool
+	}{
+		{"seated player in progress", true, func(env *testEnv) uint { return env.alice.ID }, true},
+		{"game not started", false, func(env *testEnv) uint { return env.alice.ID }, false},
+		{"player without a seat", true, func(env *testEnv) uint { return 999 }, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			env := newTestEnv(t)
+			if tt.start {
+				env.startRoom(t, "room-1", domain.GameConfig{})
+			} else if _, _, err := env.gs.HandleJoinRoom("room-1", env.alice, domain.GameConfig{}, domain.RoomAccess{}); err != nil {
+				t.Fatal(err)
+			}
+
+			game, err := env.gs.ForfeitGame("room-1", tt.playerID(env))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if (game != nil) != tt.wantEnded {
+				t.Errorf("ForfeitGame() = %v, want a finished game: %v", game, tt.wantEnded)
+			}
+		})
+	}
+}
+
+func TestExpireStaleGames(t *testing.T) {
+	env := newTestEnv(t)
+	for _, roomID := range []string{"idle", "watched"} {
+		if _, _, err := env.gs.HandleJoinRoom(roomID, env.alice, domain.GameConfig{}, domain.RoomAccess{}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	env.startRoom(t, "playing", domain.GameConfig{})
+
+	// The store's clock is in the past, so every waiting game is older than the TTL.
+	expired, err := env.gs.ExpireStaleGames(time.Hour, func(roomID string) bool { return roomID == "watched" })
+	if err != nil {
+		t.Fatal(err)
+	}
+	if expired != 1 {
+		t.Errorf("ExpireStaleGames() = %d, want 1", expired)
+	}
+
+	for roomID, want := range map[string]string{"idle": "expired", "watched": "waiting", "playing": "in_progress"} {
+		if status := env.gameStatus(t, roomID); status != want {
+			t.Errorf("room %s game status = %s, want %s", roomID, status, want)
+		}
+	}
+	if room, _ := env.store.GetRoom("idle"); room.ClosedAt == nil {
+		t.Errorf("expired room was not closed")
+	}
+
+	// A player coming back to the expired room gets a new game.
+	game, _, err := env.gs.HandleJoinRoom("idle", env.bob, domain.GameConfig{}, domain.RoomAccess{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if game.Status != "waiting" || game.SeatOf(env.bob.ID) != "X" {
+		t.Errorf("rejoined room = %s with bob as %q, want a waiting game with bob as X", game.Status, game.SeatOf(env.bob.ID))
+	}
+}
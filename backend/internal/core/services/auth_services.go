@@ -0,0 +1,271 @@
+/*
+ * file: auth_services.go
+ * package: services
+ * description:
+ *     Defines player registration, login and guest sessions, and resolves the
+ *     player behind a session token for the HTTP and WebSocket handlers.
+ */
+
+package services
+
+import (
+	"errors"
+	"log"
+	"time"
+
+	"github.com/juan10024/tictactoe-test/internal/core/domain"
+	"github.com/juan10024/tictactoe-test/internal/core/ports"
+)
+
+// sessionTTL is how long a session token stays valid.
+const sessionTTL = 24 * time.Hour
+
+var (
+	// ErrInvalidCredentials is returned when a name and password do not match a registered player.
+	ErrInvalidCredentials = errors.New("invalid player name or password")
+	// ErrNameTaken is returned when a name already belongs to a registered player or a bot.
+	ErrNameTaken = errors.New("this player name is already registered")
+	// ErrUnauthorized is returned when a session token is missing, invalid, or no longer valid for its player.
+	ErrUnauthorized = errors.New("a valid session token is required")
+)
+
+/*
+ * AuthService provides business logic for player identity.
+ *
+ * Fields:
+ *   - repo (ports.GameRepository): Repository used to load and store players.
+ *   - tokens (ports.TokenIssuer): Issues and verifies session tokens.
+ *   - hasher (ports.PasswordHasher): Hashes and checks passwords.
+ */
+type AuthService struct {
+	repo   ports.GameRepository
+	tokens ports.TokenIssuer
+	hasher ports.PasswordHasher
+}
+
+/*
+ * NewAuthService creates a new instance of AuthService.
+ *
+ * Parameters:
+ *   - r (ports.GameRepository): The repository implementation for player data.
+ *   - t (ports.TokenIssuer): The session token implementation.
+ *   - h (ports.PasswordHasher): The password hashing implementation.
+ *
+ * Returns:
+ *   - *AuthService: A new service instance.
+ */
+func NewAuthService(r ports.GameRepository, t ports.TokenIssuer, h ports.PasswordHasher) *AuthService {
+	return &AuthService{repo: r, tokens: t, hasher: h}
+}
+
+/*
+ * Session represents an authenticated session returned to the client.
+ *
+ * Fields:
+ *   - Token (string): The signed session token.
+ *   - ExpiresAt (time.Time): When the token stops being valid.
+ *   - Player (*domain.Player): The authenticated player.
+ */
+type Session struct {
+	Token     string
+	ExpiresAt time.Time
+	Player    *domain.Player
+}
+
+/*
+ * Register claims a player name with a password. A name only used so far for
+ * guest play is converted into a registered account, keeping its stats, but only
+ * by the guest playing under it: the request must carry that guest's session token.
+ *
+ * Parameters:
+ *   - name (string): The player name to register.
+ *   - password (string): The password, between 8 and 72 characters.
+ *   - guestToken (string): The session token of the guest using the name, if any.
+ *
+ * Returns:
+ *   - *Session: A session for the registered player.
+ *   - error: ErrNameTaken if the name is already registered, or used by a guest whose
+ *     token was not presented, or a validation error.
+ */
+func (s *AuthService) Register(name, password, guestToken string) (*Session, error) {
+	if err := validatePlayerName(name); err != nil {
+		return nil, err
+	}
+	if len(password) < 8 || len(password) > 72 {
+		return nil, errors.New("password must be between 8 and 72 characters")
+	}
+
+	hash, err := s.hasher.Hash(password)
+	if err != nil {
+		return nil, err
+	}
+
+	player, err := s.repo.GetPlayerByName(name)
+	switch {
+	case errors.Is(err, ports.ErrNotFound):
+		player = &domain.Player{Name: name, PasswordHash: hash}
+		if err := s.repo.CreatePlayer(player); err != nil {
+			return nil, ErrNameTaken
+		}
+	case err != nil:
+		return nil, err
+	case player.IsRegistered() || player.IsBot:
+		return nil, ErrNameTaken
+	case !s.isGuestSession(guestToken, player):
+		return nil, ErrNameTaken
+	default:
+		player.PasswordHash = hash
+		if err := s.repo.UpdatePlayer(player); err != nil {
+			return nil, err
+		}
+	}
+
+	return s.newSession(player, false)
+}
+
+/*
+ * Login checks a registered player's password.
+ *
+ * Parameters:
+ *   - name (string): The registered player name.
+ *   - password (string): The password.
+ *
+ * Returns:
+ *   - *Session: A session for the player.
+ *   - error: ErrInvalidCredentials if the name is unknown, unregistered, or the password is wrong.
+ */
+func (s *AuthService) Login(name, password string) (*Session, error) {
+	player, err := s.repo.GetPlayerByName(name)
+	if errors.Is(err, ports.ErrNotFound) {
+		return nil, ErrInvalidCredentials
+	}
+	if err != nil {
+		return nil, err
+	}
+	if !player.IsRegistered() || s.hasher.Compare(player.PasswordHash, password) != nil {
+		return nil, ErrInvalidCredentials
+	}
+
+	return s.newSession(player, false)
+}
+
+/*
+ * Guest starts a name-only session. Registered and bot names cannot be used,
+ * so guests cannot impersonate registered players.
+ *
+ * Parameters:
+ *   - name (string): The guest's player name.
+ *
+ * Returns:
+ *   - *Session: A guest session.
+ *   - error: ErrNameTaken if the name belongs to a registered player or a bot, or a validation error.
+ */
+func (s *AuthService) Guest(name string) (*Session, error) {
+	if err := validatePlayerName(name); err != nil {
+		return nil, err
+	}
+
+	player, err := s.repo.GetOrCreatePlayerByName(name)
+	if err != nil {
+		return nil, err
+	}
+	if player.IsRegistered() || player.IsBot {
+		return nil, ErrNameTaken
+	}
+
+	return s.newSession(player, true)
+}
+
+/*
+ * Authenticate resolves the player behind a session token. Guest tokens stop
+ * working once their name has been registered.
+ *
+ * Parameters:
+ *   - token (string): The session token presented by the client.
+ *
+ * Returns:
+ *   - *domain.Player: The authenticated player.
+ *   - error: ErrUnauthorized if the token is missing, invalid, or no longer valid for its player.
+ */
+func (s *AuthService) Authenticate(token string) (*domain.Player, error) {
+	if token == "" {
+		return nil, ErrUnauthorized
+	}
+
+	claims, err := s.tokens.Verify(token)
+	if err != nil {
+		return nil, ErrUnauthorized
+	}
+
+	player, err := s.repo.GetPlayerByID(claims.PlayerID)
+	if err != nil {
+		return nil, err
+	}
+	if player == nil || player.Name != claims.PlayerName || player.IsBot {
+		return nil, ErrUnauthorized
+	}
+	if claims.Guest && player.IsRegistered() {
+		return nil, ErrUnauthorized
+	}
+	return player, nil
+}
+
+/*
+ * isGuestSession reports whether a token is a valid guest session of the given player.
+ *
+ * Parameters:
+ *   - token (string): The session token presented by the client.
+ *   - player (*domain.Player): The guest player.
+ *
+ * Returns:
+ *   - bool: True if the token was issued to that guest.
+ */
+func (s *AuthService) isGuestSession(token string, player *domain.Player) bool {
+	if token == "" {
+		return false
+	}
+	claims, err := s.tokens.Verify(token)
+	return err == nil && claims.Guest && claims.PlayerID == player.ID && claims.PlayerName == player.Name
+}
+
+/*
+ * newSession issues a token for a player.
+ *
+ * Parameters:
+ *   - player (*domain.Player): The authenticated player.
+ *   - guest (bool): Whether this is a guest session.
+ *
+ * Returns:
+ *   - *Session: The new session.
+ *   - error: An error if the token cannot be issued.
+ */
+func (s *AuthService) newSession(player *domain.Player, guest bool) (*Session, error) {
+	expiresAt := time.Now().Add(sessionTTL)
+	token, err := s.tokens.Issue(domain.AuthClaims{
+		PlayerID:   player.ID,
+		PlayerName: player.Name,
+		Guest:      guest,
+		ExpiresAt:  expiresAt,
+	})
+	if err != nil {
+		log.Printf("ERROR: Could not issue session token for %s: %v", player.Name, err)
+		return nil, err
+	}
+	return &Session{Token: token, ExpiresAt: expiresAt, Player: player}, nil
+}
+
+/*
+ * validatePlayerName enforces the player name length rules.
+ *
+ * Parameters:
+ *   - name (string): The player name.
+ *
+ * Returns:
+ *   - error: An error if the name is empty or longer than 15 characters.
+ */
+func validatePlayerName(name string) error {
+	if len(name) == 0 || len(name) > 15 {
+		return errors.New("player name must be between 1 and 15 characters")
+	}
+	return nil
+}
@@ -0,0 +1,325 @@
+/*
+ * file: bot_services.go
+ * package: services
+ * description:
+ *     Defines the computer opponent: how it picks a move for each difficulty level
+ *     and how it takes its turn inside a room.
+ */
+
+package services
+
+import (
+	"errors"
+	"math"
+	"math/rand"
+
+	"github.com/juan10024/tictactoe-test/internal/core/domain"
+	"github.com/juan10024/tictactoe-test/internal/core/ports"
+)
+
+// Bot difficulty levels.
+const (
+	BotRandom  = "random"  // Plays any legal move.
+	BotGreedy  = "greedy"  // Wins when it can, blocks when it must, otherwise plays randomly.
+	BotPerfect = "perfect" // Minimax with alpha-beta pruning.
+)
+
+// botNames maps each difficulty to the name of its player row, so bots show up in stats and ranking.
+var botNames = map[string]string{
+	BotRandom:  "Bot Random",
+	BotGreedy:  "Bot Greedy",
+	BotPerfect: "Bot Perfect",
+}
+
+const (
+	// fullSearchCells is the number of empty cells below which minimax searches to the end of the game.
+	fullSearchCells = 10
+	// searchBudget bounds the number of positions a depth-limited search may visit.
+	searchBudget = 20000
+	// winScore is the minimax value of a won position, before the depth bonus.
+	winScore = 1000
+)
+
+/*
+ * PlayBotTurn makes the computer opponent's move if it is its turn in the room.
+ *
+ * Parameters:
+ *   - roomID (string): The unique identifier of the room.
+ *
+ * Returns:
+ *   - *domain.Game: The updated game, or nil if it was not the bot's turn.
+ *   - int: The position the bot played.
+ *   - error: An error if the move cannot be chosen or applied.
+ */
+func (s *GameService) PlayBotTurn(roomID string) (*domain.Game, int, error) {
+	var played *domain.Game
+	var position int
+	err := s.inRoom(roomID, func(room *roomActor) error {
+		game, err := room.current(s.repo)
+		if err != nil {
+			return ErrGameNotFound
+		}
+		if !game.IsBotTurn() {
+			return nil
+		}
+
+		rules, err := s.rulesFor(game.Ruleset)
+		if err != nil {
+			return err
+		}
+
+		botID := game.PlayerXID
+		if game.BotSymbol == "O" {
+			botID = game.PlayerOID
+		}
+		if botID == nil {
+			return errors.New("bot seat is empty")
+		}
+
+		position, err = chooseBotMove(rules, game, game.BotLevel)
+		if err != nil {
+			return err
+		}
+		played, err = s.applyMove(room, *botID, position)
+		return err
+	})
+	return played, position, err
+}
+
+/*
+ * seatBot validates the requested bot settings and seats the bot in a new game.
+ *
+ * Parameters:
+ *   - game (*domain.Game): The game being created, with the human player still in the X seat.
+ *   - config (domain.GameConfig): The room options.
+ *
+ * Returns:
+ *   - error: An error if the level or symbol is invalid or the bot player cannot be loaded.
+ */
+func (s *GameService) seatBot(game *domain.Game, config domain.GameConfig) error {
+	name, ok := botNames[config.BotLevel]
+	if !ok {
+		return errors.New("bot level must be random, greedy or perfect")
+	}
+
+	symbol := config.BotSymbol
+	if symbol == "" {
+		symbol = "O"
+	}
+	if symbol != "X" && symbol != "O" {
+		return errors.New("bot symbol must be X or O")
+	}
+
+	bot, err := s.repo.GetOrCreateBotPlayer(name)
+	if err != nil {
+		return err
+	}
+	if !bot.IsBot {
+		return errors.New("bot player name is taken by a human player")
+	}
+
+	game.BotLevel = config.BotLevel
+	game.BotSymbol = symbol
+	if symbol == "X" {
+		game.PlayerOID, game.PlayerO = game.PlayerXID, game.PlayerX
+		game.PlayerXID, game.PlayerX = &bot.ID, *bot
+	} else {
+		game.PlayerOID, game.PlayerO = &bot.ID, *bot
+	}
+	return nil
+}
+
+/*
+ * chooseBotMove picks the bot's next move for the given difficulty level.
+ *
+ * Parameters:
+ *   - rules (ports.GameRules): The ruleset of the game.
+ *   - game (*domain.Game): The current game state; it is not modified.
+ *   - level (string): The bot difficulty.
+ *
+ * Returns:
+ *   - int: The chosen position.
+ *   - error: An error if there is no legal move.
+ */
+func chooseBotMove(rules ports.GameRules, game *domain.Game, level string) (int, error) {
+	moves := rules.LegalMoves(game)
+	if len(moves) == 0 {
+		return 0, errors.New("no legal moves available")
+	}
+
+	switch level {
+	case BotGreedy:
+		if position, ok := findWinningMove(rules, game, game.CurrentTurn); ok {
+			return position, nil
+		}
+		if position, ok := findWinningMove(rules, game, opponentOf(game.CurrentTurn)); ok {
+			return position, nil
+		}
+	case BotPerfect:
+		return bestMove(rules, game, moves), nil
+	}
+	return moves[rand.Intn(len(moves))], nil
+}
+
+/*
+ * findWinningMove looks for a legal move that immediately wins the game for symbol.
+ * Checking it for the opponent's symbol finds the move that must be blocked.
+ *
+ * Parameters:
+ *   - rules (ports.GameRules): The ruleset of the game.
+ *   - game (*domain.Game): The current game state; it is not modified.
+ *   - symbol (string): The symbol to test the moves for.
+ *
+ * Returns:
+ *   - int: The winning position.
+ *   - bool: True if such a move exists.
+ */
+func findWinningMove(rules ports.GameRules, game *domain.Game, symbol string) (int, bool) {
+	probe := *game
+	probe.CurrentTurn = symbol
+	for _, position := range rules.LegalMoves(&probe) {
+		next := probe
+		if rules.ApplyMove(&next, position) != nil {
+			continue
+		}
+		if finished, winner := rules.Outcome(&next); finished && winner == symbol {
+			return position, true
+		}
+	}
+	return 0, false
+}
+
+/*
+ * bestMove runs minimax with alpha-beta pruning from the bot's point of view.
+ *
+ * Small positions (such as the whole classic game) are searched to the end, so the
+ * bot never loses there. Larger boards are searched as deep as searchBudget allows.
+ *
+ * Parameters:
+ *   - rules (ports.GameRules): The ruleset of the game.
+ *   - game (*domain.Game): The current game state; it is not modified.
+ *   - moves ([]int): The legal moves in the current position.
+ *
+ * Returns:
+ *   - int: The best position found.
+ */
+func bestMove(rules ports.GameRules, game *domain.Game, moves []int) int {
+	depth := searchDepth(game, len(moves))
+	me := game.CurrentTurn
+
+	best, bestScore := moves[0], math.MinInt
+	alpha, beta := math.MinInt+1, math.MaxInt
+	for _, position := range moves {
+		next := *game
+		if rules.ApplyMove(&next, position) != nil {
+			continue
+		}
+		next.CurrentTurn = opponentOf(next.CurrentTurn)
+
+		score := minimax(rules, &next, me, depth-1, alpha, beta)
+		if score > bestScore {
+			best, bestScore = position, score
+		}
+		alpha = max(alpha, score)
+	}
+	return best
+}
+
+/*
+ * minimax scores a position for the bot, searching at most depth more plies.
+ *
+ * Parameters:
+ *   - rules (ports.GameRules): The ruleset of the game.
+ *   - game (*domain.Game): The position to score, with the player to move in CurrentTurn.
+ *   - me (string): The bot's symbol.
+ *   - depth (int): The remaining search depth.
+ *   - alpha, beta (int): The alpha-beta window.
+ *
+ * Returns:
+ *   - int: Positive if the position favors the bot, negative if it favors the opponent,
+ *     larger in magnitude the sooner the result is reached.
+ */
+func minimax(rules ports.GameRules, game *domain.Game, me string, depth, alpha, beta int) int {
+	if finished, winner := rules.Outcome(game); finished {
+		switch winner {
+		case "":
+			return 0
+		case me:
+			return winScore + depth
+		default:
+			return -winScore - depth
+		}
+	}
+	if depth <= 0 {
+		return 0
+	}
+
+	maximizing := game.CurrentTurn == me
+	best := math.MaxInt
+	if maximizing {
+		best = math.MinInt + 1
+	}
+
+	for _, position := range rules.LegalMoves(game) {
+		next := *game
+		if rules.ApplyMove(&next, position) != nil {
+			continue
+		}
+		next.CurrentTurn = opponentOf(next.CurrentTurn)
+
+		score := minimax(rules, &next, me, depth-1, alpha, beta)
+		if maximizing {
+			best = max(best, score)
+			alpha = max(alpha, score)
+		} else {
+			best = min(best, score)
+			beta = min(beta, score)
+		}
+		if beta <= alpha {
+			break
+		}
+	}
+	return best
+}
+
+/*
+ * searchDepth decides how many plies the perfect bot searches.
+ *
+ * Parameters:
+ *   - game (*domain.Game): The current game state.
+ *   - branching (int): The number of legal moves in the current position.
+ *
+ * Returns:
+ *   - int: The search depth, always at least 2 so immediate wins and blocks are found.
+ */
+func searchDepth(game *domain.Game, branching int) int {
+	empty := 0
+	for i := 0; i < len(game.Board); i++ {
+		if game.Board[i] == ' ' {
+			empty++
+		}
+	}
+	if empty <= fullSearchCells {
+		return empty
+	}
+	if branching < 2 {
+		return 2
+	}
+	return max(2, int(math.Log(searchBudget)/math.Log(float64(branching))))
+}
+
+/*
+ * opponentOf returns the other player's symbol.
+ *
+ * Parameters:
+ *   - symbol (string): "X" or "O".
+ *
+ * Returns:
+ *   - string: "O" for "X" and "X" for "O".
+ */
+func opponentOf(symbol string) string {
+	if symbol == "X" {
+		return "O"
+	}
+	return "X"
+}
@@ -0,0 +1,181 @@
+/*
+ * file: bot_services_test.go
+ * package: services
+ * description:
+ *     Tests for the computer opponent's move choice: the perfect level never loses a
+ *     classic game whatever the opponent plays, every level that looks ahead takes an
+ *     immediate win or blocks one, and the search stays bounded on large boards.
+ */
+
+package services
+
+import (
+	"slices"
+	"testing"
+
+	"github.com/juan10024/tictactoe-test/internal/core/domain"
+	"github.com/juan10024/tictactoe-test/internal/core/ports"
+	"github.com/juan10024/tictactoe-test/internal/core/rules"
+)
+
+// classicGame returns a classic game in the given position.
+func classicGame(board, turn string) *domain.Game {
+	return &domain.Game{Ruleset: rules.ClassicName, Board: board, BoardWidth: 3, BoardHeight: 3, WinLength: 3, CurrentTurn: turn}
+}
+
+/*
+ * explore plays every possible opponent reply against the bot from the given position
+ * and reports the first finished game the bot lost, if any.
+ */
+func explore(t *testing.T, ruleset ports.GameRules, game *domain.Game, bot string) (string, bool) {
+	t.Helper()
+	if finished, winner := ruleset.Outcome(game); finished {
+		return game.Board, winner != "" && winner != bot
+	}
+
+	var replies []int
+	if game.CurrentTurn == bot {
+		position, err := chooseBotMove(ruleset, game, BotPerfect)
+		if err != nil {
+			t.Fatalf("chooseBotMove(%q) = %v", game.Board, err)
+		}
+		replies = []int{position}
+	} else {
+		replies = ruleset.LegalMoves(game)
+	}
+
+	for _, position := range replies {
+		next := *game
+		if err := ruleset.ApplyMove(&next, position); err != nil {
+			t.Fatalf("ApplyMove(%q, %d) = %v", game.Board, position, err)
+		}
+		next.CurrentTurn = opponentOf(next.CurrentTurn)
+		if board, lost := explore(t, ruleset, &next, bot); lost {
+			return board, true
+		}
+	}
+	return "", false
+}
+
+func TestPerfectBotNeverLosesClassic(t *testing.T) {
+	for _, bot := range []string{"X", "O"} {
+		if board, lost := explore(t, rules.NewClassic(), classicGame("         ", "X"), bot); lost {
+			t.Errorf("perfect bot playing %s lost with board %q", bot, board)
+		}
+	}
+}
+
+func TestBotTakesWinOrBlocks(t *testing.T) {
+	tests := []struct {
+		name  string
+		board string
+		turn  string
+		want  []int
+	}{
+		{"wins on a row", "XX " + "OO " + "   ", "X", []int{2}},
+		{"wins instead of blocking", "OO " + "XX " + "  X", "O", []int{2}},
+		{"wins on a diagonal", "O X" + " OX" + "   ", "O", []int{8}},
+		{"blocks a column", "X O" + "X  " + "   ", "O", []int{6}},
+		{"blocks an anti-diagonal", "  O" + " O " + "XX ", "X", []int{8}},
+	}
+
+	for _, level := range []string{BotGreedy, BotPerfect} {
+		for _, tt := range tests {
+			t.Run(level+"/"+tt.name, func(t *testing.T) {
+				got, err := chooseBotMove(rules.NewClassic(), classicGame(tt.board, tt.turn), level)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if !slices.Contains(tt.want, got) {
+					t.Errorf("chooseBotMove(%q) = %d, want one of %v", tt.board, got, tt.want)
+				}
+			})
+		}
+	}
+}
+
+func TestRandomBotPlaysLegalMoves(t *testing.T) {
+	game := classicGame("XO "+" X "+"O  ", "X")
+	legal := rules.NewClassic().LegalMoves(game)
+	for i := 0; i < 50; i++ {
+		got, err := chooseBotMove(rules.NewClassic(), game, BotRandom)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !slices.Contains(legal, got) {
+			t.Fatalf("chooseBotMove() = %d, not one of the legal moves %v", got, legal)
+		}
+	}
+
+	if _, err := chooseBotMove(rules.NewClassic(), classicGame("XOX"+"XOO"+"OXX", "X"), BotRandom); err == nil {
+		t.Errorf("chooseBotMove() on a finished board succeeded, want an error")
+	}
+}
+
+func TestSearchDepth(t *testing.T) {
+	tests := []struct {
+		name      string
+		cells     int
+		empty     int
+		branching int
+		want      int
+	}{
+		{"classic opening searches to the end", 9, 9, 9, 9},
+		{"few cells left searches to the end", 225, fullSearchCells, fullSearchCells, fullSearchCells},
+		{"large board keeps the minimum depth", 225, 225, 225, 2},
+		{"medium branching searches deeper", 225, 40, 12, 3},
+		{"single reply", 225, 100, 1, 2},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			board := make([]byte, tt.cells)
+			for i := range board {
+				board[i] = 'X'
+				if i < tt.empty {
+					board[i] = ' '
+				}
+			}
+			if got := searchDepth(&domain.Game{Board: string(board)}, tt.branching); got != tt.want {
+				t.Errorf("searchDepth() = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPerfectBotOnLargeBoard(t *testing.T) {
+	gomoku := rules.NewGomoku()
+	game := &domain.Game{}
+	if err := gomoku.Setup(game, domain.GameConfig{Width: 15, Height: 15, WinLength: 5}); err != nil {
+		t.Fatal(err)
+	}
+
+	// X has four in a row on the middle row, already blocked on the left; O to move must block the right end.
+	board := []byte(game.Board)
+	for x := 5; x < 9; x++ {
+		board[7*15+x] = 'X'
+	}
+	board[7*15+4] = 'O'
+	board[0] = 'O'
+	board[1] = 'O'
+	game.Board = string(board)
+	game.CurrentTurn = "O"
+
+	got, err := chooseBotMove(gomoku, game, BotPerfect)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got != 7*15+9 {
+		t.Errorf("chooseBotMove() = %d, want the block at %d", got, 7*15+9)
+	}
+
+	// With X to move, the bot completes its own line.
+	game.CurrentTurn = "X"
+	got, err = chooseBotMove(gomoku, game, BotPerfect)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got != 7*15+9 {
+		t.Errorf("chooseBotMove() = %d, want the win at %d", got, 7*15+9)
+	}
+}
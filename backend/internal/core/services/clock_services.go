@@ -0,0 +1,250 @@
+/*
+ * file: clock_services.go
+ * package: services
+ * description:
+ *     Implements chess-style time controls. The server owns the clock: it charges
+ *     the mover's time when a move is processed and ends the game on time when a
+ *     player's clock runs out.
+ */
+
+package services
+
+import (
+	"errors"
+	"time"
+
+	"github.com/juan10024/tictactoe-test/internal/core/domain"
+	"github.com/juan10024/tictactoe-test/internal/core/protocol"
+)
+
+// Time control limits, in seconds.
+const (
+	maxMoveTime  = 10 * 60
+	maxBaseTime  = 3 * 60 * 60
+	maxIncrement = 60
+)
+
+/*
+ * applyTimeControl validates the requested time control and stores it on a new game.
+ *
+ * Parameters:
+ *   - game (*domain.Game): The game being created.
+ *   - config (domain.GameConfig): The room options.
+ *
+ * Returns:
+ *   - error: An error if the values are negative, too large, or combined inconsistently.
+ */
+func applyTimeControl(game *domain.Game, config domain.GameConfig) error {
+	if config.MoveTime < 0 || config.BaseTime < 0 || config.Increment < 0 {
+		return errors.New("time control values cannot be negative")
+	}
+	if config.MoveTime > 0 && config.BaseTime > 0 {
+		return errors.New("choose either a time per move or a total time, not both")
+	}
+	if config.Increment > 0 && config.BaseTime == 0 {
+		return errors.New("an increment requires a total time")
+	}
+	if config.MoveTime > maxMoveTime || config.BaseTime > maxBaseTime || config.Increment > maxIncrement {
+		return errors.New("time control values are too large")
+	}
+
+	game.MoveTime = config.MoveTime
+	game.BaseTime = config.BaseTime
+	game.Increment = config.Increment
+	return nil
+}
+
+/*
+ * startClock fills both clocks and starts the first player's turn.
+ *
+ * Parameters:
+ *   - game (*domain.Game): The game that has just started.
+ *   - now (time.Time): The start instant.
+ *
+ * Returns:
+ *   - None.
+ */
+func startClock(game *domain.Game, now time.Time) {
+	if !game.HasClock() {
+		return
+	}
+
+	budget := int64(game.BaseTime) * 1000
+	if game.MoveTime > 0 {
+		budget = int64(game.MoveTime) * 1000
+	}
+	game.TimeLeftX, game.TimeLeftO = budget, budget
+	game.TurnStartedAt = &now
+}
+
+/*
+ * clockExpired reports whether the player to move has run out of time.
+ *
+ * Parameters:
+ *   - game (*domain.Game): The game in progress.
+ *   - now (time.Time): The instant to check.
+ *
+ * Returns:
+ *   - bool: True if the mover's clock reached zero.
+ */
+func clockExpired(game *domain.Game, now time.Time) bool {
+	deadline, ok := turnDeadline(game)
+	return ok && !now.Before(deadline)
+}
+
+/*
+ * switchClock charges the time spent by the player who just moved and starts the
+ * clock of the player now in CurrentTurn.
+ *
+ * Parameters:
+ *   - game (*domain.Game): The game, with CurrentTurn already passed to the next player.
+ *   - now (time.Time): The instant the move was processed.
+ *
+ * Returns:
+ *   - None.
+ */
+func switchClock(game *domain.Game, now time.Time) {
+	if game.TurnStartedAt == nil {
+		startClock(game, now)
+		return
+	}
+
+	mover := timeLeftOf(game, opponentOf(game.CurrentTurn))
+	if game.MoveTime > 0 {
+		*mover = int64(game.MoveTime) * 1000
+		*timeLeftOf(game, game.CurrentTurn) = int64(game.MoveTime) * 1000
+	} else {
+		*mover -= now.Sub(*game.TurnStartedAt).Milliseconds()
+		*mover += int64(game.Increment) * 1000
+	}
+	game.TurnStartedAt = &now
+}
+
+/*
+ * turnDeadline computes when the current player's clock runs out.
+ *
+ * Parameters:
+ *   - game (*domain.Game): The game to inspect.
+ *
+ * Returns:
+ *   - time.Time: The deadline.
+ *   - bool: False if the game has no running clock.
+ */
+func turnDeadline(game *domain.Game) (time.Time, bool) {
+	if !game.HasClock() || game.Status != "in_progress" || game.TurnStartedAt == nil {
+		return time.Time{}, false
+	}
+	left := *timeLeftOf(game, game.CurrentTurn)
+	return game.TurnStartedAt.Add(time.Duration(left) * time.Millisecond), true
+}
+
+/*
+ * clockSnapshot computes both players' remaining time at a given instant.
+ *
+ * Parameters:
+ *   - game (*domain.Game): The game to inspect.
+ *   - now (time.Time): The instant of the snapshot.
+ *
+ * Returns:
+ *   - *protocol.ClockSnapshot: The remaining times, or nil if the game has no time control.
+ */
+func clockSnapshot(game *domain.Game, now time.Time) *protocol.ClockSnapshot {
+	if !game.HasClock() {
+		return nil
+	}
+
+	snapshot := &protocol.ClockSnapshot{TimeLeftX: game.TimeLeftX, TimeLeftO: game.TimeLeftO}
+	if deadline, ok := turnDeadline(game); ok {
+		remaining := max(deadline.Sub(now).Milliseconds(), 0)
+		if game.CurrentTurn == "X" {
+			snapshot.TimeLeftX = remaining
+		} else {
+			snapshot.TimeLeftO = remaining
+		}
+		snapshot.Running = game.CurrentTurn
+	}
+	return snapshot
+}
+
+/*
+ * timeLeftOf returns a pointer to the clock field of the given symbol.
+ *
+ * Parameters:
+ *   - game (*domain.Game): The game.
+ *   - symbol (string): "X" or "O".
+ *
+ * Returns:
+ *   - *int64: The player's remaining time field.
+ */
+func timeLeftOf(game *domain.Game, symbol string) *int64 {
+	if symbol == "X" {
+		return &game.TimeLeftX
+	}
+	return &game.TimeLeftO
+}
+
+/*
+ * StartGame moves a room's game from waiting to in progress and starts its clock,
+ * once both seats are taken.
+ *
+ * Parameters:
+ *   - roomID (string): The unique identifier of the room.
+ *
+ * Returns:
+ *   - *domain.Game: The started game, or nil if the game was not ready to start.
+ *   - error: An error if the game cannot be loaded or saved.
+ */
+func (s *GameService) StartGame(roomID string) (*domain.Game, error) {
+	var started *domain.Game
+	err := s.inRoom(roomID, func(room *roomActor) error {
+		game, err := room.load(s.repo)
+		if err != nil {
+			return ErrGameNotFound
+		}
+		if game.Status != "waiting" || game.PlayerXID == nil || game.PlayerOID == nil {
+			return nil
+		}
+
+		game.Status = "in_progress"
+		game.CurrentTurn = "X"
+		startClock(game, time.Now())
+		if err := s.repo.Update(game); err != nil {
+			return err
+		}
+		room.commit(game)
+		started = game
+		return nil
+	})
+	return started, err
+}
+
+/*
+ * TimeoutGame ends a room's game as a loss for the player to move if their clock has run out.
+ *
+ * Parameters:
+ *   - roomID (string): The unique identifier of the room.
+ *
+ * Returns:
+ *   - *domain.Game: The finished game, or nil if no clock had run out.
+ *   - error: An error if the game cannot be loaded or saved.
+ */
+func (s *GameService) TimeoutGame(roomID string) (*domain.Game, error) {
+	var finished *domain.Game
+	err := s.inRoom(roomID, func(room *roomActor) error {
+		game, err := room.load(s.repo)
+		if err != nil {
+			return ErrGameNotFound
+		}
+		if !clockExpired(game, time.Now()) {
+			return nil
+		}
+
+		if err := s.saveFinishedGame(room, game, opponentOf(game.CurrentTurn), domain.EndReasonTimeout, nil); err != nil {
+			return err
+		}
+		room.commit(game)
+		finished = game
+		return nil
+	})
+	return finished, err
+}
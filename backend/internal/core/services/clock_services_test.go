@@ -0,0 +1,142 @@
+/*
+ * file: clock_services_test.go
+ * package: services
+ * description:
+ *     Tests for the server-owned clock: time control validation, per-move and
+ *     base-plus-increment accounting, and the timeout at zero.
+ */
+
+package services
+
+import (
+	"testing"
+	"time"
+
+	"github.com/juan10024/tictactoe-test/internal/core/domain"
+)
+
+var clockStart = time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+
+// startedGame returns an in-progress game with the given time control and its clock started at clockStart.
+func startedGame(t *testing.T, config domain.GameConfig) *domain.Game {
+	t.Helper()
+	game := &domain.Game{Status: "in_progress", CurrentTurn: "X"}
+	if err := applyTimeControl(game, config); err != nil {
+		t.Fatal(err)
+	}
+	startClock(game, clockStart)
+	return game
+}
+
+// move passes the turn as the game service does after a move, at the given offset from clockStart.
+func move(game *domain.Game, after time.Duration) {
+	game.CurrentTurn = opponentOf(game.CurrentTurn)
+	switchClock(game, clockStart.Add(after))
+}
+
+func TestApplyTimeControl(t *testing.T) {
+	tests := []struct {
+		name    string
+		config  domain.GameConfig
+		wantErr bool
+	}{
+		{"no clock", domain.GameConfig{}, false},
+		{"per move", domain.GameConfig{MoveTime: 30}, false},
+		{"base time", domain.GameConfig{BaseTime: 300}, false},
+		{"base time with increment", domain.GameConfig{BaseTime: 180, Increment: 2}, false},
+		{"largest values", domain.GameConfig{BaseTime: maxBaseTime, Increment: maxIncrement}, false},
+		{"negative", domain.GameConfig{MoveTime: -1}, true},
+		{"per move and base time", domain.GameConfig{MoveTime: 30, BaseTime: 300}, true},
+		{"increment without base time", domain.GameConfig{Increment: 2}, true},
+		{"increment with per move", domain.GameConfig{MoveTime: 30, Increment: 2}, true},
+		{"move time too large", domain.GameConfig{MoveTime: maxMoveTime + 1}, true},
+		{"increment too large", domain.GameConfig{BaseTime: 60, Increment: maxIncrement + 1}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			game := &domain.Game{}
+			err := applyTimeControl(game, tt.config)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("applyTimeControl() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && (game.MoveTime != tt.config.MoveTime || game.BaseTime != tt.config.BaseTime || game.Increment != tt.config.Increment) {
+				t.Errorf("applyTimeControl() stored %d/%d/%d", game.MoveTime, game.BaseTime, game.Increment)
+			}
+		})
+	}
+}
+
+func TestPerMoveClock(t *testing.T) {
+	game := startedGame(t, domain.GameConfig{MoveTime: 10})
+	if game.TimeLeftX != 10000 || game.TimeLeftO != 10000 {
+		t.Fatalf("clocks = %d/%d, want 10000 each", game.TimeLeftX, game.TimeLeftO)
+	}
+
+	// X thinks for 7s: both clocks are reset to a full move for O's turn.
+	move(game, 7*time.Second)
+	if game.TimeLeftX != 10000 || game.TimeLeftO != 10000 {
+		t.Errorf("clocks after a move = %d/%d, want 10000 each", game.TimeLeftX, game.TimeLeftO)
+	}
+	if clockExpired(game, clockStart.Add(16*time.Second)) {
+		t.Errorf("O's clock expired after 9s of a 10s move")
+	}
+	if !clockExpired(game, clockStart.Add(17*time.Second)) {
+		t.Errorf("O's clock did not expire after 10s of a 10s move")
+	}
+}
+
+func TestBaseTimeWithIncrement(t *testing.T) {
+	game := startedGame(t, domain.GameConfig{BaseTime: 60, Increment: 2})
+
+	// X spends 5s and gets 2s back; O's clock starts at the move.
+	move(game, 5*time.Second)
+	if game.TimeLeftX != 57000 {
+		t.Errorf("X's clock = %d, want 57000", game.TimeLeftX)
+	}
+	if game.TimeLeftO != 60000 {
+		t.Errorf("O's clock = %d, want 60000 while it was not running", game.TimeLeftO)
+	}
+
+	// O spends 1.5s and gets 2s back.
+	move(game, 6500*time.Millisecond)
+	if game.TimeLeftO != 60500 {
+		t.Errorf("O's clock = %d, want 60500", game.TimeLeftO)
+	}
+
+	snapshot := clockSnapshot(game, clockStart.Add(16500*time.Millisecond))
+	if snapshot.Running != "X" || snapshot.TimeLeftX != 47000 || snapshot.TimeLeftO != 60500 {
+		t.Errorf("clockSnapshot() = %+v, want X running with 47000 left and O at 60500", snapshot)
+	}
+}
+
+func TestClockTimesOutAtZero(t *testing.T) {
+	game := startedGame(t, domain.GameConfig{BaseTime: 5})
+	deadline := clockStart.Add(5 * time.Second)
+
+	if clockExpired(game, deadline.Add(-time.Millisecond)) {
+		t.Errorf("clock expired a millisecond before reaching zero")
+	}
+	if !clockExpired(game, deadline) {
+		t.Errorf("clock did not expire on reaching zero")
+	}
+	if snapshot := clockSnapshot(game, deadline.Add(time.Second)); snapshot.TimeLeftX != 0 {
+		t.Errorf("clockSnapshot() past the deadline = %d left, want 0", snapshot.TimeLeftX)
+	}
+
+	game.Status = "finished"
+	if clockExpired(game, deadline.Add(time.Hour)) {
+		t.Errorf("a finished game timed out")
+	}
+}
+
+func TestNoClock(t *testing.T) {
+	game := startedGame(t, domain.GameConfig{})
+	move(game, time.Hour)
+	if clockExpired(game, clockStart.Add(24*time.Hour)) {
+		t.Errorf("a game without a time control timed out")
+	}
+	if snapshot := clockSnapshot(game, clockStart); snapshot != nil {
+		t.Errorf("clockSnapshot() = %+v, want nil without a time control", snapshot)
+	}
+}
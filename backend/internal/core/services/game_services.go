@@ -9,20 +9,48 @@ package services
 
 import (
 	"errors"
+	"fmt"
 	"strings"
+	"time"
 
 	"github.com/juan10024/tictactoe-test/internal/core/domain"
 	"github.com/juan10024/tictactoe-test/internal/core/ports"
 )
 
+var (
+	// ErrGameNotFound is returned when the requested game or room has no game.
+	ErrGameNotFound = errors.New("game not found")
+	// ErrTimeUp is returned when a move arrives after the mover's clock ran out; the game is lost on time.
+	ErrTimeUp = errors.New("time is up: the game was lost on time")
+	// ErrGameConflict is returned when the game was changed elsewhere while a request was applied;
+	// nothing was saved and the request can be sent again.
+	ErrGameConflict = errors.New("the game was changed by another request, please try again")
+	// ErrRoomCommandFailed is returned when a command panicked on its room's actor; the room
+	// is reloaded from the repository before its next command.
+	ErrRoomCommandFailed = errors.New("the room could not process the request")
+)
+
+// maxConflictRetries is how many times a player update is retried after losing a version race.
+const maxConflictRetries = 3
+
 /*
  * GameService provides business logic for game management and player actions.
  *
  * Fields:
  *   - repo (ports.GameRepository): Repository used to persist and retrieve game data.
+ *   - uow (ports.UnitOfWork): Runs the writes that finish a game in a single transaction.
+ *   - roomService (*RoomService): Enforces each room's privacy settings on join.
+ *   - rules (map[string]ports.GameRules): Available rulesets, keyed by name.
+ *   - defaultRuleset (string): Ruleset used when a room is created without choosing one.
+ *   - rooms (*roomRegistry): The actors holding each active room's game in memory.
  */
 type GameService struct {
-	repo ports.GameRepository
+	repo           ports.GameRepository
+	uow            ports.UnitOfWork
+	roomService    *RoomService
+	rules          map[string]ports.GameRules
+	defaultRuleset string
+	rooms          *roomRegistry
 }
 
 /*
@@ -30,12 +58,43 @@ type GameService struct {
  *
  * Parameters:
  *   - r (ports.GameRepository): The repository implementation for game data.
+ *   - uow (ports.UnitOfWork): The transaction implementation bound to the same storage.
+ *   - roomService (*RoomService): The service holding room settings.
+ *   - rulesets (...ports.GameRules): The available rulesets; the first one is the default.
  *
  * Returns:
  *   - *GameService: A new service instance configured with the provided repository.
  */
-func NewGameService(r ports.GameRepository) *GameService {
-	return &GameService{repo: r}
+func NewGameService(r ports.GameRepository, uow ports.UnitOfWork, roomService *RoomService, rulesets ...ports.GameRules) *GameService {
+	gs := &GameService{repo: r, uow: uow, roomService: roomService, rules: make(map[string]ports.GameRules), rooms: newRoomRegistry()}
+	for _, rs := range rulesets {
+		if gs.defaultRuleset == "" {
+			gs.defaultRuleset = rs.Name()
+		}
+		gs.rules[rs.Name()] = rs
+	}
+	return gs
+}
+
+/*
+ * rulesFor resolves a ruleset by name, falling back to the default ruleset.
+ *
+ * Parameters:
+ *   - name (string): The ruleset identifier; empty selects the default.
+ *
+ * Returns:
+ *   - ports.GameRules: The matching ruleset.
+ *   - error: An error if no ruleset is registered under that name.
+ */
+func (s *GameService) rulesFor(name string) (ports.GameRules, error) {
+	if name == "" {
+		name = s.defaultRuleset
+	}
+	rules, ok := s.rules[name]
+	if !ok {
+		return nil, fmt.Errorf("unknown ruleset: %s", name)
+	}
+	return rules, nil
 }
 
 /*
@@ -53,102 +112,154 @@ func (gs *GameService) GetPlayerByID(id uint) (*domain.Player, error) {
 }
 
 /*
- * HandleJoinRoom allows a player to join or create a game room.
+ * HandleJoinRoom allows an authenticated player to join or create a game room.
+ * A player already seated in the room gets the room's game back; anybody else,
+ * observers included, must satisfy the room's privacy settings.
  *
  * Parameters:
  *   - roomID (string): The unique identifier of the room.
- *   - playerName (string): The name of the player joining the room.
+ *   - player (*domain.Player): The authenticated player joining the room.
+ *   - config (domain.GameConfig): Options applied if the room has to be created.
+ *   - access (domain.RoomAccess): The password or invite for a private room.
  *
  * Returns:
  *   - *domain.Game: The game instance for the room.
  *   - *domain.Player: The player instance that joined.
- *   - error: An error if joining or creating the room fails.
+ *   - error: ErrRoomAccessDenied if the room is private, or an error if joining or creating the room fails.
  */
-func (s *GameService) HandleJoinRoom(roomID, playerName string) (*domain.Game, *domain.Player, error) {
-	if len(playerName) == 0 || len(playerName) > 15 {
-		return nil, nil, errors.New("player name must be between 1 and 15 characters")
+func (s *GameService) HandleJoinRoom(roomID string, player *domain.Player, config domain.GameConfig, access domain.RoomAccess) (*domain.Game, *domain.Player, error) {
+	if player == nil || player.IsBot {
+		return nil, nil, errors.New("a human player is required to join a room")
 	}
 
-	player, err := s.repo.GetOrCreatePlayerByName(playerName)
+	var game *domain.Game
+	err := s.inRoom(roomID, func(room *roomActor) error {
+		var err error
+		game, err = s.joinRoom(room, player, config, access)
+		return err
+	})
 	if err != nil {
 		return nil, nil, err
 	}
+	return game, player, nil
+}
 
-	existingGame, err := s.repo.GetByRoomID(roomID)
+/*
+ * joinRoom seats a player in the room's game, creating the game if the room has none.
+ * It runs on the room's actor.
+ *
+ * Parameters:
+ *   - room (*roomActor): The room's actor.
+ *   - player (*domain.Player): The authenticated player joining the room.
+ *   - config (domain.GameConfig): Options applied if the room has to be created.
+ *   - access (domain.RoomAccess): The password or invite for a private room.
+ *
+ * Returns:
+ *   - *domain.Game: The room's game after the join.
+ *   - error: An error if the player may not enter, or joining or creating the room fails.
+ */
+func (s *GameService) joinRoom(room *roomActor, player *domain.Player, config domain.GameConfig, access domain.RoomAccess) (*domain.Game, error) {
+	roomRec, err := s.roomService.enterRoom(room.roomID, player, config, access)
 	if err != nil {
-		newGame := &domain.Game{
-			RoomID:      roomID,
-			PlayerXID:   &player.ID,
-			PlayerX:     *player,
-			Status:      "waiting",
-			Board:       "         ",
-			CurrentTurn: "X",
-		}
-
-		if createErr := s.repo.Create(newGame); createErr != nil {
-
-			finalGame, finalErr := s.repo.GetByRoomID(roomID)
-			if finalErr != nil {
-				return nil, nil, errors.New("failed to retrieve game after creation attempt: " + finalErr.Error())
-			}
-
-			if finalGame.PlayerXID != nil && finalGame.PlayerX.Name != "" {
-				if strings.EqualFold(finalGame.PlayerX.Name, playerName) {
-					return nil, nil, errors.New("a player with this name already exists in the room")
-				}
-			}
-			if finalGame.PlayerOID != nil && finalGame.PlayerO.Name != "" {
-				if strings.EqualFold(finalGame.PlayerO.Name, playerName) {
-					return nil, nil, errors.New("a player with this name already exists in the room")
-				}
-			}
-
-			if (finalGame.PlayerXID != nil && *finalGame.PlayerXID == player.ID) ||
-				(finalGame.PlayerOID != nil && *finalGame.PlayerOID == player.ID) {
-				return finalGame, player, nil
-			}
+		return nil, err
+	}
 
-			if finalGame.PlayerXID != nil && finalGame.PlayerOID != nil {
-				return finalGame, player, nil // Convertir en observador
-			}
+	existingGame, err := room.load(s.repo)
+	if err != nil || existingGame.Status == "expired" {
+		rules, rulesErr := s.rulesFor(config.Ruleset)
+		if rulesErr != nil {
+			return nil, rulesErr
+		}
 
-			if finalGame.PlayerXID != nil && *finalGame.PlayerXID != player.ID && finalGame.PlayerOID == nil {
-				finalGame.PlayerOID = &player.ID
-				finalGame.PlayerO = *player
-				if updateErr := s.repo.Update(finalGame); updateErr != nil {
-					return nil, nil, updateErr
-				}
-				return finalGame, player, nil
+		newGame := &domain.Game{
+			RoomID:    room.roomID,
+			PlayerXID: &player.ID,
+			PlayerX:   *player,
+			Status:    "waiting",
+		}
+		if setupErr := rules.Setup(newGame, config); setupErr != nil {
+			return nil, setupErr
+		}
+		if clockErr := applyTimeControl(newGame, config); clockErr != nil {
+			return nil, clockErr
+		}
+		if config.BotLevel != "" {
+			if botErr := s.seatBot(newGame, config); botErr != nil {
+				return nil, botErr
 			}
+		}
+		assignResumeTokens(newGame)
 
-			return finalGame, player, nil
+		if createErr := s.createGame(room, roomRec, newGame); createErr != nil {
+			return nil, createErr
 		}
-		return newGame, player, nil
+		room.commit(newGame)
+		return newGame, nil
 	}
 
-	if existingGame.PlayerX.Name != "" && strings.EqualFold(existingGame.PlayerX.Name, playerName) {
-		return nil, nil, errors.New("a player with this name already exists in the room")
+	if existingGame.SeatOf(player.ID) != "" {
+		return existingGame, nil
 	}
 
-	if existingGame.PlayerO.Name != "" && strings.EqualFold(existingGame.PlayerO.Name, playerName) {
-		return nil, nil, errors.New("a player with this name already exists in the room")
+	if existingGame.PlayerX.Name != "" && strings.EqualFold(existingGame.PlayerX.Name, player.Name) {
+		return nil, errors.New("a player with this name already exists in the room")
 	}
 
-	if (existingGame.PlayerXID != nil && *existingGame.PlayerXID == player.ID) ||
-		(existingGame.PlayerOID != nil && *existingGame.PlayerOID == player.ID) {
-		return existingGame, player, nil
+	if existingGame.PlayerO.Name != "" && strings.EqualFold(existingGame.PlayerO.Name, player.Name) {
+		return nil, errors.New("a player with this name already exists in the room")
 	}
 
 	if existingGame.Status == "waiting" && existingGame.PlayerOID == nil && existingGame.PlayerXID != nil {
 		existingGame.PlayerOID = &player.ID
 		existingGame.PlayerO = *player
-		if err := s.repo.Update(existingGame); err != nil {
-			return nil, nil, err
+		assignResumeTokens(existingGame)
+		err := s.uow.Do(func(games ports.GameRepository, rooms ports.RoomRepository) error {
+			if err := games.Update(existingGame); err != nil {
+				return err
+			}
+			roomRec.TakeSeats(existingGame)
+			return rooms.UpdateRoom(roomRec)
+		})
+		if err != nil {
+			return nil, err
 		}
-		return existingGame, player, nil
+		room.commitRoom(roomRec)
+		room.commit(existingGame)
 	}
 
-	return existingGame, player, nil
+	return existingGame, nil
+}
+
+/*
+ * createGame stores a new game as the room's current game and seats its players in
+ * the room, all in one unit of work. A closed room is reopened with fresh seats and score.
+ * The updated room record becomes the actor's; committing the game is left to the caller.
+ *
+ * Parameters:
+ *   - room (*roomActor): The room's actor.
+ *   - roomRec (*domain.Room): A private copy of the room the game is played in.
+ *   - game (*domain.Game): The new game.
+ *
+ * Returns:
+ *   - error: An error if any write fails; nothing is persisted in that case.
+ */
+func (s *GameService) createGame(room *roomActor, roomRec *domain.Room, game *domain.Game) error {
+	err := s.uow.Do(func(games ports.GameRepository, rooms ports.RoomRepository) error {
+		if err := games.Create(game); err != nil {
+			return err
+		}
+		if roomRec.ClosedAt != nil {
+			roomRec.Reopen()
+		}
+		roomRec.CurrentGameID = &game.ID
+		roomRec.TakeSeats(game)
+		return rooms.UpdateRoom(roomRec)
+	})
+	if err != nil {
+		return err
+	}
+	room.commitRoom(roomRec)
+	return nil
 }
 
 /*
@@ -158,125 +269,223 @@ func (s *GameService) HandleJoinRoom(roomID, playerName string) (*domain.Game, *
  * Parameters:
  *   - roomID (string): The unique identifier of the room.
  *   - playerID (uint): The unique identifier of the player making the move.
- *   - position (int): The board position (0-8) where the move is made.
+ *   - position (int): The board position where the move is made, as encoded by the game's ruleset.
  *
  * Returns:
  *   - *domain.Game: The updated game instance.
  *   - error: An error if the move is invalid or cannot be applied.
  */
 func (s *GameService) MakeMove(roomID string, playerID uint, position int) (*domain.Game, error) {
-	game, err := s.repo.GetByRoomID(roomID)
+	var game *domain.Game
+	err := s.inRoom(roomID, func(room *roomActor) error {
+		var err error
+		game, err = s.applyMove(room, playerID, position)
+		return err
+	})
+	return game, err
+}
+
+/*
+ * applyMove validates and applies a move on the room's actor, and writes the
+ * result through to the repository.
+ *
+ * Parameters:
+ *   - room (*roomActor): The room's actor.
+ *   - playerID (uint): The unique identifier of the player making the move.
+ *   - position (int): The board position where the move is made, as encoded by the game's ruleset.
+ *
+ * Returns:
+ *   - *domain.Game: The updated game instance.
+ *   - error: An error if the move is invalid or cannot be applied; ErrTimeUp, together
+ *     with the finished game, if the mover's clock had run out.
+ */
+func (s *GameService) applyMove(room *roomActor, playerID uint, position int) (*domain.Game, error) {
+	game, err := room.load(s.repo)
 	if err != nil {
-		return nil, errors.New("game not found")
+		return nil, ErrGameNotFound
 	}
 
 	if game.Status != "in_progress" {
 		return nil, errors.New("game is not currently in progress")
 	}
 
-	if position < 0 || position > 8 {
-		return nil, errors.New("invalid move: position is out of bounds")
-	}
-
-	if game.Board[position] != ' ' {
-		return nil, errors.New("invalid move: position is already taken")
+	rules, err := s.rulesFor(game.Ruleset)
+	if err != nil {
+		return nil, err
 	}
 
-	var expectedPlayerID *uint
-	var expectedSymbol string
-
-	if game.CurrentTurn == "X" {
-		expectedPlayerID = game.PlayerXID
-		expectedSymbol = "X"
-	} else {
+	expectedPlayerID := game.PlayerXID
+	if game.CurrentTurn == "O" {
 		expectedPlayerID = game.PlayerOID
-		expectedSymbol = "O"
 	}
 
 	if expectedPlayerID == nil || playerID != *expectedPlayerID {
 		return nil, errors.New("it is not your turn")
 	}
 
-	boardRunes := []rune(game.Board)
-	boardRunes[position] = rune(expectedSymbol[0])
-	game.Board = string(boardRunes)
+	now := time.Now()
+	if game.HasClock() && clockExpired(game, now) {
+		if err := s.saveFinishedGame(room, game, opponentOf(game.CurrentTurn), domain.EndReasonTimeout, nil); err != nil {
+			return nil, err
+		}
+		room.commit(game)
+		return game, ErrTimeUp
+	}
+
+	if err := rules.ApplyMove(game, position); err != nil {
+		return nil, err
+	}
+
+	move := &domain.GameMove{
+		PlayerID:   playerID,
+		MoveNumber: game.MovesPlayed(),
+		Position:   position,
+		Symbol:     game.Board[position : position+1],
+	}
 
-	if winnerSymbol := checkWinner(game.Board); winnerSymbol != "" {
-		game.Status = "finished"
-		if winnerSymbol == "X" {
-			game.WinnerID = game.PlayerXID
-		} else {
-			game.WinnerID = game.PlayerOID
+	finished, winnerSymbol := rules.Outcome(game)
+	if finished {
+		reason := domain.EndReasonWin
+		if winnerSymbol == "" {
+			reason = domain.EndReasonDraw
 		}
-		if game.WinnerID != nil {
-			winner, err := s.repo.GetPlayerByID(*game.WinnerID)
-			if err == nil && winner != nil {
-				winner.Wins++
-				s.repo.UpdatePlayer(winner)
-			}
+		err = s.saveFinishedGame(room, game, winnerSymbol, reason, move)
+	} else {
+		game.CurrentTurn = opponentOf(game.CurrentTurn)
+		if game.HasClock() {
+			switchClock(game, now)
+		}
+		err = s.repo.RecordMove(game, move)
+	}
+	if err != nil {
+		return nil, err
+	}
+	room.commit(game)
+	return game, nil
+}
 
-			var loserID *uint
-			if winner != nil && game.WinnerID != nil && *game.WinnerID == winner.ID {
-				if game.WinnerID == game.PlayerXID {
-					loserID = game.PlayerOID
-				} else {
-					loserID = game.PlayerXID
-				}
-			}
-			if loserID != nil {
-				loser, err := s.repo.GetPlayerByID(*loserID)
-				if err == nil && loser != nil {
-					loser.Losses++
-					s.repo.UpdatePlayer(loser)
-				}
-			}
+/*
+ * saveFinishedGame finishes a game and persists the result as one unit of work:
+ * both players' stats and ratings, the room's series score (and the series result
+ * if this game decided it), the game and, if given, the move that ended it are
+ * committed together or not at all. The room's series score is taken from, and
+ * written back to, the room's actor; committing the game is left to the caller.
+ *
+ * Parameters:
+ *   - room (*roomActor): The room's actor.
+ *   - game (*domain.Game): The game to finish.
+ *   - winnerSymbol (string): "X", "O", or an empty string for a draw.
+ *   - reason (string): Why the game ended (see the domain.EndReason constants).
+ *   - move (*domain.GameMove): The final move, or nil when the game ended without one.
+ *
+ * Returns:
+ *   - error: An error if any write fails; nothing is persisted in that case.
+ */
+func (s *GameService) saveFinishedGame(room *roomActor, game *domain.Game, winnerSymbol, reason string, move *domain.GameMove) error {
+	roomRec, err := room.loadRoom(s.roomService)
+	if err != nil {
+		return err
+	}
+
+	err = s.uow.Do(func(repo ports.GameRepository, rooms ports.RoomRepository) error {
+		if err := s.finishGame(repo, game, winnerSymbol, reason); err != nil {
+			return err
 		}
-	} else if !strings.Contains(game.Board, " ") {
-		game.Status = "finished"
-		if game.PlayerXID != nil {
-			playerX, err := s.repo.GetPlayerByID(*game.PlayerXID)
-			if err == nil && playerX != nil {
-				playerX.Draws++
-				s.repo.UpdatePlayer(playerX)
-			}
+
+		roomRec.RecordResult(game.WinnerID)
+		if err := rooms.UpdateRoom(roomRec); err != nil {
+			return err
 		}
-		if game.PlayerOID != nil {
-			playerO, err := s.repo.GetPlayerByID(*game.PlayerOID)
-			if err == nil && playerO != nil {
-				playerO.Draws++
-				s.repo.UpdatePlayer(playerO)
+		if roomRec.SeriesOver() {
+			if err := rooms.CreateSeriesResult(seriesResult(roomRec)); err != nil {
+				return err
 			}
 		}
-	} else {
-		game.CurrentTurn = map[string]string{"X": "O", "O": "X"}[game.CurrentTurn]
+
+		if move != nil {
+			return repo.RecordMove(game, move)
+		}
+		return repo.Update(game)
+	})
+	if err != nil {
+		return err
 	}
+	room.commitRoom(roomRec)
+	return nil
+}
 
-	if err := s.repo.Update(game); err != nil {
-		return nil, err
+/*
+ * finishGame marks a game as finished, records the winner and the reason it ended,
+ * and updates both players' stats and ratings through the given repository.
+ *
+ * Parameters:
+ *   - repo (ports.GameRepository): The repository of the unit of work finishing the game.
+ *   - game (*domain.Game): The game to finish; it is not persisted here.
+ *   - winnerSymbol (string): "X", "O", or an empty string for a draw.
+ *   - reason (string): Why the game ended (see the domain.EndReason constants).
+ *
+ * Returns:
+ *   - error: An error if a player's stats or ratings cannot be saved.
+ */
+func (s *GameService) finishGame(repo ports.GameRepository, game *domain.Game, winnerSymbol, reason string) error {
+	game.Status = "finished"
+	game.EndReason = reason
+	game.TurnStartedAt = nil
+
+	var err error
+	if winnerSymbol != "" {
+		winnerID, loserID := game.PlayerXID, game.PlayerOID
+		if winnerSymbol == "O" {
+			winnerID, loserID = loserID, winnerID
+		}
+		game.WinnerID = winnerID
+		err = errors.Join(
+			modifyPlayer(repo, winnerID, func(p *domain.Player) { p.Wins++ }),
+			modifyPlayer(repo, loserID, func(p *domain.Player) { p.Losses++ }),
+		)
+	} else {
+		err = errors.Join(
+			modifyPlayer(repo, game.PlayerXID, func(p *domain.Player) { p.Draws++ }),
+			modifyPlayer(repo, game.PlayerOID, func(p *domain.Player) { p.Draws++ }),
+		)
 	}
-	return game, nil
+	if err != nil {
+		return err
+	}
+
+	return updateRatings(repo, game, winnerSymbol)
 }
 
 /*
- * checkWinner determines the winner symbol ("X" or "O") based on board state.
+ * modifyPlayer applies a change to a player's latest stored state and saves it, reloading
+ * and retrying when another game updated the same player in between, so no increment is lost.
  *
  * Parameters:
- *   - board (string): The current game board.
+ *   - repo (ports.GameRepository): The repository to read and write the player with.
+ *   - id (*uint): The player's ID; nil for an empty seat, which is ignored.
+ *   - change (func(*domain.Player)): The change to apply.
  *
  * Returns:
- *   - string: The winner symbol if there is a winner, otherwise an empty string.
+ *   - error: An error if the player cannot be loaded or saved.
  */
-func checkWinner(board string) string {
-	winConditions := [8][3]int{
-		{0, 1, 2}, {3, 4, 5}, {6, 7, 8},
-		{0, 3, 6}, {1, 4, 7}, {2, 5, 8},
-		{0, 4, 8}, {2, 4, 6},
+func modifyPlayer(repo ports.GameRepository, id *uint, change func(p *domain.Player)) error {
+	if id == nil {
+		return nil
 	}
 
-	for _, c := range winConditions {
-		if board[c[0]] != ' ' && board[c[0]] == board[c[1]] && board[c[1]] == board[c[2]] {
-			return string(board[c[0]])
+	for attempt := 0; ; attempt++ {
+		player, err := repo.GetPlayerByID(*id)
+		if err != nil {
+			return err
+		}
+		if player == nil {
+			return fmt.Errorf("player %d not found", *id)
+		}
+
+		change(player)
+		err = repo.UpdatePlayer(player)
+		if err == nil || !errors.Is(err, ports.ErrConflict) || attempt == maxConflictRetries {
+			return err
 		}
 	}
-	return ""
 }
@@ -0,0 +1,220 @@
+/*
+ * file: game_services_test.go
+ * package: services
+ * description:
+ *     Test harness shared by the service tests: a GameService over an in-memory store
+ *     with two human players, and helpers to open a room and play moves in it. Also
+ *     tests that a finished game is saved as one unit of work.
+ */
+
+package services
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/juan10024/tictactoe-test/internal/core/domain"
+	"github.com/juan10024/tictactoe-test/internal/core/ports"
+	"github.com/juan10024/tictactoe-test/internal/core/ports/portstest"
+	"github.com/juan10024/tictactoe-test/internal/core/rules"
+	"github.com/juan10024/tictactoe-test/internal/infra/auth"
+)
+
+// testEnv is a GameService over an in-memory store, with alice and bob registered.
+type testEnv struct {
+	store *portstest.MemoryStore
+	rooms *RoomService
+	gs    *GameService
+	alice *domain.Player
+	bob   *domain.Player
+}
+
+// newTestEnv builds the services the way main.go wires them, over an empty store.
+func newTestEnv(t *testing.T) *testEnv {
+	store := portstest.NewMemoryStore()
+	return newTestEnvOn(t, store, store, store)
+}
+
+// newTestEnvOn builds the services over the given store, reaching its games and transactions through repo and uow.
+func newTestEnvOn(t *testing.T, store *portstest.MemoryStore, repo ports.GameRepository, uow ports.UnitOfWork) *testEnv {
+	t.Helper()
+	rooms := NewRoomService(store, auth.NewBcryptHasher(), auth.NewHMACInviteIssuer([]byte("services-test-secret")))
+	env := &testEnv{
+		store: store,
+		rooms: rooms,
+		gs:    NewGameService(repo, uow, rooms, rules.NewClassic(), rules.NewGomoku(), rules.NewUltimate()),
+	}
+
+	var err error
+	if env.alice, err = store.GetOrCreatePlayerByName("alice"); err != nil {
+		t.Fatal(err)
+	}
+	if env.bob, err = store.GetOrCreatePlayerByName("bob"); err != nil {
+		t.Fatal(err)
+	}
+	return env
+}
+
+// otherInstance returns a second GameService over the same store, as another backend instance would run.
+func (e *testEnv) otherInstance() *GameService {
+	return NewGameService(e.store, e.store, e.rooms, rules.NewClassic(), rules.NewGomoku(), rules.NewUltimate())
+}
+
+// startRoom opens a room with the given options, seats alice (X) and bob (O) and starts the game.
+func (e *testEnv) startRoom(t *testing.T, roomID string, config domain.GameConfig) *domain.Game {
+	t.Helper()
+	return e.startRoomWith(t, roomID, config, domain.RoomAccess{})
+}
+
+// startRoomWith is startRoom for a private room: both players present access, and alice creates the room with it.
+func (e *testEnv) startRoomWith(t *testing.T, roomID string, config domain.GameConfig, access domain.RoomAccess) *domain.Game {
+	t.Helper()
+	for _, player := range []*domain.Player{e.alice, e.bob} {
+		if _, _, err := e.gs.HandleJoinRoom(roomID, player, config, access); err != nil {
+			t.Fatalf("%s joining %s: %v", player.Name, roomID, err)
+		}
+	}
+	game, err := e.gs.StartGame(roomID)
+	if err != nil || game == nil {
+		t.Fatalf("StartGame(%s) = %v, %v", roomID, game, err)
+	}
+	return game
+}
+
+// play plays the given positions in turn, each by the player to move, and returns the last game.
+func (e *testEnv) play(t *testing.T, roomID string, positions ...int) *domain.Game {
+	t.Helper()
+	var game *domain.Game
+	for _, position := range positions {
+		current, err := e.gs.currentGame(roomID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		mover := *current.PlayerXID
+		if current.CurrentTurn == "O" {
+			mover = *current.PlayerOID
+		}
+		if game, err = e.gs.MakeMove(roomID, mover, position); err != nil {
+			t.Fatalf("move at %d: %v", position, err)
+		}
+	}
+	return game
+}
+
+// errInjected is the failure a faultyStore injects.
+var errInjected = errors.New("injected write failure")
+
+/*
+ * faultyStore is an in-memory store where one chosen write is applied and then reported
+ * as failed, so only a rollback of the whole transaction leaves the store unchanged.
+ *
+ * Fields:
+ *   - failOn (string): The write that fails: "UpdatePlayer", "UpdateRatings", "UpdateRoom",
+ *     "CreateSeriesResult" or "RecordMove"; "" for none.
+ */
+type faultyStore struct {
+	*portstest.MemoryStore
+	failOn string
+}
+
+func (s *faultyStore) fail(write string) error {
+	if s.failOn == write {
+		return errInjected
+	}
+	return nil
+}
+
+func (s *faultyStore) Do(fn func(games ports.GameRepository, rooms ports.RoomRepository) error) error {
+	return s.MemoryStore.Do(func(ports.GameRepository, ports.RoomRepository) error { return fn(s, s) })
+}
+
+func (s *faultyStore) UpdatePlayer(player *domain.Player) error {
+	return errors.Join(s.fail("UpdatePlayer"), s.MemoryStore.UpdatePlayer(player))
+}
+
+func (s *faultyStore) UpdateRatings(players []*domain.Player, history []domain.RatingHistory) error {
+	return errors.Join(s.fail("UpdateRatings"), s.MemoryStore.UpdateRatings(players, history))
+}
+
+func (s *faultyStore) UpdateRoom(room *domain.Room) error {
+	return errors.Join(s.fail("UpdateRoom"), s.MemoryStore.UpdateRoom(room))
+}
+
+func (s *faultyStore) CreateSeriesResult(result *domain.SeriesResult) error {
+	return errors.Join(s.fail("CreateSeriesResult"), s.MemoryStore.CreateSeriesResult(result))
+}
+
+func (s *faultyStore) RecordMove(game *domain.Game, move *domain.GameMove) error {
+	return errors.Join(s.fail("RecordMove"), s.MemoryStore.RecordMove(game, move))
+}
+
+func TestFinishedGameIsSavedAtomically(t *testing.T) {
+	for _, failOn := range []string{"UpdatePlayer", "UpdateRatings", "UpdateRoom", "CreateSeriesResult", "RecordMove"} {
+		t.Run(failOn, func(t *testing.T) {
+			store := &faultyStore{MemoryStore: portstest.NewMemoryStore()}
+			env := newTestEnvOn(t, store.MemoryStore, store, store)
+			game := env.startRoom(t, "room-1", domain.GameConfig{BestOf: 3})
+			env.play(t, "room-1", 0, 3, 1, 4)
+
+			// Alice already won the first game of the series, so her next win decides it.
+			room, err := store.GetRoom("room-1")
+			if err != nil {
+				t.Fatal(err)
+			}
+			room.PlayerOneWins = 1
+			if err := store.MemoryStore.UpdateRoom(room); err != nil {
+				t.Fatal(err)
+			}
+			env.gs.inRoom("room-1", func(actor *roomActor) error {
+				actor.record = nil
+				return nil
+			})
+			aliceBefore, _ := store.GetPlayerByID(env.alice.ID)
+
+			store.failOn = failOn
+			if _, err := env.gs.MakeMove("room-1", env.alice.ID, 2); !errors.Is(err, errInjected) {
+				t.Fatalf("winning move error = %v, want the injected failure", err)
+			}
+
+			// Nothing of the finished game reached the store...
+			stored, _ := store.GetByID(game.ID)
+			moves, _ := store.GetMovesByGameID(game.ID)
+			alice, _ := store.GetPlayerByID(env.alice.ID)
+			bob, _ := store.GetPlayerByID(env.bob.ID)
+			room, _ = store.GetRoom("room-1")
+			series, _ := store.GetSeriesByRoomID("room-1")
+			if stored.Status != "in_progress" || len(moves) != 4 {
+				t.Errorf("stored game = %s with %d moves, want in_progress with 4", stored.Status, len(moves))
+			}
+			if alice.Wins != 0 || bob.Losses != 0 || alice.Rating != aliceBefore.Rating {
+				t.Errorf("stats saved: alice %d wins rated %.0f, bob %d losses", alice.Wins, alice.Rating, bob.Losses)
+			}
+			if room.PlayerOneWins != 1 || len(series) != 0 {
+				t.Errorf("series saved: score %d, %d results", room.PlayerOneWins, len(series))
+			}
+
+			// ...nor became the room's state.
+			var current *domain.Game
+			var record *domain.Room
+			env.gs.inRoom("room-1", func(actor *roomActor) error {
+				current, _ = actor.current(env.gs.repo)
+				record, err = actor.currentRoom(env.rooms)
+				return err
+			})
+			if current.Status != "in_progress" || record.PlayerOneWins != 1 {
+				t.Errorf("room state = game %s, score %d; want in_progress and 1", current.Status, record.PlayerOneWins)
+			}
+
+			// The same move goes through once the store recovers, counted once.
+			store.failOn = ""
+			if _, err := env.gs.MakeMove("room-1", env.alice.ID, 2); err != nil {
+				t.Fatalf("winning move after recovery error = %v", err)
+			}
+			alice, _ = store.GetPlayerByID(env.alice.ID)
+			series, _ = store.GetSeriesByRoomID("room-1")
+			if alice.Wins != 1 || len(series) != 1 {
+				t.Errorf("after recovery alice has %d wins and the room %d series results, want 1 and 1", alice.Wins, len(series))
+			}
+		})
+	}
+}
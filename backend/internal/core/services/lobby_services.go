@@ -0,0 +1,303 @@
+/*
+ * file: lobby_services.go
+ * package: services
+ * description:
+ *     Implements the public lobby: the list of rooms waiting for an opponent and
+ *     games being played, and the room created/started/finished events streamed to
+ *     lobby subscribers. Events are published on the room broadcaster, so a lobby
+ *     sees the rooms of every backend instance.
+ */
+
+package services
+
+import (
+	"encoding/json"
+	"log"
+	"sync"
+
+	"github.com/gorilla/websocket"
+
+	"github.com/juan10024/tictactoe-test/internal/core/domain"
+	"github.com/juan10024/tictactoe-test/internal/core/ports"
+	"github.com/juan10024/tictactoe-test/internal/core/protocol"
+)
+
+// lobbyChannel is the broadcaster room that carries lobby events. Room IDs are never empty,
+// so it cannot collide with a real room.
+const lobbyChannel = ""
+
+// lobbyQueueSize bounds the room changes waiting to be published as lobby events.
+const lobbyQueueSize = 256
+
+/*
+ * LobbyRoom is an open room as listed in the lobby. Each API maps it to its own view:
+ * protocol.NewLobbyRoom for the lobby socket, dto.NewLobbyRooms for the REST listing.
+ *
+ * Fields:
+ *   - Game (*domain.Game): The room's current game, with its players preloaded.
+ *   - Privacy (string): The room's privacy setting; never invite-only.
+ *   - Spectators (int): Observers connected to any instance.
+ */
+type LobbyRoom struct {
+	Game       *domain.Game
+	Privacy    string
+	Spectators int
+}
+
+// lobbyChange is a room change waiting to be published as a lobby event.
+type lobbyChange struct {
+	eventType string
+	game      *domain.Game // The committed game; never modified.
+	record    *domain.Room // The room's record when the game was committed, nil if the actor had not loaded it.
+}
+
+/*
+ * Lobby lists open rooms and fans lobby events out to its subscribers.
+ *
+ * Fields:
+ *   - hub (*Hub): Reference to the Hub, used to count spectators.
+ *   - gameService (*GameService): Service whose rooms are listed and watched.
+ *   - broadcaster (ports.RoomBroadcaster): Carries lobby events between instances.
+ *   - changes (chan lobbyChange): Room changes queued by the room actors, published in order by publishChanges.
+ *   - mu (sync.RWMutex): Protects subscribers.
+ *   - subscribers (map[chan []byte]*websocket.Conn): The outgoing queue of each lobby connection.
+ */
+type Lobby struct {
+	hub         *Hub
+	gameService *GameService
+	broadcaster ports.RoomBroadcaster
+	changes     chan lobbyChange
+	mu          sync.RWMutex
+	subscribers map[chan []byte]*websocket.Conn
+}
+
+/*
+ * NewLobby creates a lobby and starts watching the game service's rooms.
+ * It must be created before rooms are served, since it hooks into their actors.
+ *
+ * Parameters:
+ *   - hub (*Hub): Reference to the Hub, used to count spectators.
+ *   - gs (*GameService): Service whose rooms are listed and watched.
+ *   - broadcaster (ports.RoomBroadcaster): The pub/sub channel shared by all backend instances.
+ *
+ * Returns:
+ *   - *Lobby: A lobby with no subscribers.
+ */
+func NewLobby(hub *Hub, gs *GameService, broadcaster ports.RoomBroadcaster) *Lobby {
+	l := &Lobby{
+		hub:         hub,
+		gameService: gs,
+		broadcaster: broadcaster,
+		changes:     make(chan lobbyChange, lobbyQueueSize),
+		subscribers: make(map[chan []byte]*websocket.Conn),
+	}
+	gs.rooms.onCommit = l.roomChanged
+	broadcaster.Subscribe(l.deliver)
+	go l.publishChanges()
+	return l
+}
+
+/*
+ * Rooms lists the rooms waiting for an opponent and the games in progress, newest first.
+ * Invite-only rooms are left out.
+ *
+ * Parameters:
+ *   - None.
+ *
+ * Returns:
+ *   - []LobbyRoom: The open rooms.
+ *   - error: An error if the games cannot be loaded.
+ */
+func (l *Lobby) Rooms() ([]LobbyRoom, error) {
+	games, err := l.gameService.repo.GetOpenGames()
+	if err != nil {
+		return nil, err
+	}
+
+	roomIDs := make([]string, 0, len(games))
+	for _, game := range games {
+		roomIDs = append(roomIDs, game.RoomID)
+	}
+	privacy, err := l.gameService.roomService.privacyOf(roomIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	rooms := make([]LobbyRoom, 0, len(games))
+	for i := range games {
+		if privacy[games[i].RoomID] == domain.RoomInviteOnly {
+			continue
+		}
+		rooms = append(rooms, l.describe(&games[i], privacy[games[i].RoomID]))
+	}
+	return rooms, nil
+}
+
+/*
+ * describe lists a room in the lobby.
+ *
+ * Parameters:
+ *   - game (*domain.Game): The room's current game.
+ *   - privacy (string): The room's privacy setting.
+ *
+ * Returns:
+ *   - LobbyRoom: The room with its current audience.
+ */
+func (l *Lobby) describe(game *domain.Game, privacy string) LobbyRoom {
+	return LobbyRoom{Game: game, Privacy: privacy, Spectators: l.hub.spectators(game.RoomID)}
+}
+
+/*
+ * roomChanged is called by a room's actor after each commit and queues the lobby event
+ * the change amounts to, if any. It never blocks the actor: the event is published by
+ * publishChanges, and dropped with a warning if the queue is full. Invite-only rooms
+ * stay out of the lobby.
+ *
+ * Parameters:
+ *   - prev (*domain.Game): The room's previous game, nil if the room had none.
+ *   - next (*domain.Game): The room's new game.
+ *   - record (*domain.Room): The room's record, nil if the actor has not loaded it.
+ *
+ * Returns:
+ *   - None.
+ */
+func (l *Lobby) roomChanged(prev, next *domain.Game, record *domain.Room) {
+	eventType := lobbyEventType(prev, next)
+	if eventType == "" || (record != nil && record.Privacy == domain.RoomInviteOnly) {
+		return
+	}
+
+	select {
+	case l.changes <- lobbyChange{eventType: eventType, game: next, record: record}:
+	default:
+		log.Printf("WARN: Lobby queue full, dropping %s event of room %s", eventType, next.RoomID)
+	}
+}
+
+/*
+ * publishChanges publishes the queued room changes as lobby events, in the order the
+ * actors committed them. The room's privacy is read from the database only when the
+ * actor did not have the record. It blocks forever and is started by NewLobby.
+ *
+ * Parameters:
+ *   - None.
+ *
+ * Returns:
+ *   - None. Failures are logged.
+ */
+func (l *Lobby) publishChanges() {
+	for change := range l.changes {
+		roomID := change.game.RoomID
+		var privacy string
+		if change.record != nil {
+			privacy = change.record.Privacy
+		} else {
+			settings, err := l.gameService.roomService.privacyOf([]string{roomID})
+			if err != nil {
+				log.Printf("ERROR: Could not load settings of room %s for the lobby: %v", roomID, err)
+				continue
+			}
+			privacy = settings[roomID]
+		}
+		if privacy == domain.RoomInviteOnly {
+			continue
+		}
+
+		room := l.describe(change.game, privacy)
+		data, err := json.Marshal(protocol.LobbyEvent{Type: change.eventType, Room: protocol.NewLobbyRoom(room.Game, room.Privacy, room.Spectators)})
+		if err != nil {
+			log.Printf("ERROR: Could not marshal lobby event for room %s: %v", roomID, err)
+			continue
+		}
+		if err := l.broadcaster.Publish(lobbyChannel, data); err != nil {
+			log.Printf("ERROR: Could not publish lobby event for room %s: %v", roomID, err)
+		}
+	}
+}
+
+/*
+ * lobbyEventType classifies a room change. A new game counts as a created room when it
+ * waits for an opponent and as a started one when it begins right away (a rematch).
+ *
+ * Parameters:
+ *   - prev (*domain.Game): The room's previous game, nil if the room had none.
+ *   - next (*domain.Game): The room's new game.
+ *
+ * Returns:
+ *   - string: The lobby event type, or "" if the change is not shown in the lobby.
+ */
+func lobbyEventType(prev, next *domain.Game) string {
+	if prev != nil && prev.ID == next.ID && prev.Status == next.Status {
+		return ""
+	}
+
+	switch next.Status {
+	case "waiting":
+		return protocol.TypeRoomCreated
+	case "in_progress":
+		return protocol.TypeRoomStarted
+	case "finished", "expired":
+		return protocol.TypeRoomFinished
+	}
+	return ""
+}
+
+/*
+ * deliver is the broadcaster subscription: it queues lobby events for this instance's
+ * subscribers. A subscriber whose queue is full is disconnected, so it reconnects
+ * and starts over from a fresh snapshot instead of silently missing events.
+ *
+ * Parameters:
+ *   - roomID (string): The broadcaster room; anything but lobbyChannel is ignored.
+ *   - data ([]byte): The published LobbyEvent.
+ *
+ * Returns:
+ *   - None.
+ */
+func (l *Lobby) deliver(roomID string, data []byte) {
+	if roomID != lobbyChannel {
+		return
+	}
+
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	for send, conn := range l.subscribers {
+		select {
+		case send <- data:
+		default:
+			log.Printf("WARN: Lobby subscriber send buffer full. Closing connection.")
+			conn.Close()
+		}
+	}
+}
+
+/*
+ * subscribe registers a lobby connection's outgoing queue.
+ *
+ * Parameters:
+ *   - send (chan []byte): The queue the connection writes from.
+ *   - conn (*websocket.Conn): The connection, closed if it falls behind.
+ *
+ * Returns:
+ *   - None.
+ */
+func (l *Lobby) subscribe(send chan []byte, conn *websocket.Conn) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.subscribers[send] = conn
+}
+
+/*
+ * unsubscribe removes a lobby connection's outgoing queue.
+ *
+ * Parameters:
+ *   - send (chan []byte): The queue registered with subscribe.
+ *
+ * Returns:
+ *   - None.
+ */
+func (l *Lobby) unsubscribe(send chan []byte) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	delete(l.subscribers, send)
+}
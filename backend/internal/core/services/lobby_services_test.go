@@ -0,0 +1,106 @@
+/*
+ * file: lobby_services_test.go
+ * package: services
+ * description:
+ *     Tests for the lobby: room changes committed by the actors are published as
+ *     lobby events, in order, and invite-only rooms never show up.
+ */
+
+package services
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/juan10024/tictactoe-test/internal/core/domain"
+	"github.com/juan10024/tictactoe-test/internal/core/protocol"
+	"github.com/juan10024/tictactoe-test/internal/infra/broadcast"
+)
+
+// lobbyEvents starts a lobby over env and returns the events it publishes.
+func lobbyEvents(t *testing.T, env *testEnv) <-chan protocol.LobbyEvent {
+	shared := broadcast.NewMemoryBroadcaster()
+	NewLobby(newTestHub(shared), env.gs, shared)
+
+	events := make(chan protocol.LobbyEvent, 16)
+	shared.Subscribe(func(roomID string, message []byte) {
+		if roomID != lobbyChannel {
+			return
+		}
+		var event protocol.LobbyEvent
+		if err := json.Unmarshal(message, &event); err != nil {
+			t.Errorf("lobby event %s: %v", message, err)
+		}
+		events <- event
+	})
+	return events
+}
+
+// nextEvent returns the next lobby event, or fails the test if none comes.
+func nextEvent(t *testing.T, events <-chan protocol.LobbyEvent) protocol.LobbyEvent {
+	t.Helper()
+	select {
+	case event := <-events:
+		return event
+	case <-time.After(time.Second):
+		t.Fatal("no lobby event published")
+		return protocol.LobbyEvent{}
+	}
+}
+
+func TestLobbyEventsFollowTheRoom(t *testing.T) {
+	env := newTestEnv(t)
+	events := lobbyEvents(t, env)
+
+	env.startRoomWith(t, "room-1", domain.GameConfig{Privacy: domain.RoomPassword}, domain.RoomAccess{Password: "open sesame"})
+	env.play(t, "room-1", 0, 3, 1, 4, 2)
+
+	for _, want := range []string{protocol.TypeRoomCreated, protocol.TypeRoomStarted, protocol.TypeRoomFinished} {
+		event := nextEvent(t, events)
+		if event.Type != want || event.Room.RoomID != "room-1" || event.Room.Privacy != domain.RoomPassword {
+			t.Errorf("lobby event = %s of %s (%s), want %s of room-1 (password)", event.Type, event.Room.RoomID, event.Room.Privacy, want)
+		}
+	}
+}
+
+func TestInviteOnlyRoomsStayOutOfTheLobby(t *testing.T) {
+	env := newTestEnv(t)
+	events := lobbyEvents(t, env)
+
+	for _, room := range []struct {
+		id      string
+		privacy string
+	}{{"secret", domain.RoomInviteOnly}, {"open", domain.RoomPublic}} {
+		if _, _, err := env.gs.HandleJoinRoom(room.id, env.alice, domain.GameConfig{Privacy: room.privacy}, domain.RoomAccess{}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// Events are published in order, so the open room's comes after any of the secret one's.
+	if event := nextEvent(t, events); event.Room.RoomID != "open" {
+		t.Errorf("lobby event = %s of %s, want only the open room", event.Type, event.Room.RoomID)
+	}
+}
+
+func TestLobbyReadsPrivacyOfRoomsNotInMemory(t *testing.T) {
+	env := newTestEnv(t)
+	events := lobbyEvents(t, env)
+
+	// The lobby is told about a game whose room record the actor has not loaded.
+	env.startRoomWith(t, "room-1", domain.GameConfig{Privacy: domain.RoomPassword}, domain.RoomAccess{Password: "open sesame"})
+	for range []string{protocol.TypeRoomCreated, protocol.TypeRoomStarted} {
+		nextEvent(t, events)
+	}
+	game, err := env.gs.currentGame("room-1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	finished := *game
+	finished.Status = "finished"
+	env.gs.rooms.onCommit(game, &finished, nil)
+
+	if event := nextEvent(t, events); event.Type != protocol.TypeRoomFinished || event.Room.Privacy != domain.RoomPassword {
+		t.Errorf("lobby event = %s (%s), want roomFinished with the stored privacy", event.Type, event.Room.Privacy)
+	}
+}
@@ -0,0 +1,346 @@
+/*
+ * file: matchmaking_services.go
+ * package: services
+ * description:
+ *     Implements the quick play queue: players wait in it with their preferences,
+ *     the matchmaker pairs players of similar rating, creates a room for them and
+ *     tells each one the room and the symbol they play. The accepted rating gap
+ *     widens the longer a player waits.
+ */
+
+package services
+
+import (
+	crand "crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log"
+	"math"
+	"math/rand"
+	"sync"
+	"time"
+
+	"github.com/juan10024/tictactoe-test/internal/core/domain"
+	"github.com/juan10024/tictactoe-test/internal/core/protocol"
+)
+
+const (
+	// matchBaseTolerance is the rating gap accepted as soon as a player enters the queue.
+	matchBaseTolerance = 100.0
+	// matchToleranceStep is added to the accepted gap every matchWidenInterval of waiting.
+	matchToleranceStep = 50.0
+	// matchWidenInterval is how often the accepted gap widens.
+	matchWidenInterval = 5 * time.Second
+	// matchMaxTolerance caps the accepted gap.
+	matchMaxTolerance = 800.0
+)
+
+var (
+	ErrAlreadyQueued = errors.New("player is already in the matchmaking queue")
+	ErrInvalidRange  = errors.New("minRating must not be greater than maxRating")
+)
+
+// MatchPreferences are the options a player enters the queue with; see protocol.MatchPreferences.
+type MatchPreferences = protocol.MatchPreferences
+
+/*
+ * MatchTicket is a player's place in the queue.
+ *
+ * Fields:
+ *   - player (*domain.Player): The queued player.
+ *   - prefs (MatchPreferences): The player's preferences.
+ *   - enqueuedAt (time.Time): When the player entered the queue.
+ *   - result (chan MatchResult): Receives the outcome once the player is paired.
+ */
+type MatchTicket struct {
+	player     *domain.Player
+	prefs      MatchPreferences
+	enqueuedAt time.Time
+	result     chan MatchResult
+}
+
+// MatchResult is the outcome of a pairing: the match, or the error that prevented creating its room.
+type MatchResult struct {
+	Match *protocol.MatchFound
+	Err   error
+}
+
+/*
+ * Result returns the channel that receives the ticket's match. It receives exactly
+ * one value, unless the ticket is cancelled first.
+ *
+ * Returns:
+ *   - <-chan MatchResult: The outcome channel.
+ */
+func (t *MatchTicket) Result() <-chan MatchResult {
+	return t.result
+}
+
+/*
+ * tolerance returns the rating gap the ticket accepts after waiting until now.
+ *
+ * Parameters:
+ *   - now (time.Time): The current time.
+ *
+ * Returns:
+ *   - float64: The accepted rating gap.
+ */
+func (t *MatchTicket) tolerance(now time.Time) float64 {
+	steps := math.Floor(float64(now.Sub(t.enqueuedAt)) / float64(matchWidenInterval))
+	return math.Min(matchBaseTolerance+steps*matchToleranceStep, matchMaxTolerance)
+}
+
+/*
+ * accepts reports whether the ticket's player is willing to play an opponent of the given rating.
+ *
+ * Parameters:
+ *   - rating (float64): The opponent's rating.
+ *   - now (time.Time): The current time.
+ *
+ * Returns:
+ *   - bool: True if the rating is within the explicit range and the current tolerance.
+ */
+func (t *MatchTicket) accepts(rating float64, now time.Time) bool {
+	if t.prefs.MinRating > 0 && rating < t.prefs.MinRating {
+		return false
+	}
+	if t.prefs.MaxRating > 0 && rating > t.prefs.MaxRating {
+		return false
+	}
+	return math.Abs(rating-t.player.Rating) <= t.tolerance(now)
+}
+
+/*
+ * Matchmaker pairs queued players and creates their rooms.
+ *
+ * Fields:
+ *   - gameService (*GameService): Service used to create the rooms.
+ *   - mu (sync.Mutex): Protects queue.
+ *   - queue ([]*MatchTicket): Waiting tickets, oldest first.
+ */
+type Matchmaker struct {
+	gameService *GameService
+	mu          sync.Mutex
+	queue       []*MatchTicket
+}
+
+/*
+ * NewMatchmaker constructs a new Matchmaker with an empty queue.
+ *
+ * Parameters:
+ *   - gs (*GameService): Service used to create the rooms.
+ *
+ * Returns:
+ *   - *Matchmaker: A new matchmaker. Run must be started for the queue to widen over time.
+ */
+func NewMatchmaker(gs *GameService) *Matchmaker {
+	return &Matchmaker{gameService: gs}
+}
+
+/*
+ * Enqueue puts a player in the queue and tries to pair them right away.
+ *
+ * Parameters:
+ *   - player (*domain.Player): The authenticated player.
+ *   - prefs (MatchPreferences): The player's preferences.
+ *
+ * Returns:
+ *   - *MatchTicket: The player's ticket; its Result channel receives the match.
+ *   - error: An error if the preferences are invalid or the player is already queued.
+ */
+func (m *Matchmaker) Enqueue(player *domain.Player, prefs MatchPreferences) (*MatchTicket, error) {
+	if player == nil || player.IsBot {
+		return nil, errors.New("a human player is required to play")
+	}
+	if prefs.MinRating < 0 || prefs.MaxRating < 0 {
+		return nil, errors.New("rating bounds must not be negative")
+	}
+	if prefs.MaxRating > 0 && prefs.MinRating > prefs.MaxRating {
+		return nil, ErrInvalidRange
+	}
+	if prefs.Ruleset != "" {
+		if _, err := m.gameService.rulesFor(prefs.Ruleset); err != nil {
+			return nil, err
+		}
+	}
+
+	ticket := &MatchTicket{
+		player:     player,
+		prefs:      prefs,
+		enqueuedAt: time.Now(),
+		result:     make(chan MatchResult, 1),
+	}
+
+	m.mu.Lock()
+	for _, queued := range m.queue {
+		if queued.player.ID == player.ID {
+			m.mu.Unlock()
+			return nil, ErrAlreadyQueued
+		}
+	}
+	m.queue = append(m.queue, ticket)
+	m.mu.Unlock()
+
+	m.matchQueued(time.Now())
+	return ticket, nil
+}
+
+/*
+ * Cancel removes a ticket from the queue, when a player gives up or disconnects.
+ *
+ * Parameters:
+ *   - ticket (*MatchTicket): The ticket to remove.
+ *
+ * Returns:
+ *   - bool: True if the ticket was still waiting, false if it had already been paired.
+ */
+func (m *Matchmaker) Cancel(ticket *MatchTicket) bool {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for i, queued := range m.queue {
+		if queued == ticket {
+			m.queue = append(m.queue[:i], m.queue[i+1:]...)
+			return true
+		}
+	}
+	return false
+}
+
+/*
+ * Run retries the pairing periodically, so tickets whose tolerance widened find
+ * opponents. It blocks forever and is meant to be started on its own goroutine.
+ *
+ * Parameters:
+ *   - interval (time.Duration): How often to retry.
+ *
+ * Returns:
+ *   - None.
+ */
+func (m *Matchmaker) Run(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for now := range ticker.C {
+		m.matchQueued(now)
+	}
+}
+
+/*
+ * matchQueued pairs every compatible couple of queued tickets, oldest first, and
+ * creates their rooms.
+ *
+ * Parameters:
+ *   - now (time.Time): The time the tolerances are computed at.
+ *
+ * Returns:
+ *   - None.
+ */
+func (m *Matchmaker) matchQueued(now time.Time) {
+	m.mu.Lock()
+	var pairs [][2]*MatchTicket
+	for i := 0; i < len(m.queue); i++ {
+		for j := i + 1; j < len(m.queue); j++ {
+			a, b := m.queue[i], m.queue[j]
+			if !compatible(a, b, now) {
+				continue
+			}
+			pairs = append(pairs, [2]*MatchTicket{a, b})
+			m.queue = append(m.queue[:j], m.queue[j+1:]...)
+			m.queue = append(m.queue[:i], m.queue[i+1:]...)
+			i--
+			break
+		}
+	}
+	m.mu.Unlock()
+
+	for _, pair := range pairs {
+		m.startMatch(pair[0], pair[1])
+	}
+}
+
+/*
+ * compatible reports whether two tickets can be paired.
+ *
+ * Parameters:
+ *   - a, b (*MatchTicket): The tickets.
+ *   - now (time.Time): The time the tolerances are computed at.
+ *
+ * Returns:
+ *   - bool: True if the players differ, agree on the ruleset and accept each other's rating.
+ */
+func compatible(a, b *MatchTicket, now time.Time) bool {
+	if a.player.ID == b.player.ID {
+		return false
+	}
+	if a.prefs.Ruleset != "" && b.prefs.Ruleset != "" && a.prefs.Ruleset != b.prefs.Ruleset {
+		return false
+	}
+	return a.accepts(b.player.Rating, now) && b.accepts(a.player.Rating, now)
+}
+
+/*
+ * startMatch creates a room for two paired players, seats them in random order and
+ * notifies both tickets.
+ *
+ * Parameters:
+ *   - a, b (*MatchTicket): The paired tickets, already removed from the queue.
+ *
+ * Returns:
+ *   - None. If the room cannot be created, both tickets receive the error.
+ */
+func (m *Matchmaker) startMatch(a, b *MatchTicket) {
+	if rand.Intn(2) == 0 {
+		a, b = b, a
+	}
+
+	ruleset := a.prefs.Ruleset
+	if ruleset == "" {
+		ruleset = b.prefs.Ruleset
+	}
+	roomID, err := newMatchRoomID()
+	if err != nil {
+		log.Printf("ERROR: Could not create match room for %s and %s: %v", a.player.Name, b.player.Name, err)
+		a.result <- MatchResult{Err: err}
+		b.result <- MatchResult{Err: err}
+		return
+	}
+	config := domain.GameConfig{Ruleset: ruleset}
+
+	var game *domain.Game
+	for _, ticket := range []*MatchTicket{a, b} {
+		game, _, err = m.gameService.HandleJoinRoom(roomID, ticket.player, config, domain.RoomAccess{})
+		if err != nil {
+			break
+		}
+	}
+	if err == nil && (game.SeatOf(a.player.ID) == "" || game.SeatOf(b.player.ID) == "") {
+		err = fmt.Errorf("room %s was not created for both players", roomID)
+	}
+	if err != nil {
+		log.Printf("ERROR: Could not create match room for %s and %s: %v", a.player.Name, b.player.Name, err)
+		a.result <- MatchResult{Err: err}
+		b.result <- MatchResult{Err: err}
+		return
+	}
+
+	log.Printf("INFO: Matched %s and %s in room %s", a.player.Name, b.player.Name, roomID)
+	a.result <- MatchResult{Match: protocol.NewMatchFound(game, a.player, b.player)}
+	b.result <- MatchResult{Match: protocol.NewMatchFound(game, b.player, a.player)}
+}
+
+/*
+ * newMatchRoomID generates a random identifier for a matchmade room.
+ *
+ * Returns:
+ *   - string: The room ID, such as "match-3f9a0c1b2d4e".
+ *   - error: An error if no randomness is available.
+ */
+func newMatchRoomID() (string, error) {
+	buf := make([]byte, 6)
+	if _, err := crand.Read(buf); err != nil {
+		return "", err
+	}
+	return "match-" + hex.EncodeToString(buf), nil
+}
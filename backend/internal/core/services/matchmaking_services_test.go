@@ -0,0 +1,185 @@
+/*
+ * file: matchmaking_services_test.go
+ * package: services
+ * description:
+ *     Tests for the quick play queue: the accepted rating gap widens with the wait,
+ *     explicit rating bounds and rulesets are honoured, and cancelled tickets are
+ *     never paired.
+ */
+
+package services
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/juan10024/tictactoe-test/internal/core/domain"
+	"github.com/juan10024/tictactoe-test/internal/core/rules"
+)
+
+var queueNow = time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+
+// queuedTicket returns a ticket of a player with the given rating, queued for the given time before queueNow.
+func queuedTicket(id uint, rating float64, prefs MatchPreferences, waited time.Duration) *MatchTicket {
+	player := &domain.Player{Name: "player", Rating: rating}
+	player.ID = id
+	return &MatchTicket{player: player, prefs: prefs, enqueuedAt: queueNow.Add(-waited), result: make(chan MatchResult, 1)}
+}
+
+func TestToleranceWidensWithTheWait(t *testing.T) {
+	tests := []struct {
+		waited time.Duration
+		want   float64
+	}{
+		{0, matchBaseTolerance},
+		{matchWidenInterval - time.Millisecond, matchBaseTolerance},
+		{matchWidenInterval, matchBaseTolerance + matchToleranceStep},
+		{3 * matchWidenInterval, matchBaseTolerance + 3*matchToleranceStep},
+		{time.Hour, matchMaxTolerance},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.waited.String(), func(t *testing.T) {
+			if got := queuedTicket(1, 1500, MatchPreferences{}, tt.waited).tolerance(queueNow); got != tt.want {
+				t.Errorf("tolerance after %s = %.0f, want %.0f", tt.waited, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCompatibleTickets(t *testing.T) {
+	tests := []struct {
+		name   string
+		a, b   *MatchTicket
+		wanted bool
+	}{
+		{"close ratings", queuedTicket(1, 1500, MatchPreferences{}, 0), queuedTicket(2, 1580, MatchPreferences{}, 0), true},
+		{"gap too wide for now", queuedTicket(1, 1500, MatchPreferences{}, 0), queuedTicket(2, 1700, MatchPreferences{}, 0), false},
+		{"gap accepted after waiting", queuedTicket(1, 1500, MatchPreferences{}, 10*time.Second), queuedTicket(2, 1700, MatchPreferences{}, 10*time.Second), true},
+		{"only one side waited long enough", queuedTicket(1, 1500, MatchPreferences{}, 10*time.Second), queuedTicket(2, 1700, MatchPreferences{}, 0), false},
+		{"below the minimum rating", queuedTicket(1, 1500, MatchPreferences{MinRating: 1550}, 0), queuedTicket(2, 1520, MatchPreferences{}, 0), false},
+		{"above the maximum rating", queuedTicket(1, 1500, MatchPreferences{MaxRating: 1510}, 0), queuedTicket(2, 1520, MatchPreferences{}, 0), false},
+		{"bounds do not override the tolerance", queuedTicket(1, 1500, MatchPreferences{MaxRating: 3000}, 0), queuedTicket(2, 2000, MatchPreferences{}, 0), false},
+		{"different rulesets", queuedTicket(1, 1500, MatchPreferences{Ruleset: rules.ClassicName}, 0), queuedTicket(2, 1500, MatchPreferences{Ruleset: rules.GomokuName}, 0), false},
+		{"one side has no preference", queuedTicket(1, 1500, MatchPreferences{Ruleset: rules.GomokuName}, 0), queuedTicket(2, 1500, MatchPreferences{}, 0), true},
+		{"same player", queuedTicket(1, 1500, MatchPreferences{}, 0), queuedTicket(1, 1500, MatchPreferences{}, 0), false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := compatible(tt.a, tt.b, queueNow); got != tt.wanted {
+				t.Errorf("compatible() = %v, want %v", got, tt.wanted)
+			}
+			if got := compatible(tt.b, tt.a, queueNow); got != tt.wanted {
+				t.Errorf("compatible() with the tickets swapped = %v, want %v", got, tt.wanted)
+			}
+		})
+	}
+}
+
+func TestEnqueueRejectsInvalidPreferences(t *testing.T) {
+	env := newTestEnv(t)
+	m := NewMatchmaker(env.gs)
+	bot := &domain.Player{Name: "bot", IsBot: true}
+
+	tests := []struct {
+		name   string
+		player *domain.Player
+		prefs  MatchPreferences
+	}{
+		{"bot", bot, MatchPreferences{}},
+		{"negative bound", env.alice, MatchPreferences{MinRating: -1}},
+		{"inverted range", env.alice, MatchPreferences{MinRating: 1600, MaxRating: 1400}},
+		{"unknown ruleset", env.alice, MatchPreferences{Ruleset: "chess"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := m.Enqueue(tt.player, tt.prefs); err == nil {
+				t.Errorf("Enqueue() succeeded, want an error")
+			}
+		})
+	}
+
+	if _, err := m.Enqueue(env.alice, MatchPreferences{Ruleset: rules.GomokuName}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := m.Enqueue(env.alice, MatchPreferences{}); !errors.Is(err, ErrAlreadyQueued) {
+		t.Errorf("second Enqueue() error = %v, want ErrAlreadyQueued", err)
+	}
+}
+
+func TestMatchCreatesTheRoom(t *testing.T) {
+	env := newTestEnv(t)
+	m := NewMatchmaker(env.gs)
+
+	alice, err := m.Enqueue(env.alice, MatchPreferences{Ruleset: rules.GomokuName})
+	if err != nil {
+		t.Fatal(err)
+	}
+	bob, err := m.Enqueue(env.bob, MatchPreferences{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	a, b := <-alice.Result(), <-bob.Result()
+	if a.Err != nil || b.Err != nil {
+		t.Fatalf("match errors = %v, %v", a.Err, b.Err)
+	}
+	if a.Match.RoomID != b.Match.RoomID || a.Match.Ruleset != rules.GomokuName {
+		t.Errorf("matches = room %s / %s with %s, want the same gomoku room", a.Match.RoomID, b.Match.RoomID, a.Match.Ruleset)
+	}
+	if a.Match.Symbol == b.Match.Symbol || a.Match.Opponent.Name != "bob" || b.Match.Opponent.Name != "alice" {
+		t.Errorf("alice plays %s against %s, bob plays %s against %s", a.Match.Symbol, a.Match.Opponent.Name, b.Match.Symbol, b.Match.Opponent.Name)
+	}
+
+	game, err := env.gs.currentGame(a.Match.RoomID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if game.SeatOf(env.alice.ID) != a.Match.Symbol || !canResume(game, env.bob.ID, b.Match.ResumeToken) {
+		t.Errorf("room seats do not match the announced symbols and resume tokens")
+	}
+}
+
+func TestCancelledTicketIsNotMatched(t *testing.T) {
+	env := newTestEnv(t)
+	m := NewMatchmaker(env.gs)
+
+	// Far enough apart that they only match once the gap has widened.
+	env.bob.Rating = env.alice.Rating + matchBaseTolerance + matchToleranceStep
+	alice, err := m.Enqueue(env.alice, MatchPreferences{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	bob, err := m.Enqueue(env.bob, MatchPreferences{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !m.Cancel(alice) {
+		t.Errorf("Cancel() = false for a waiting ticket")
+	}
+	if m.Cancel(alice) {
+		t.Errorf("Cancel() = true for a ticket already cancelled")
+	}
+
+	m.matchQueued(time.Now().Add(time.Minute))
+	select {
+	case result := <-bob.Result():
+		t.Fatalf("bob was matched with a cancelled ticket: %+v", result)
+	default:
+	}
+
+	// The cancelled player may queue again.
+	if _, err := m.Enqueue(env.alice, MatchPreferences{}); err != nil {
+		t.Errorf("Enqueue() after Cancel() error = %v", err)
+	}
+	m.matchQueued(time.Now().Add(time.Minute))
+	if result := <-bob.Result(); result.Err != nil || result.Match.Opponent.Name != "alice" {
+		t.Errorf("bob's match = %+v, want alice once the gap widened", result)
+	}
+	if m.Cancel(bob) {
+		t.Errorf("Cancel() = true for a ticket already matched")
+	}
+}
@@ -0,0 +1,235 @@
+/*
+ * file: presence_services.go
+ * package: services
+ * description:
+ *     Shares who is connected to each room between backend instances. Every Hub
+ *     publishes the players and observers it holds for a room on the broadcaster
+ *     whenever they change, and again periodically; the other instances keep the
+ *     latest presence of each peer, so a player connected anywhere counts as present.
+ */
+
+package services
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"log"
+	"time"
+)
+
+const (
+	// presenceInterval is how often a Hub republishes the presence of its rooms.
+	presenceInterval = 15 * time.Second
+	// presenceTTL is how long a peer's presence counts without being republished,
+	// so the clients of an instance that stopped are eventually forgotten.
+	presenceTTL = 3 * presenceInterval
+	// presenceQueueSize bounds the rooms waiting for their presence to be published.
+	presenceQueueSize = 256
+)
+
+/*
+ * roomPresence is what an instance publishes about a room: its connected seated
+ * players and how many observers it holds. An empty presence withdraws the instance.
+ *
+ * Fields:
+ *   - Instance (string): The publishing instance.
+ *   - Players ([]uint): The IDs of the players connected as players.
+ *   - Observers (int): The number of observer connections.
+ */
+type roomPresence struct {
+	Instance  string `json:"instance"`
+	Players   []uint `json:"players,omitempty"`
+	Observers int    `json:"observers,omitempty"`
+}
+
+/*
+ * peerPresence is the latest presence received from another instance for a room.
+ *
+ * Fields:
+ *   - players (map[uint]bool): The players connected there.
+ *   - observers (int): The observers connected there.
+ *   - seenAt (time.Time): When it was received.
+ */
+type peerPresence struct {
+	players   map[uint]bool
+	observers int
+	seenAt    time.Time
+}
+
+/*
+ * newInstanceID generates the random identifier a Hub signs its presence with.
+ *
+ * Returns:
+ *   - string: A 16-character hexadecimal ID.
+ */
+func newInstanceID() string {
+	buf := make([]byte, 8)
+	if _, err := rand.Read(buf); err != nil {
+		log.Printf("WARN: Could not generate instance ID, using the clock: %v", err)
+		return fmt.Sprintf("%016x", time.Now().UnixNano())
+	}
+	return hex.EncodeToString(buf)
+}
+
+/*
+ * presenceChanged queues a room whose local clients changed, to have its presence
+ * published. It never blocks the Hub loop: if the queue is full the room is published
+ * with the next heartbeat instead.
+ *
+ * Parameters:
+ *   - roomID (string): The unique identifier of the room.
+ *
+ * Returns:
+ *   - None.
+ */
+func (h *Hub) presenceChanged(roomID string) {
+	select {
+	case h.presenceQueue <- roomID:
+	default:
+		log.Printf("WARN: Presence queue full, room %s waits for the next heartbeat", roomID)
+	}
+}
+
+/*
+ * runPresence publishes the presence of the rooms queued by presenceChanged and,
+ * every presenceInterval, of all the rooms with local clients. Stale peer presence
+ * is pruned at the same time. It blocks forever and is started by Run.
+ *
+ * Parameters:
+ *   - None.
+ *
+ * Returns:
+ *   - None.
+ */
+func (h *Hub) runPresence() {
+	ticker := time.NewTicker(presenceInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case roomID := <-h.presenceQueue:
+			h.publishPresence(roomID)
+
+		case <-ticker.C:
+			h.mu.RLock()
+			roomIDs := make([]string, 0, len(h.rooms))
+			for roomID := range h.rooms {
+				roomIDs = append(roomIDs, roomID)
+			}
+			h.mu.RUnlock()
+			for _, roomID := range roomIDs {
+				h.publishPresence(roomID)
+			}
+			h.prunePresence()
+		}
+	}
+}
+
+/*
+ * publishPresence publishes this instance's current clients in a room.
+ *
+ * Parameters:
+ *   - roomID (string): The unique identifier of the room.
+ *
+ * Returns:
+ *   - None.
+ */
+func (h *Hub) publishPresence(roomID string) {
+	presence := roomPresence{Instance: h.instance}
+	h.mu.RLock()
+	seen := make(map[uint]bool)
+	for client := range h.rooms[roomID] {
+		switch {
+		case client.isObserver:
+			presence.Observers++
+		case !seen[client.playerID]:
+			seen[client.playerID] = true
+			presence.Players = append(presence.Players, client.playerID)
+		}
+	}
+	h.mu.RUnlock()
+
+	h.publish(roomID, roomEnvelope{Presence: &presence})
+}
+
+/*
+ * recordPresence stores the presence another instance published for a room.
+ * The Hub's own presence, which the broadcaster also hands back, is ignored.
+ *
+ * Parameters:
+ *   - roomID (string): The unique identifier of the room.
+ *   - presence (roomPresence): The published presence.
+ *
+ * Returns:
+ *   - None.
+ */
+func (h *Hub) recordPresence(roomID string, presence roomPresence) {
+	if presence.Instance == h.instance {
+		return
+	}
+
+	h.presenceMu.Lock()
+	defer h.presenceMu.Unlock()
+	if len(presence.Players) == 0 && presence.Observers == 0 {
+		delete(h.peers[roomID], presence.Instance)
+		if len(h.peers[roomID]) == 0 {
+			delete(h.peers, roomID)
+		}
+		return
+	}
+
+	players := make(map[uint]bool, len(presence.Players))
+	for _, id := range presence.Players {
+		players[id] = true
+	}
+	if h.peers[roomID] == nil {
+		h.peers[roomID] = make(map[string]peerPresence)
+	}
+	h.peers[roomID][presence.Instance] = peerPresence{players: players, observers: presence.Observers, seenAt: time.Now()}
+}
+
+/*
+ * prunePresence forgets the peer presence not republished within presenceTTL.
+ *
+ * Parameters:
+ *   - None.
+ *
+ * Returns:
+ *   - None.
+ */
+func (h *Hub) prunePresence() {
+	h.presenceMu.Lock()
+	defer h.presenceMu.Unlock()
+	for roomID, peers := range h.peers {
+		for instance, peer := range peers {
+			if time.Since(peer.seenAt) > presenceTTL {
+				delete(peers, instance)
+			}
+		}
+		if len(peers) == 0 {
+			delete(h.peers, roomID)
+		}
+	}
+}
+
+/*
+ * peersOf returns the fresh presence other instances published for a room.
+ *
+ * Parameters:
+ *   - roomID (string): The unique identifier of the room.
+ *
+ * Returns:
+ *   - []peerPresence: One entry per instance with clients in the room.
+ */
+func (h *Hub) peersOf(roomID string) []peerPresence {
+	h.presenceMu.RLock()
+	defer h.presenceMu.RUnlock()
+	var fresh []peerPresence
+	for _, peer := range h.peers[roomID] {
+		if time.Since(peer.seenAt) <= presenceTTL {
+			fresh = append(fresh, peer)
+		}
+	}
+	return fresh
+}
@@ -0,0 +1,106 @@
+/*
+ * file: presence_services_test.go
+ * package: services
+ * description:
+ *     Tests for presence shared between instances: two hubs on one broadcaster see
+ *     each other's players and observers, a player connected to the other instance
+ *     is not forfeited, and the presence of a silent instance expires.
+ */
+
+package services
+
+import (
+	"testing"
+	"time"
+
+	"github.com/juan10024/tictactoe-test/internal/core/domain"
+	"github.com/juan10024/tictactoe-test/internal/infra/broadcast"
+)
+
+func TestPresenceIsSharedBetweenHubs(t *testing.T) {
+	shared := broadcast.NewMemoryBroadcaster()
+	here, there := newTestHub(shared), newTestHub(shared)
+	env := newTestEnv(t)
+
+	alice := connect(there, "room-1", env.alice, false)
+	connect(there, "room-1", env.bob, true)
+	connect(there, "room-1", env.bob, true)
+	waitFor(t, "the other hub's clients show up", func() bool {
+		return here.hasPlayer("room-1", env.alice.ID) && here.spectators("room-1") == 2
+	})
+
+	tests := []struct {
+		name string
+		got  interface{}
+		want interface{}
+	}{
+		{"alice is a player", here.hasPlayer("room-1", env.alice.ID), true},
+		{"bob only watches", here.hasPlayer("room-1", env.bob.ID), false},
+		{"room has clients", here.hasClients("room-1"), true},
+		{"other room has none", here.hasClients("room-2"), false},
+		{"spectators", here.spectators("room-1"), 2},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.got != tt.want {
+				t.Errorf("got %v, want %v", tt.got, tt.want)
+			}
+		})
+	}
+
+	// Local and remote observers add up.
+	connect(here, "room-1", env.bob, true)
+	waitFor(t, "the local observer is counted", func() bool { return here.spectators("room-1") == 3 })
+	if there.spectators("room-1") != 3 {
+		t.Errorf("other hub counts %d spectators, want 3", there.spectators("room-1"))
+	}
+
+	there.unregister <- alice
+	waitFor(t, "alice leaves the other hub", func() bool { return !here.hasPlayer("room-1", env.alice.ID) })
+}
+
+func TestNoForfeitWhileConnectedToAnotherHub(t *testing.T) {
+	shared := broadcast.NewMemoryBroadcaster()
+	here, there := newTestHub(shared), newTestHub(shared)
+	env := newTestEnv(t)
+	env.startRoom(t, "room-1", domain.GameConfig{})
+
+	// Alice dropped her connection here and came back through the other instance.
+	alice := connect(there, "room-1", env.alice, false)
+	waitFor(t, "alice shows up on the other hub", func() bool { return here.hasPlayer("room-1", env.alice.ID) })
+	scheduleForfeit(here, env.gs, "room-1", env.alice.ID)
+	time.Sleep(5 * testGrace)
+	if status := env.gameStatus(t, "room-1"); status != "in_progress" {
+		t.Fatalf("game status = %s while alice is connected to the other hub, want in_progress", status)
+	}
+
+	// Once she leaves that one too, her grace period there forfeits the game.
+	there.unregister <- alice
+	scheduleForfeit(there, env.gs, "room-1", env.alice.ID)
+	waitFor(t, "the game is forfeited", func() bool { return env.gameStatus(t, "room-1") == "finished" })
+}
+
+func TestSilentHubPresenceExpires(t *testing.T) {
+	shared := broadcast.NewMemoryBroadcaster()
+	here, there := newTestHub(shared), newTestHub(shared)
+	env := newTestEnv(t)
+
+	connect(there, "room-1", env.alice, false)
+	waitFor(t, "alice shows up on the other hub", func() bool { return here.hasPlayer("room-1", env.alice.ID) })
+
+	// The other instance stops without withdrawing its clients.
+	here.presenceMu.Lock()
+	for instance, peer := range here.peers["room-1"] {
+		peer.seenAt = time.Now().Add(-presenceTTL - time.Second)
+		here.peers["room-1"][instance] = peer
+	}
+	here.presenceMu.Unlock()
+
+	if here.hasPlayer("room-1", env.alice.ID) || here.hasClients("room-1") {
+		t.Errorf("stale presence still counts")
+	}
+	here.prunePresence()
+	if len(here.peers) != 0 {
+		t.Errorf("stale presence kept after pruning: %v", here.peers)
+	}
+}
@@ -0,0 +1,214 @@
+/*
+ * file: rating_services.go
+ * package: services
+ * description:
+ *     Implements the Glicko-2 rating system used to rank players by skill.
+ *     Every finished game is treated as a one-game rating period for both players.
+ */
+
+package services
+
+import (
+	"errors"
+	"fmt"
+	"math"
+
+	"github.com/juan10024/tictactoe-test/internal/core/domain"
+	"github.com/juan10024/tictactoe-test/internal/core/ports"
+)
+
+// Glicko-2 system constants.
+const (
+	glickoScale     = 173.7178 // Converts between the Glicko and Glicko-2 scales.
+	glickoTau       = 0.5      // Constrains how fast volatility changes.
+	glickoEpsilon   = 0.000001 // Convergence tolerance of the volatility iteration.
+	maxRatingDev    = 350.0    // Deviation of an unrated player.
+	ratingBaseValue = 1500.0   // Rating of an unrated player.
+)
+
+/*
+ * updateRatings computes the new Glicko-2 ratings of both players of a finished game
+ * and persists them, together with one history entry per player. The players embedded
+ * in the game are refreshed with their new stats and ratings.
+ *
+ * Parameters:
+ *   - repo (ports.GameRepository): The repository of the unit of work finishing the game.
+ *   - game (*domain.Game): The finished game.
+ *   - winnerSymbol (string): "X", "O", or an empty string for a draw.
+ *
+ * Returns:
+ *   - error: An error if the players cannot be loaded or their ratings saved.
+ */
+func updateRatings(repo ports.GameRepository, game *domain.Game, winnerSymbol string) error {
+	if game.PlayerXID == nil || game.PlayerOID == nil {
+		return nil
+	}
+
+	scoreX := 0.5
+	switch winnerSymbol {
+	case "X":
+		scoreX = 1
+	case "O":
+		scoreX = 0
+	}
+
+	// A conflict means one of the players just finished another game: rate again from their new values.
+	for attempt := 0; ; attempt++ {
+		playerX, errX := repo.GetPlayerByID(*game.PlayerXID)
+		playerO, errO := repo.GetPlayerByID(*game.PlayerOID)
+		if err := errors.Join(errX, errO); err != nil {
+			return err
+		}
+		if playerX == nil || playerO == nil {
+			return fmt.Errorf("could not load players to rate game %d in room %s", game.ID, game.RoomID)
+		}
+
+		history := rateMatch(game.ID, playerX, playerO, scoreX)
+		err := repo.UpdateRatings([]*domain.Player{playerX, playerO}, history)
+		if err == nil {
+			game.PlayerX, game.PlayerO = *playerX, *playerO
+			return nil
+		}
+		if !errors.Is(err, ports.ErrConflict) || attempt == maxConflictRetries {
+			return err
+		}
+	}
+}
+
+/*
+ * rateMatch applies the Glicko-2 update to both players of a game, using each
+ * player's rating from before the game for the other's update.
+ *
+ * Parameters:
+ *   - gameID (uint): The game that produced the result.
+ *   - a, b (*domain.Player): The two players; their rating fields are updated in place.
+ *   - scoreA (float64): The score of player a (1 win, 0.5 draw, 0 loss).
+ *
+ * Returns:
+ *   - []domain.RatingHistory: One history entry per player with the new rating.
+ */
+func rateMatch(gameID uint, a, b *domain.Player, scoreA float64) []domain.RatingHistory {
+	ratingA, devA, volA := glicko2(a.Rating, a.RatingDeviation, a.RatingVolatility, glickoResult{b.Rating, b.RatingDeviation, scoreA})
+	ratingB, devB, volB := glicko2(b.Rating, b.RatingDeviation, b.RatingVolatility, glickoResult{a.Rating, a.RatingDeviation, 1 - scoreA})
+
+	a.Rating, a.RatingDeviation, a.RatingVolatility = ratingA, devA, volA
+	b.Rating, b.RatingDeviation, b.RatingVolatility = ratingB, devB, volB
+
+	history := make([]domain.RatingHistory, 0, 2)
+	for _, p := range []*domain.Player{a, b} {
+		gid := gameID
+		history = append(history, domain.RatingHistory{
+			PlayerID:         p.ID,
+			GameID:           &gid,
+			Rating:           p.Rating,
+			RatingDeviation:  p.RatingDeviation,
+			RatingVolatility: p.RatingVolatility,
+		})
+	}
+	return history
+}
+
+/*
+ * glickoResult is one game of a rating period, seen from the player being rated.
+ *
+ * Fields:
+ *   - oppRating, oppDeviation (float64): The opponent's rating and deviation before the game.
+ *   - score (float64): The player's score (1 win, 0.5 draw, 0 loss).
+ */
+type glickoResult struct {
+	oppRating, oppDeviation, score float64
+}
+
+/*
+ * glicko2 computes a player's new rating after a rating period, following
+ * Glickman's "Example of the Glicko-2 system". The service rates one game per period.
+ *
+ * Parameters:
+ *   - rating, deviation, volatility (float64): The player's current rating values.
+ *   - results (...glickoResult): The games of the period; at least one.
+ *
+ * Returns:
+ *   - float64: The new rating.
+ *   - float64: The new rating deviation.
+ *   - float64: The new volatility.
+ */
+func glicko2(rating, deviation, volatility float64, results ...glickoResult) (float64, float64, float64) {
+	if deviation <= 0 {
+		rating, deviation, volatility = ratingBaseValue, maxRatingDev, 0.06
+	}
+
+	mu := (rating - ratingBaseValue) / glickoScale
+	phi := deviation / glickoScale
+
+	var inverseV, improvement float64
+	for _, r := range results {
+		if r.oppDeviation <= 0 {
+			r.oppRating, r.oppDeviation = ratingBaseValue, maxRatingDev
+		}
+		oppMu := (r.oppRating - ratingBaseValue) / glickoScale
+		oppPhi := r.oppDeviation / glickoScale
+
+		g := 1 / math.Sqrt(1+3*oppPhi*oppPhi/(math.Pi*math.Pi))
+		expected := 1 / (1 + math.Exp(-g*(mu-oppMu)))
+		inverseV += g * g * expected * (1 - expected)
+		improvement += g * (r.score - expected)
+	}
+	v := 1 / inverseV
+	delta := v * improvement
+
+	newVolatility := nextVolatility(phi, volatility, v, delta)
+
+	phiStar := math.Sqrt(phi*phi + newVolatility*newVolatility)
+	newPhi := 1 / math.Sqrt(1/(phiStar*phiStar)+1/v)
+	newMu := mu + newPhi*newPhi*improvement
+
+	newDeviation := math.Min(newPhi*glickoScale, maxRatingDev)
+	return newMu*glickoScale + ratingBaseValue, newDeviation, newVolatility
+}
+
+/*
+ * nextVolatility solves for the new volatility with the Illinois algorithm (step 5 of Glicko-2).
+ *
+ * Parameters:
+ *   - phi (float64): The player's deviation on the Glicko-2 scale.
+ *   - volatility (float64): The player's current volatility.
+ *   - v (float64): The estimated variance of the player's rating based on the game.
+ *   - delta (float64): The estimated improvement in rating.
+ *
+ * Returns:
+ *   - float64: The new volatility.
+ */
+func nextVolatility(phi, volatility, v, delta float64) float64 {
+	a := math.Log(volatility * volatility)
+	f := func(x float64) float64 {
+		ex := math.Exp(x)
+		num := ex * (delta*delta - phi*phi - v - ex)
+		den := 2 * (phi*phi + v + ex) * (phi*phi + v + ex)
+		return num/den - (x-a)/(glickoTau*glickoTau)
+	}
+
+	lower := a
+	var upper float64
+	if delta*delta > phi*phi+v {
+		upper = math.Log(delta*delta - phi*phi - v)
+	} else {
+		k := 1.0
+		for f(a-k*glickoTau) < 0 {
+			k++
+		}
+		upper = a - k*glickoTau
+	}
+
+	fLower, fUpper := f(lower), f(upper)
+	for math.Abs(upper-lower) > glickoEpsilon {
+		c := lower + (lower-upper)*fLower/(fUpper-fLower)
+		fC := f(c)
+		if fC*fUpper <= 0 {
+			lower, fLower = upper, fUpper
+		} else {
+			fLower /= 2
+		}
+		upper, fUpper = c, fC
+	}
+	return math.Exp(lower / 2)
+}
@@ -0,0 +1,99 @@
+/*
+ * file: rating_services_test.go
+ * package: services
+ * description:
+ *     Tests for the Glicko-2 implementation: the worked example of Glickman's paper,
+ *     and the win, loss and draw paths of a single rated game.
+ */
+
+package services
+
+import (
+	"math"
+	"testing"
+
+	"github.com/juan10024/tictactoe-test/internal/core/domain"
+)
+
+// near reports whether got is within tolerance of want.
+func near(got, want, tolerance float64) bool {
+	return math.Abs(got-want) <= tolerance
+}
+
+// TestGlicko2PaperExample reproduces "Example of the Glicko-2 system" (Glickman, 2013):
+// a 1500/200/0.06 player beats a 1400/30 player and loses to 1550/100 and 1700/300 ones.
+func TestGlicko2PaperExample(t *testing.T) {
+	rating, deviation, volatility := glicko2(1500, 200, 0.06,
+		glickoResult{1400, 30, 1},
+		glickoResult{1550, 100, 0},
+		glickoResult{1700, 300, 0},
+	)
+
+	if !near(rating, 1464.06, 0.01) {
+		t.Errorf("rating = %.4f, want 1464.06", rating)
+	}
+	if !near(deviation, 151.52, 0.01) {
+		t.Errorf("deviation = %.4f, want 151.52", deviation)
+	}
+	if !near(volatility, 0.05999, 0.00001) {
+		t.Errorf("volatility = %.6f, want 0.05999", volatility)
+	}
+}
+
+func TestRateMatch(t *testing.T) {
+	tests := []struct {
+		name          string
+		ratingA       float64
+		ratingB       float64
+		scoreA        float64
+		wantAUp       bool
+		wantBUp       bool
+		wantUnchanged bool
+	}{
+		{"win", 1500, 1500, 1, true, false, false},
+		{"loss", 1500, 1500, 0, false, true, false},
+		{"draw between equals", 1500, 1500, 0.5, false, false, true},
+		{"draw favours the lower rated", 1400, 1600, 0.5, true, false, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			a := &domain.Player{ID: 1, Rating: tt.ratingA, RatingDeviation: 200, RatingVolatility: 0.06}
+			b := &domain.Player{ID: 2, Rating: tt.ratingB, RatingDeviation: 200, RatingVolatility: 0.06}
+			history := rateMatch(7, a, b, tt.scoreA)
+
+			if tt.wantUnchanged {
+				if !near(a.Rating, tt.ratingA, 1e-9) || !near(b.Rating, tt.ratingB, 1e-9) {
+					t.Errorf("ratings = %.4f / %.4f, want unchanged", a.Rating, b.Rating)
+				}
+			} else {
+				if (a.Rating > tt.ratingA) != tt.wantAUp || (b.Rating > tt.ratingB) != tt.wantBUp {
+					t.Errorf("ratings = %.4f / %.4f from %.0f / %.0f", a.Rating, b.Rating, tt.ratingA, tt.ratingB)
+				}
+			}
+			// Equal deviations make the update zero-sum.
+			if !near(a.Rating-tt.ratingA, tt.ratingB-b.Rating, 1e-6) {
+				t.Errorf("rating changes %.4f and %.4f do not cancel out", a.Rating-tt.ratingA, b.Rating-tt.ratingB)
+			}
+			if a.RatingDeviation >= 200 || b.RatingDeviation >= 200 {
+				t.Errorf("deviations = %.4f / %.4f, want both below 200 after a game", a.RatingDeviation, b.RatingDeviation)
+			}
+
+			if len(history) != 2 || history[0].PlayerID != 1 || history[1].PlayerID != 2 {
+				t.Fatalf("history = %+v, want one entry per player", history)
+			}
+			if *history[0].GameID != 7 || history[0].Rating != a.Rating || history[1].Rating != b.Rating {
+				t.Errorf("history = %+v, want game 7 with the new ratings", history)
+			}
+		})
+	}
+}
+
+func TestGlicko2UnratedDefaults(t *testing.T) {
+	// A player or opponent without a deviation is treated as a new 1500/350 player.
+	got, gotDeviation, _ := glicko2(0, 0, 0, glickoResult{0, 0, 0.5})
+	want, wantDeviation, _ := glicko2(ratingBaseValue, maxRatingDev, 0.06, glickoResult{ratingBaseValue, maxRatingDev, 0.5})
+	if got != want || gotDeviation != wantDeviation {
+		t.Errorf("glicko2() = %.4f/%.4f, want %.4f/%.4f", got, gotDeviation, want, wantDeviation)
+	}
+}
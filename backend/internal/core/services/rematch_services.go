@@ -0,0 +1,450 @@
+/*
+ * file: rematch_services.go
+ * package: services
+ * description:
+ *     Negotiates rematches on the server: after a game finishes either player may
+ *     offer a rematch, which the other accepts or declines before it expires. The
+ *     next game only starts once both players agreed (a bot always agrees), and every
+ *     change of the negotiation is broadcast to the whole room, observers included.
+ */
+
+package services
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/juan10024/tictactoe-test/internal/core/domain"
+	"github.com/juan10024/tictactoe-test/internal/core/protocol"
+)
+
+// rematchOfferTTL is how long a rematch offer waits for the other player.
+const rematchOfferTTL = 30 * time.Second
+
+// Rematch negotiation states.
+const (
+	RematchOffered  = "offered"
+	RematchAccepted = "accepted"
+	RematchDeclined = "declined"
+	RematchExpired  = "expired"
+)
+
+// Rematch negotiation actions a player can take.
+const (
+	rematchOffer   = "offer"
+	rematchAccept  = "accept"
+	rematchDecline = "decline"
+)
+
+var (
+	// ErrRematchNotAllowed is returned when a rematch is negotiated by an observer or before the game is finished.
+	ErrRematchNotAllowed = errors.New("a rematch can only be negotiated by a player once the game is finished")
+	// ErrNoRematchOffer is returned when a player answers a rematch nobody offered.
+	ErrNoRematchOffer = errors.New("there is no rematch offer to answer")
+)
+
+/*
+ * rematchNegotiation is the rematch state of a room after a finished game. It is only
+ * touched on the room's actor.
+ *
+ * Fields:
+ *   - gameID (uint): The finished game the rematch follows.
+ *   - state (string): RematchOffered, RematchAccepted, RematchDeclined or RematchExpired.
+ *   - offeredBy (string): The symbol of the player who offered.
+ *   - accepted (map[string]bool): The symbols that agreed; the offer counts as agreement.
+ *   - declinedBy (string): The symbol of the player who declined, if any.
+ *   - expiresAt (time.Time): When an unanswered offer expires.
+ */
+type rematchNegotiation struct {
+	gameID     uint
+	state      string
+	offeredBy  string
+	accepted   map[string]bool
+	declinedBy string
+	expiresAt  time.Time
+}
+
+/*
+ * OfferRematch offers a rematch of the room's finished game. If the opponent already
+ * offered one, the offer accepts it.
+ *
+ * Parameters:
+ *   - roomID (string): The unique identifier of the room.
+ *   - playerID (uint): The player offering.
+ *
+ * Returns:
+ *   - *protocol.RematchUpdate: The negotiation after the offer.
+ *   - *domain.Game: The next game if the rematch was agreed, nil otherwise.
+ *   - error: ErrRematchNotAllowed, or an error if the next game cannot be created.
+ */
+func (s *GameService) OfferRematch(roomID string, playerID uint) (*protocol.RematchUpdate, *domain.Game, error) {
+	return s.negotiateRematch(roomID, playerID, rematchOffer)
+}
+
+/*
+ * RespondToRematch accepts or declines the pending rematch offer of a room.
+ *
+ * Parameters:
+ *   - roomID (string): The unique identifier of the room.
+ *   - playerID (uint): The player answering.
+ *   - accept (bool): True to accept the offer, false to decline it.
+ *
+ * Returns:
+ *   - *protocol.RematchUpdate: The negotiation after the answer.
+ *   - *domain.Game: The next game if the rematch was agreed, nil otherwise.
+ *   - error: ErrRematchNotAllowed, ErrNoRematchOffer, or an error if the next game cannot be created.
+ */
+func (s *GameService) RespondToRematch(roomID string, playerID uint, accept bool) (*protocol.RematchUpdate, *domain.Game, error) {
+	if accept {
+		return s.negotiateRematch(roomID, playerID, rematchAccept)
+	}
+	return s.negotiateRematch(roomID, playerID, rematchDecline)
+}
+
+/*
+ * negotiateRematch applies a player's action to the room's rematch negotiation on the
+ * room's actor, and starts the next game once both players agreed.
+ *
+ * Parameters:
+ *   - roomID (string): The unique identifier of the room.
+ *   - playerID (uint): The player acting.
+ *   - action (string): rematchOffer, rematchAccept or rematchDecline.
+ *
+ * Returns:
+ *   - *protocol.RematchUpdate: The negotiation after the action.
+ *   - *domain.Game: The next game if the rematch was agreed, nil otherwise.
+ *   - error: ErrRematchNotAllowed, ErrNoRematchOffer, or an error if the next game cannot be created.
+ */
+func (s *GameService) negotiateRematch(roomID string, playerID uint, action string) (*protocol.RematchUpdate, *domain.Game, error) {
+	var update *protocol.RematchUpdate
+	var newGame *domain.Game
+	err := s.inRoom(roomID, func(room *roomActor) error {
+		game, err := room.current(s.repo)
+		if err != nil || game.Status != "finished" {
+			return ErrRematchNotAllowed
+		}
+		symbol := game.SeatOf(playerID)
+		if symbol == "" {
+			return ErrRematchNotAllowed
+		}
+		roomRec, err := room.loadRoom(s.roomService)
+		if err != nil {
+			return err
+		}
+
+		offer, err := advanceRematch(room.rematch, game, symbol, action, time.Now())
+		if err != nil {
+			return err
+		}
+		room.rematch = offer
+
+		if offer.agreed() {
+			next, err := s.startRematch(room, game, roomRec)
+			if err != nil {
+				return err
+			}
+			offer.state = RematchAccepted
+			newGame = next
+		}
+
+		update = rematchUpdate(offer, game, roomRec.SwapsOnRematch())
+		return nil
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+	return update, newGame, nil
+}
+
+/*
+ * ExpireRematch expires a rematch offer that nobody answered in time.
+ *
+ * Parameters:
+ *   - roomID (string): The unique identifier of the room.
+ *   - gameID (uint): The finished game the offer follows.
+ *
+ * Returns:
+ *   - *protocol.RematchUpdate: The expired negotiation, or nil if the offer was already answered.
+ *   - error: An error if the room's game cannot be loaded.
+ */
+func (s *GameService) ExpireRematch(roomID string, gameID uint) (*protocol.RematchUpdate, error) {
+	var update *protocol.RematchUpdate
+	err := s.inRoom(roomID, func(room *roomActor) error {
+		offer := room.rematch
+		if offer == nil || !offer.pendingFor(gameID) {
+			return nil
+		}
+		game, err := room.current(s.repo)
+		if err != nil {
+			return err
+		}
+		roomRec, err := room.currentRoom(s.roomService)
+		if err != nil {
+			return err
+		}
+
+		offer.state = RematchExpired
+		update = rematchUpdate(offer, game, roomRec.SwapsOnRematch())
+		return nil
+	})
+	return update, err
+}
+
+/*
+ * advanceRematch applies a player's action to a room's rematch negotiation. Without
+ * a pending offer for the game, an offer opens a new negotiation, in which a bot
+ * opponent has already agreed; accepting or declining needs a pending offer.
+ *
+ * Parameters:
+ *   - offer (*rematchNegotiation): The room's negotiation, nil if none.
+ *   - game (*domain.Game): The room's finished game.
+ *   - symbol (string): The symbol of the player acting.
+ *   - action (string): rematchOffer, rematchAccept or rematchDecline.
+ *   - now (time.Time): The instant of the action; an offer past its expiry is no longer pending.
+ *
+ * Returns:
+ *   - *rematchNegotiation: The negotiation after the action; a new one for a new offer.
+ *   - error: ErrNoRematchOffer if the player answers an offer that is not pending.
+ */
+func advanceRematch(offer *rematchNegotiation, game *domain.Game, symbol, action string, now time.Time) (*rematchNegotiation, error) {
+	if offer == nil || !offer.pendingFor(game.ID) || !now.Before(offer.expiresAt) {
+		if action != rematchOffer {
+			return nil, ErrNoRematchOffer
+		}
+		offer = &rematchNegotiation{
+			gameID:    game.ID,
+			state:     RematchOffered,
+			offeredBy: symbol,
+			accepted:  make(map[string]bool),
+			expiresAt: now.Add(rematchOfferTTL),
+		}
+		if game.BotSymbol != "" {
+			offer.accepted[game.BotSymbol] = true
+		}
+	}
+
+	if action == rematchDecline {
+		offer.state = RematchDeclined
+		offer.declinedBy = symbol
+	} else {
+		offer.accepted[symbol] = true
+	}
+	return offer, nil
+}
+
+/*
+ * pendingFor reports whether the negotiation is an unanswered offer following the given game.
+ *
+ * Parameters:
+ *   - gameID (uint): The finished game.
+ *
+ * Returns:
+ *   - bool: True if the offer still waits for an answer.
+ */
+func (n *rematchNegotiation) pendingFor(gameID uint) bool {
+	return n.gameID == gameID && n.state == RematchOffered
+}
+
+/*
+ * agreed reports whether both players agreed to a pending offer.
+ *
+ * Returns:
+ *   - bool: True once X and O both accepted.
+ */
+func (n *rematchNegotiation) agreed() bool {
+	return n.state == RematchOffered && n.accepted["X"] && n.accepted["O"]
+}
+
+/*
+ * startRematch creates the room's next game once both players agreed, reusing the
+ * players, ruleset and board options of the finished game. The players swap symbols
+ * if the room is configured to (series rooms always do), and a new series starts
+ * once the previous one is over. It runs on the room's actor.
+ *
+ * Parameters:
+ *   - room (*roomActor): The room's actor.
+ *   - latest (*domain.Game): The room's finished game.
+ *   - roomRec (*domain.Room): A private copy of the room's settings and score.
+ *
+ * Returns:
+ *   - *domain.Game: The newly created game.
+ *   - error: An error if the new game cannot be created.
+ */
+func (s *GameService) startRematch(room *roomActor, latest *domain.Game, roomRec *domain.Room) (*domain.Game, error) {
+	rules, err := s.rulesFor(latest.Ruleset)
+	if err != nil {
+		return nil, err
+	}
+
+	game := &domain.Game{
+		RoomID:    latest.RoomID,
+		PlayerXID: latest.PlayerXID,
+		PlayerX:   latest.PlayerX,
+		PlayerOID: latest.PlayerOID,
+		PlayerO:   latest.PlayerO,
+		Status:    "in_progress",
+		BotLevel:  latest.BotLevel,
+		BotSymbol: latest.BotSymbol,
+
+		ResumeTokenX: latest.ResumeTokenX,
+		ResumeTokenO: latest.ResumeTokenO,
+	}
+	if err := rules.Setup(game, latest.Config()); err != nil {
+		return nil, err
+	}
+	if err := applyTimeControl(game, latest.Config()); err != nil {
+		return nil, err
+	}
+
+	if roomRec.SwapsOnRematch() {
+		swapSeats(game)
+	}
+	if roomRec.IsSeries() && roomRec.SeriesOver() {
+		roomRec.ResetScore()
+	}
+	startClock(game, time.Now())
+
+	if err := s.createGame(room, roomRec, game); err != nil {
+		return nil, err
+	}
+	room.commit(game)
+	return game, nil
+}
+
+/*
+ * rematchUpdate builds the broadcast describing a room's rematch negotiation.
+ *
+ * Parameters:
+ *   - offer (*rematchNegotiation): The negotiation.
+ *   - game (*domain.Game): The finished game it follows.
+ *   - swapSides (bool): Whether the players exchange X and O in the next game.
+ *
+ * Returns:
+ *   - *protocol.RematchUpdate: The message for the room.
+ */
+func rematchUpdate(offer *rematchNegotiation, game *domain.Game, swapSides bool) *protocol.RematchUpdate {
+	return &protocol.RematchUpdate{
+		Header:         protocol.Header{Type: protocol.TypeRematchUpdate},
+		GameID:         offer.gameID,
+		State:          offer.state,
+		OfferedBy:      offer.offeredBy,
+		OfferedByName:  playerNameOf(game, offer.offeredBy),
+		AcceptedX:      offer.accepted["X"],
+		AcceptedO:      offer.accepted["O"],
+		DeclinedBy:     offer.declinedBy,
+		DeclinedByName: playerNameOf(game, offer.declinedBy),
+		SwapSides:      swapSides,
+		ExpiresAt:      offer.expiresAt,
+	}
+}
+
+/*
+ * playerNameOf returns the name of the player seated at a symbol.
+ *
+ * Parameters:
+ *   - game (*domain.Game): The game to inspect.
+ *   - symbol (string): "X", "O" or "".
+ *
+ * Returns:
+ *   - string: The player's name, or "" for an empty seat or symbol.
+ */
+func playerNameOf(game *domain.Game, symbol string) string {
+	switch {
+	case symbol == "X" && game.PlayerXID != nil:
+		return game.PlayerX.Name
+	case symbol == "O" && game.PlayerOID != nil:
+		return game.PlayerO.Name
+	}
+	return ""
+}
+
+/*
+ * rematchKey builds the Hub timer key of a room's rematch offer.
+ *
+ * Parameters:
+ *   - roomID (string): The unique identifier of the room.
+ *
+ * Returns:
+ *   - string: The timer key.
+ */
+func rematchKey(roomID string) string {
+	return fmt.Sprintf("%s#rematch", roomID)
+}
+
+/*
+ * handleRematch applies a client's rematch message and tells the room about it: the
+ * negotiation state goes to everybody in the room, the ack or error only to the client.
+ * A new offer arms its expiry timer; agreeing to the rematch starts the next game.
+ *
+ * Parameters:
+ *   - c (*Client): The player's client.
+ *   - gs (*GameService): Service used to negotiate the rematch.
+ *   - req (protocol.ClientMessage): A rematchOffer, rematchAccept or rematchDecline message.
+ *
+ * Returns:
+ *   - None.
+ */
+func handleRematch(c *Client, gs *GameService, req protocol.ClientMessage) {
+	var update *protocol.RematchUpdate
+	var game *domain.Game
+	var err error
+	switch req.Type {
+	case protocol.TypeRematchOffer:
+		update, game, err = gs.OfferRematch(c.room, c.playerID)
+	default:
+		update, game, err = gs.RespondToRematch(c.room, c.playerID, req.Type == protocol.TypeRematchAccept)
+	}
+	if err != nil {
+		log.Printf("WARN: %s by player %d in room %s refused: %v", req.Type, c.playerID, c.room, err)
+		c.fail(req, err)
+		return
+	}
+	c.ack(req)
+
+	if update.State == RematchOffered {
+		hub, roomID, gameID := c.hub, c.room, update.GameID
+		hub.scheduleTimer(rematchKey(roomID), rematchOfferTTL, func() {
+			expired, err := gs.ExpireRematch(roomID, gameID)
+			if err != nil {
+				log.Printf("ERROR: Could not expire rematch offer in room %s: %v", roomID, err)
+				return
+			}
+			if expired != nil {
+				broadcastRematch(hub, roomID, expired)
+			}
+		})
+	} else {
+		c.hub.stopTimer(rematchKey(c.room))
+	}
+
+	broadcastRematch(c.hub, c.room, update)
+	if game != nil {
+		broadcastGameState(c.hub, gs, c.room)
+		if game.IsBotTurn() {
+			scheduleBotMove(c.hub, gs, c.room)
+		}
+	}
+}
+
+/*
+ * broadcastRematch sends a rematch negotiation update to everybody in the room.
+ *
+ * Parameters:
+ *   - hub (*Hub): Reference to the Hub managing rooms and clients.
+ *   - roomID (string): The unique identifier of the room.
+ *   - update (*protocol.RematchUpdate): The negotiation state.
+ *
+ * Returns:
+ *   - None.
+ */
+func broadcastRematch(hub *Hub, roomID string, update *protocol.RematchUpdate) {
+	msgBytes, err := json.Marshal(update)
+	if err != nil {
+		log.Printf("ERROR: Could not marshal rematch update: %v", err)
+		return
+	}
+	hub.broadcast(roomID, msgBytes)
+}
@@ -0,0 +1,210 @@
+/*
+ * file: rematch_services_test.go
+ * package: services
+ * description:
+ *     Tests for the rematch negotiation state machine: offers, acceptance by the other
+ *     player or a crossing offer, declines, expiry, and the bot's automatic agreement.
+ */
+
+package services
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/juan10024/tictactoe-test/internal/core/domain"
+)
+
+var rematchNow = time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+
+// finishedGame returns a finished game between alice (X) and bob (O).
+func finishedGame(id uint) *domain.Game {
+	x, o := uint(1), uint(2)
+	game := &domain.Game{
+		RoomID:    "room-1",
+		Status:    "finished",
+		PlayerXID: &x,
+		PlayerX:   domain.Player{Name: "alice"},
+		PlayerOID: &o,
+		PlayerO:   domain.Player{Name: "bob"},
+	}
+	game.ID = id
+	return game
+}
+
+// step is one player's action in a negotiation, taken after the given delay.
+type step struct {
+	symbol, action string
+	after          time.Duration
+}
+
+// negotiate runs the steps from no offer and returns the final negotiation and the error of the last step.
+func negotiate(game *domain.Game, steps ...step) (*rematchNegotiation, error) {
+	var offer *rematchNegotiation
+	var err error
+	for _, s := range steps {
+		var next *rematchNegotiation
+		next, err = advanceRematch(offer, game, s.symbol, s.action, rematchNow.Add(s.after))
+		if err == nil {
+			offer = next
+		}
+	}
+	return offer, err
+}
+
+func TestAdvanceRematch(t *testing.T) {
+	tests := []struct {
+		name           string
+		botSymbol      string
+		steps          []step
+		wantErr        error
+		wantState      string
+		wantAgreed     bool
+		wantOfferedBy  string
+		wantDeclinedBy string
+	}{
+		{
+			name:          "offer waits for the opponent",
+			steps:         []step{{"X", rematchOffer, 0}},
+			wantState:     RematchOffered,
+			wantOfferedBy: "X",
+		},
+		{
+			name:          "opponent accepts",
+			steps:         []step{{"X", rematchOffer, 0}, {"O", rematchAccept, time.Second}},
+			wantState:     RematchOffered,
+			wantAgreed:    true,
+			wantOfferedBy: "X",
+		},
+		{
+			name:          "crossing offers agree",
+			steps:         []step{{"O", rematchOffer, 0}, {"X", rematchOffer, time.Second}},
+			wantState:     RematchOffered,
+			wantAgreed:    true,
+			wantOfferedBy: "O",
+		},
+		{
+			name:          "the offerer cannot accept alone",
+			steps:         []step{{"X", rematchOffer, 0}, {"X", rematchAccept, time.Second}},
+			wantState:     RematchOffered,
+			wantOfferedBy: "X",
+		},
+		{
+			name:           "opponent declines",
+			steps:          []step{{"X", rematchOffer, 0}, {"O", rematchDecline, time.Second}},
+			wantState:      RematchDeclined,
+			wantOfferedBy:  "X",
+			wantDeclinedBy: "O",
+		},
+		{
+			name:    "accepting without an offer",
+			steps:   []step{{"O", rematchAccept, 0}},
+			wantErr: ErrNoRematchOffer,
+		},
+		{
+			name:    "accepting a declined offer",
+			steps:   []step{{"X", rematchOffer, 0}, {"O", rematchDecline, time.Second}, {"O", rematchAccept, 2 * time.Second}},
+			wantErr: ErrNoRematchOffer,
+		},
+		{
+			name:          "offering again after a decline",
+			steps:         []step{{"X", rematchOffer, 0}, {"O", rematchDecline, time.Second}, {"O", rematchOffer, 2 * time.Second}},
+			wantState:     RematchOffered,
+			wantOfferedBy: "O",
+		},
+		{
+			name:    "accepting after the offer expired",
+			steps:   []step{{"X", rematchOffer, 0}, {"O", rematchAccept, rematchOfferTTL}},
+			wantErr: ErrNoRematchOffer,
+		},
+		{
+			name:          "accepting just before expiry",
+			steps:         []step{{"X", rematchOffer, 0}, {"O", rematchAccept, rematchOfferTTL - time.Millisecond}},
+			wantState:     RematchOffered,
+			wantAgreed:    true,
+			wantOfferedBy: "X",
+		},
+		{
+			name:          "a bot agrees at once",
+			botSymbol:     "O",
+			steps:         []step{{"X", rematchOffer, 0}},
+			wantState:     RematchOffered,
+			wantAgreed:    true,
+			wantOfferedBy: "X",
+		},
+		{
+			name:          "a bot playing X agrees too",
+			botSymbol:     "X",
+			steps:         []step{{"O", rematchOffer, 0}},
+			wantState:     RematchOffered,
+			wantAgreed:    true,
+			wantOfferedBy: "O",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			game := finishedGame(7)
+			game.BotSymbol = tt.botSymbol
+			offer, err := negotiate(game, tt.steps...)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("advanceRematch() error = %v, want %v", err, tt.wantErr)
+			}
+			if tt.wantErr != nil {
+				return
+			}
+			if offer.state != tt.wantState || offer.agreed() != tt.wantAgreed {
+				t.Errorf("state = %s, agreed = %v; want %s, %v", offer.state, offer.agreed(), tt.wantState, tt.wantAgreed)
+			}
+			if offer.offeredBy != tt.wantOfferedBy || offer.declinedBy != tt.wantDeclinedBy {
+				t.Errorf("offeredBy = %q, declinedBy = %q; want %q, %q", offer.offeredBy, offer.declinedBy, tt.wantOfferedBy, tt.wantDeclinedBy)
+			}
+			if offer.gameID != 7 {
+				t.Errorf("gameID = %d, want 7", offer.gameID)
+			}
+		})
+	}
+}
+
+func TestRematchOfferForAnotherGame(t *testing.T) {
+	offer, err := negotiate(finishedGame(7), step{"X", rematchOffer, 0})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The room has finished another game since: the old offer cannot be answered.
+	if _, err := advanceRematch(offer, finishedGame(8), "O", rematchAccept, rematchNow); !errors.Is(err, ErrNoRematchOffer) {
+		t.Errorf("accepting an offer for game 7 after game 8: error = %v, want ErrNoRematchOffer", err)
+	}
+	next, err := advanceRematch(offer, finishedGame(8), "O", rematchOffer, rematchNow)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if next == offer || next.gameID != 8 || next.agreed() {
+		t.Errorf("offer after game 8 = %+v, want a new pending offer for game 8", next)
+	}
+}
+
+func TestRematchExpiry(t *testing.T) {
+	offer, err := negotiate(finishedGame(7), step{"X", rematchOffer, 0})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !offer.expiresAt.Equal(rematchNow.Add(rematchOfferTTL)) {
+		t.Errorf("expiresAt = %v, want %v", offer.expiresAt, rematchNow.Add(rematchOfferTTL))
+	}
+	if !offer.pendingFor(7) || offer.pendingFor(8) {
+		t.Errorf("pendingFor(7) = %v, pendingFor(8) = %v; want true, false", offer.pendingFor(7), offer.pendingFor(8))
+	}
+
+	offer.state = RematchExpired
+	if offer.pendingFor(7) || offer.agreed() {
+		t.Errorf("an expired offer is still pending or agreed")
+	}
+
+	update := rematchUpdate(offer, finishedGame(7), true)
+	if update.State != RematchExpired || update.OfferedByName != "alice" || !update.SwapSides || update.AcceptedO {
+		t.Errorf("rematchUpdate() = %+v", update)
+	}
+}
@@ -0,0 +1,155 @@
+/*
+ * file: replay_services.go
+ * package: services
+ * description:
+ *     Provides the move log of a game and rebuilds the board after every ply,
+ *     so finished or disputed games can be reviewed move by move.
+ */
+
+package services
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/juan10024/tictactoe-test/internal/core/domain"
+	"github.com/juan10024/tictactoe-test/internal/core/ports"
+)
+
+/*
+ * GameMovesResponse represents the response DTO containing the move log of a game.
+ *
+ * Fields:
+ *   - GameID (uint): The game identifier.
+ *   - Moves ([]domain.GameMove): Every recorded move, in the order it was played.
+ */
+type GameMovesResponse struct {
+	GameID uint              `json:"gameId"`
+	Moves  []domain.GameMove `json:"moves"`
+}
+
+/*
+ * ReplayFrame represents the state of the board after one ply.
+ *
+ * Fields:
+ *   - MoveNumber (int): The ply that produced this frame; 0 is the empty starting board.
+ *   - Position (*int): The position played, nil for the starting board.
+ *   - Symbol (string): The symbol played, empty for the starting board.
+ *   - Board (string): The board after the move.
+ *   - ActiveBoard (*int): The sub-board the next move is forced into (Ultimate only).
+ */
+type ReplayFrame struct {
+	MoveNumber  int    `json:"moveNumber"`
+	Position    *int   `json:"position"`
+	Symbol      string `json:"symbol,omitempty"`
+	Board       string `json:"board"`
+	ActiveBoard *int   `json:"activeBoard"`
+}
+
+/*
+ * GameReplayResponse represents the response DTO containing a full game replay.
+ *
+ * Fields:
+ *   - Game (*domain.Game): The replayed game in its current state.
+ *   - Frames ([]ReplayFrame): The board before the first move and after every ply.
+ */
+type GameReplayResponse struct {
+	Game   *domain.Game  `json:"game"`
+	Frames []ReplayFrame `json:"frames"`
+}
+
+/*
+ * getGame loads a game by ID, translating a missing record into ErrGameNotFound.
+ *
+ * Parameters:
+ *   - gameID (uint): The game identifier.
+ *
+ * Returns:
+ *   - *domain.Game: The game.
+ *   - error: ErrGameNotFound, or the repository error.
+ */
+func (s *GameService) getGame(gameID uint) (*domain.Game, error) {
+	game, err := s.repo.GetByID(gameID)
+	if errors.Is(err, ports.ErrNotFound) {
+		return nil, ErrGameNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return game, nil
+}
+
+/*
+ * GetGameMoves retrieves the move log of a game.
+ *
+ * Parameters:
+ *   - gameID (uint): The game identifier.
+ *
+ * Returns:
+ *   - *GameMovesResponse: DTO containing the game's moves.
+ *   - error: ErrGameNotFound if the game does not exist, or an error if retrieving the data fails.
+ */
+func (s *GameService) GetGameMoves(gameID uint) (*GameMovesResponse, error) {
+	if _, err := s.getGame(gameID); err != nil {
+		return nil, err
+	}
+
+	moves, err := s.repo.GetMovesByGameID(gameID)
+	if err != nil {
+		return nil, err
+	}
+	return &GameMovesResponse{GameID: gameID, Moves: moves}, nil
+}
+
+/*
+ * GetGameReplay replays the recorded moves of a game with its ruleset and
+ * returns the board after each ply.
+ *
+ * Parameters:
+ *   - gameID (uint): The game identifier.
+ *
+ * Returns:
+ *   - *GameReplayResponse: DTO containing the game and one frame per ply.
+ *   - error: ErrGameNotFound if the game does not exist, or an error if a recorded move cannot be replayed.
+ */
+func (s *GameService) GetGameReplay(gameID uint) (*GameReplayResponse, error) {
+	game, err := s.getGame(gameID)
+	if err != nil {
+		return nil, err
+	}
+
+	rules, err := s.rulesFor(game.Ruleset)
+	if err != nil {
+		return nil, err
+	}
+
+	moves, err := s.repo.GetMovesByGameID(gameID)
+	if err != nil {
+		return nil, err
+	}
+
+	var replay domain.Game
+	if err := rules.Setup(&replay, game.Config()); err != nil {
+		return nil, err
+	}
+
+	frames := make([]ReplayFrame, 0, len(moves)+1)
+	frames = append(frames, ReplayFrame{Board: replay.Board})
+	for _, move := range moves {
+		replay.CurrentTurn = move.Symbol
+		if err := rules.ApplyMove(&replay, move.Position); err != nil {
+			return nil, fmt.Errorf("move %d cannot be replayed: %w", move.MoveNumber, err)
+		}
+
+		position := move.Position
+		frames = append(frames, ReplayFrame{
+			MoveNumber:  move.MoveNumber,
+			Position:    &position,
+			Symbol:      move.Symbol,
+			Board:       replay.Board,
+			ActiveBoard: replay.ActiveBoard,
+		})
+	}
+
+	return &GameReplayResponse{Game: game, Frames: frames}, nil
+}
@@ -0,0 +1,131 @@
+/*
+ * file: replay_services_test.go
+ * package: services
+ * description:
+ *     Tests for the move log and the replay: the board after every ply, the forced
+ *     sub-board of Ultimate games, unknown games and move logs that cannot be replayed.
+ */
+
+package services
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/juan10024/tictactoe-test/internal/core/domain"
+	"github.com/juan10024/tictactoe-test/internal/core/rules"
+)
+
+func TestGameReplay(t *testing.T) {
+	env := newTestEnv(t)
+	env.startRoom(t, "room-1", domain.GameConfig{})
+	game := env.play(t, "room-1", 0, 3, 1, 4, 2)
+
+	moves, err := env.gs.GetGameMoves(game.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(moves.Moves) != 5 {
+		t.Fatalf("GetGameMoves() = %d moves, want 5", len(moves.Moves))
+	}
+	for i, move := range moves.Moves {
+		wantPlayer := env.alice.ID
+		if i%2 == 1 {
+			wantPlayer = env.bob.ID
+		}
+		if move.MoveNumber != i+1 || move.PlayerID != wantPlayer {
+			t.Errorf("move %d = number %d by player %d, want number %d by %d", i, move.MoveNumber, move.PlayerID, i+1, wantPlayer)
+		}
+	}
+
+	replay, err := env.gs.GetGameReplay(game.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if replay.Game.ID != game.ID || replay.Game.Status != "finished" {
+		t.Errorf("replayed game = %d %s, want %d finished", replay.Game.ID, replay.Game.Status, game.ID)
+	}
+
+	want := []struct {
+		position int
+		symbol   string
+		board    string
+	}{
+		{-1, "", "         "},
+		{0, "X", "X        "},
+		{3, "O", "X  O     "},
+		{1, "X", "XX O     "},
+		{4, "O", "XX OO    "},
+		{2, "X", "XXXOO    "},
+	}
+	if len(replay.Frames) != len(want) {
+		t.Fatalf("GetGameReplay() = %d frames, want %d", len(replay.Frames), len(want))
+	}
+	for i, w := range want {
+		frame := replay.Frames[i]
+		if frame.MoveNumber != i || frame.Symbol != w.symbol || frame.Board != w.board {
+			t.Errorf("frame %d = %d %q %q, want %d %q %q", i, frame.MoveNumber, frame.Symbol, frame.Board, i, w.symbol, w.board)
+		}
+		if (frame.Position == nil) != (w.position < 0) || (frame.Position != nil && *frame.Position != w.position) {
+			t.Errorf("frame %d position = %v, want %d", i, frame.Position, w.position)
+		}
+	}
+}
+
+func TestUltimateReplayTracksForcedSubBoard(t *testing.T) {
+	env := newTestEnv(t)
+	env.startRoom(t, "room-1", domain.GameConfig{Ruleset: rules.UltimateName})
+
+	// X plays the centre of the top-left sub-board, sending O to the centre sub-board,
+	// whose top-left cell sends X back to the top-left sub-board.
+	game := env.play(t, "room-1", 10, 30)
+
+	replay, err := env.gs.GetGameReplay(game.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(replay.Frames) != 3 {
+		t.Fatalf("GetGameReplay() = %d frames, want 3", len(replay.Frames))
+	}
+	if replay.Frames[0].ActiveBoard != nil {
+		t.Errorf("starting frame forces sub-board %d, want none", *replay.Frames[0].ActiveBoard)
+	}
+	for i, want := range []int{4, 0} {
+		frame := replay.Frames[i+1]
+		if frame.ActiveBoard == nil || *frame.ActiveBoard != want {
+			t.Errorf("frame %d active board = %v, want %d", i+1, frame.ActiveBoard, want)
+		}
+	}
+	if replay.Frames[2].Board != game.Board {
+		t.Errorf("last frame board differs from the game's board")
+	}
+}
+
+func TestReplayOfUnknownGame(t *testing.T) {
+	env := newTestEnv(t)
+	if _, err := env.gs.GetGameMoves(42); !errors.Is(err, ErrGameNotFound) {
+		t.Errorf("GetGameMoves(42) error = %v, want ErrGameNotFound", err)
+	}
+	if _, err := env.gs.GetGameReplay(42); !errors.Is(err, ErrGameNotFound) {
+		t.Errorf("GetGameReplay(42) error = %v, want ErrGameNotFound", err)
+	}
+}
+
+func TestReplayRejectsAnIllegalMoveLog(t *testing.T) {
+	env := newTestEnv(t)
+	env.startRoom(t, "room-1", domain.GameConfig{})
+	game := env.play(t, "room-1", 4)
+
+	// A second move on the same cell can only come from a corrupted log.
+	stored, err := env.store.GetByID(game.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := env.store.RecordMove(stored, &domain.GameMove{GameID: game.ID, PlayerID: env.bob.ID, MoveNumber: 2, Position: 4, Symbol: "O"}); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := env.gs.GetGameReplay(game.ID); err == nil {
+		t.Errorf("GetGameReplay() of a log playing a cell twice succeeded, want an error")
+	}
+}
@@ -0,0 +1,74 @@
+/*
+ * file: resume_services.go
+ * package: services
+ * description:
+ *     Issues per-seat resume tokens and checks them when a player reconnects to a
+ *     game in progress, so a refreshed browser gets its X/O seat back.
+ */
+
+package services
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/hex"
+	"log"
+
+	"github.com/juan10024/tictactoe-test/internal/core/domain"
+)
+
+/*
+ * newResumeToken generates a random, URL-safe seat token.
+ *
+ * Parameters:
+ *   - None.
+ *
+ * Returns:
+ *   - string: A 32-character hexadecimal token, or "" if no randomness is available.
+ */
+func newResumeToken() string {
+	buf := make([]byte, 16)
+	if _, err := rand.Read(buf); err != nil {
+		log.Printf("ERROR: Could not generate resume token: %v", err)
+		return ""
+	}
+	return hex.EncodeToString(buf)
+}
+
+/*
+ * assignResumeTokens gives every human seat of a game that does not have one yet a resume token.
+ * It must be called before the game is saved.
+ *
+ * Parameters:
+ *   - game (*domain.Game): The game whose seats were just filled.
+ *
+ * Returns:
+ *   - None.
+ */
+func assignResumeTokens(game *domain.Game) {
+	if game.PlayerXID != nil && game.BotSymbol != "X" && game.ResumeTokenX == "" {
+		game.ResumeTokenX = newResumeToken()
+	}
+	if game.PlayerOID != nil && game.BotSymbol != "O" && game.ResumeTokenO == "" {
+		game.ResumeTokenO = newResumeToken()
+	}
+}
+
+/*
+ * canResume reports whether a reconnecting player presented the resume token of their seat.
+ *
+ * Parameters:
+ *   - game (*domain.Game): The room's current game.
+ *   - playerID (uint): The authenticated player.
+ *   - token (string): The resume token presented by the client.
+ *
+ * Returns:
+ *   - bool: True if the player is seated and the token matches their seat.
+ */
+func canResume(game *domain.Game, playerID uint, token string) bool {
+	expected := game.ResumeTokenOf(playerID)
+	if token == "" || expected == "" {
+		return false
+	}
+	return subtle.ConstantTimeCompare([]byte(token), []byte(expected)) == 1
+}
@@ -0,0 +1,169 @@
+/*
+ * file: resume_services_test.go
+ * package: services
+ * description:
+ *     Tests for resume tokens: which seats get one, which tokens are accepted, and a
+ *     player reclaiming their seat over a new connection while the old one is still open.
+ */
+
+package services
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+
+	"github.com/juan10024/tictactoe-test/internal/core/domain"
+	"github.com/juan10024/tictactoe-test/internal/core/protocol"
+	"github.com/juan10024/tictactoe-test/internal/infra/broadcast"
+)
+
+func TestAssignResumeTokens(t *testing.T) {
+	x, o := uint(1), uint(2)
+	tests := []struct {
+		name      string
+		game      domain.Game
+		wantX     bool
+		wantO     bool
+		keepsOldX bool
+	}{
+		{"both seats human", domain.Game{PlayerXID: &x, PlayerOID: &o}, true, true, false},
+		{"only X seated", domain.Game{PlayerXID: &x}, true, false, false},
+		{"bot plays O", domain.Game{PlayerXID: &x, PlayerOID: &o, BotSymbol: "O"}, true, false, false},
+		{"X already has a token", domain.Game{PlayerXID: &x, PlayerOID: &o, ResumeTokenX: "kept"}, true, true, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			game := tt.game
+			assignResumeTokens(&game)
+			if (game.ResumeTokenX != "") != tt.wantX || (game.ResumeTokenO != "") != tt.wantO {
+				t.Errorf("tokens = %q / %q, want X: %v, O: %v", game.ResumeTokenX, game.ResumeTokenO, tt.wantX, tt.wantO)
+			}
+			if tt.keepsOldX && game.ResumeTokenX != "kept" {
+				t.Errorf("existing X token replaced by %q", game.ResumeTokenX)
+			}
+			if game.ResumeTokenX != "" && game.ResumeTokenX == game.ResumeTokenO {
+				t.Errorf("both seats got the same token")
+			}
+		})
+	}
+}
+
+func TestCanResume(t *testing.T) {
+	x, o := uint(1), uint(2)
+	game := &domain.Game{PlayerXID: &x, PlayerOID: &o, ResumeTokenX: "token-x", ResumeTokenO: "token-o"}
+	tests := []struct {
+		name     string
+		playerID uint
+		token    string
+		want     bool
+	}{
+		{"own seat's token", x, "token-x", true},
+		{"other seat's token", x, "token-o", false},
+		{"not seated", 3, "token-x", false},
+		{"no token", x, "", false},
+		{"wrong token", o, "token-x", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := canResume(game, tt.playerID, tt.token); got != tt.want {
+				t.Errorf("canResume(%d, %q) = %v, want %v", tt.playerID, tt.token, got, tt.want)
+			}
+		})
+	}
+}
+
+// roomServer serves room-1 over WebSocket to the player named in ?player=, as the room handler does.
+func roomServer(t *testing.T, env *testEnv, hub *Hub) *httptest.Server {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		player := env.alice
+		if r.URL.Query().Get("player") == "bob" {
+			player = env.bob
+		}
+		ServeWs(hub, env.gs, w, r, "room-1", player, r.URL.Query().Get("resume"), domain.GameConfig{}, domain.RoomAccess{})
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+// dialRoom connects to the room server and returns the connection with the snapshot it was sent on join.
+func dialRoom(t *testing.T, server *httptest.Server, player, resume string) (*websocket.Conn, protocol.GameState) {
+	t.Helper()
+	query := url.Values{"player": {player}, "resume": {resume}}
+	conn, _, err := websocket.DefaultDialer.Dial("ws"+strings.TrimPrefix(server.URL, "http")+"?"+query.Encode(), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { conn.Close() })
+
+	conn.SetReadDeadline(time.Now().Add(time.Second))
+	for {
+		_, message, err := conn.ReadMessage()
+		if err != nil {
+			t.Fatalf("reading the join snapshot: %v", err)
+		}
+		var state protocol.GameState
+		if err := json.Unmarshal(message, &state); err != nil {
+			t.Fatal(err)
+		}
+		if state.Type == protocol.TypeGameStateUpdate {
+			return conn, state
+		}
+	}
+}
+
+func TestResumeTokenReclaimsSeat(t *testing.T) {
+	env := newTestEnv(t)
+	hub := newTestHub(broadcast.NewMemoryBroadcaster())
+	server := roomServer(t, env, hub)
+	game := env.startRoom(t, "room-1", domain.GameConfig{})
+
+	stale, state := dialRoom(t, server, "alice", game.ResumeTokenX)
+	if state.IsObserver || state.Seat != "X" || state.ResumeToken != game.ResumeTokenX {
+		t.Fatalf("first connection = observer %v, seat %q, token %q; want seat X with its token", state.IsObserver, state.Seat, state.ResumeToken)
+	}
+
+	tests := []struct {
+		name   string
+		resume string
+	}{
+		{"without the token", ""},
+		{"with the other seat's token", game.ResumeTokenO},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, state := dialRoom(t, server, "alice", tt.resume); !state.IsObserver {
+				t.Errorf("second connection %s took the seat, want an observer", tt.name)
+			}
+		})
+	}
+
+	if _, state := dialRoom(t, server, "alice", game.ResumeTokenX); state.IsObserver || state.Seat != "X" {
+		t.Errorf("connection with the seat's token = observer %v, seat %q; want seat X", state.IsObserver, state.Seat)
+	}
+
+	// The connection whose seat was reclaimed is dropped...
+	stale.SetReadDeadline(time.Now().Add(time.Second))
+	for {
+		if _, _, err := stale.ReadMessage(); err != nil {
+			if ne, ok := err.(interface{ Timeout() bool }); ok && ne.Timeout() {
+				t.Fatalf("stale connection still open after its seat was reclaimed")
+			}
+			break
+		}
+	}
+
+	// ...without forfeiting the game, since the player is connected again.
+	time.Sleep(5 * testGrace)
+	if status := env.gameStatus(t, "room-1"); status != "in_progress" {
+		t.Errorf("game status = %s after reclaiming the seat, want in_progress", status)
+	}
+}
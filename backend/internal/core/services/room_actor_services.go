@@ -0,0 +1,346 @@
+/*
+ * file: room_actor_services.go
+ * package: services
+ * description:
+ *     Runs each active room on its own goroutine (actor). The actor holds the room's
+ *     authoritative game and room record (seats and series score) in memory and executes the commands sent to it one at a
+ *     time, so moves, joins and rematches in the same room never race. Every change is
+ *     written through to the repository before it becomes the room's state.
+ */
+
+package services
+
+import (
+	"errors"
+	"log"
+	"runtime/debug"
+	"sync"
+	"time"
+
+	"github.com/juan10024/tictactoe-test/internal/core/domain"
+	"github.com/juan10024/tictactoe-test/internal/core/ports"
+)
+
+// roomIdleTimeout is how long a room actor lives without receiving commands before releasing its game.
+const roomIdleTimeout = 5 * time.Minute
+
+/*
+ * roomActor owns the in-memory state of a single room.
+ *
+ * Fields:
+ *   - roomID (string): The room this actor runs.
+ *   - inbox (chan func()): Commands to execute, in arrival order.
+ *   - game (*domain.Game): The room's latest persisted game, nil until loaded. It is only
+ *     touched on the actor goroutine and never modified in place once committed.
+ *   - record (*domain.Room): The room's latest persisted settings, seats and series score, nil
+ *     until loaded. Like game, it is only touched on the actor and never modified once committed.
+ *   - pending (int): Commands acquired but not finished yet; guarded by the registry lock.
+ *   - onCommit (func(prev, next *domain.Game, record *domain.Room)): Told about every committed
+ *     game, with the room's record (nil if not loaded yet); may be nil. It runs on the actor.
+ *   - rematch (*rematchNegotiation): The rematch negotiation after the room's last finished game, nil if none.
+ */
+type roomActor struct {
+	roomID   string
+	inbox    chan func()
+	game     *domain.Game
+	record   *domain.Room
+	pending  int
+	onCommit func(prev, next *domain.Game, record *domain.Room)
+	rematch  *rematchNegotiation
+}
+
+/*
+ * current returns the room's game, loading it from the repository the first time.
+ * The returned game is shared and must not be modified; use load to change it.
+ *
+ * Parameters:
+ *   - repo (ports.GameRepository): Repository used when the game is not in memory yet.
+ *
+ * Returns:
+ *   - *domain.Game: The room's latest game.
+ *   - error: An error if the room has no game.
+ */
+func (a *roomActor) current(repo ports.GameRepository) (*domain.Game, error) {
+	if a.game == nil {
+		game, err := repo.GetByRoomID(a.roomID)
+		if err != nil {
+			return nil, err
+		}
+		a.game = game
+	}
+	return a.game, nil
+}
+
+/*
+ * load returns a private copy of the room's game that a command may modify.
+ * The changes only become the room's state once persisted and passed to commit.
+ *
+ * Parameters:
+ *   - repo (ports.GameRepository): Repository used when the game is not in memory yet.
+ *
+ * Returns:
+ *   - *domain.Game: A copy of the room's latest game.
+ *   - error: An error if the room has no game.
+ */
+func (a *roomActor) load(repo ports.GameRepository) (*domain.Game, error) {
+	game, err := a.current(repo)
+	if err != nil {
+		return nil, err
+	}
+	copied := *game
+	return &copied, nil
+}
+
+/*
+ * commit makes a persisted game the room's state and reports the change to the
+ * registry's watcher, if any. A command changing both should commitRoom first, so
+ * the watcher sees the record that goes with the game.
+ *
+ * Parameters:
+ *   - game (*domain.Game): The game just written to the repository.
+ *
+ * Returns:
+ *   - None.
+ */
+func (a *roomActor) commit(game *domain.Game) {
+	prev := a.game
+	a.game = game
+	if a.onCommit != nil {
+		a.onCommit(prev, game, a.record)
+	}
+}
+
+/*
+ * currentRoom returns the room's record, loading it the first time.
+ * The returned record is shared and must not be modified; use loadRoom to change it.
+ *
+ * Parameters:
+ *   - rooms (*RoomService): Service used when the record is not in memory yet.
+ *
+ * Returns:
+ *   - *domain.Room: The room's latest record.
+ *   - error: ErrRoomNotFound, or an error if the room cannot be loaded.
+ */
+func (a *roomActor) currentRoom(rooms *RoomService) (*domain.Room, error) {
+	if a.record == nil {
+		record, err := rooms.getRoom(a.roomID)
+		if err != nil {
+			return nil, err
+		}
+		a.record = record
+	}
+	return a.record, nil
+}
+
+/*
+ * loadRoom returns a private copy of the room's record that a command may modify.
+ * The changes only become the room's state once persisted and passed to commitRoom.
+ *
+ * Parameters:
+ *   - rooms (*RoomService): Service used when the record is not in memory yet.
+ *
+ * Returns:
+ *   - *domain.Room: A copy of the room's latest record.
+ *   - error: ErrRoomNotFound, or an error if the room cannot be loaded.
+ */
+func (a *roomActor) loadRoom(rooms *RoomService) (*domain.Room, error) {
+	record, err := a.currentRoom(rooms)
+	if err != nil {
+		return nil, err
+	}
+	copied := *record
+	return &copied, nil
+}
+
+/*
+ * commitRoom makes a persisted room record the room's state.
+ *
+ * Parameters:
+ *   - record (*domain.Room): The record just written to the repository.
+ *
+ * Returns:
+ *   - None.
+ */
+func (a *roomActor) commitRoom(record *domain.Room) {
+	a.record = record
+}
+
+/*
+ * roomRegistry starts room actors on demand and stops them once idle.
+ *
+ * Fields:
+ *   - mu (sync.Mutex): Protects actors and each actor's pending count.
+ *   - actors (map[string]*roomActor): Running actors, keyed by room ID.
+ *   - onCommit (func(prev, next *domain.Game, record *domain.Room)): Handed to every actor it starts; set once at startup.
+ */
+type roomRegistry struct {
+	mu       sync.Mutex
+	actors   map[string]*roomActor
+	onCommit func(prev, next *domain.Game, record *domain.Room)
+}
+
+/*
+ * newRoomRegistry creates an empty registry.
+ *
+ * Returns:
+ *   - *roomRegistry: A registry with no running actors.
+ */
+func newRoomRegistry() *roomRegistry {
+	return &roomRegistry{actors: make(map[string]*roomActor)}
+}
+
+/*
+ * acquire returns the actor of a room, starting it if needed, and reserves it
+ * for one command so it cannot stop before the command is delivered.
+ *
+ * Parameters:
+ *   - roomID (string): The unique identifier of the room.
+ *
+ * Returns:
+ *   - *roomActor: The room's running actor.
+ */
+func (r *roomRegistry) acquire(roomID string) *roomActor {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	actor, ok := r.actors[roomID]
+	if !ok {
+		actor = &roomActor{roomID: roomID, inbox: make(chan func()), onCommit: r.onCommit}
+		r.actors[roomID] = actor
+		go r.run(actor)
+	}
+	actor.pending++
+	return actor
+}
+
+/*
+ * run is the actor loop: it executes commands one by one and stops after
+ * roomIdleTimeout without any.
+ *
+ * Parameters:
+ *   - actor (*roomActor): The actor to run.
+ *
+ * Returns:
+ *   - None.
+ */
+func (r *roomRegistry) run(actor *roomActor) {
+	idle := time.NewTimer(roomIdleTimeout)
+	defer idle.Stop()
+
+	for {
+		select {
+		case cmd := <-actor.inbox:
+			cmd()
+			r.mu.Lock()
+			actor.pending--
+			r.mu.Unlock()
+
+			if !idle.Stop() {
+				select {
+				case <-idle.C:
+				default:
+				}
+			}
+			idle.Reset(roomIdleTimeout)
+
+		case <-idle.C:
+			r.mu.Lock()
+			if actor.pending == 0 {
+				delete(r.actors, actor.roomID)
+				r.mu.Unlock()
+				return
+			}
+			r.mu.Unlock()
+			idle.Reset(roomIdleTimeout)
+		}
+	}
+}
+
+/*
+ * inRoom runs fn on the room's actor and waits for it to finish. fn must not call
+ * inRoom for the same room, or it would wait on itself. If fn lost a version race
+ * against another writer (such as another backend instance), the room's in-memory
+ * game and record are reloaded and fn runs once more; a second conflict is returned as
+ * ErrGameConflict. A panicking fn does not take the actor down: the room's in-memory
+ * state, which fn may have left half-changed, is dropped and ErrRoomCommandFailed returned.
+ *
+ * Parameters:
+ *   - roomID (string): The unique identifier of the room.
+ *   - fn (func(*roomActor) error): The command, given exclusive access to the room.
+ *
+ * Returns:
+ *   - error: The error returned by fn, ErrGameConflict, or ErrRoomCommandFailed.
+ */
+func (s *GameService) inRoom(roomID string, fn func(room *roomActor) error) error {
+	actor := s.rooms.acquire(roomID)
+	done := make(chan error, 1)
+	actor.inbox <- func() {
+		defer func() {
+			if r := recover(); r != nil {
+				log.Printf("ERROR: Command panicked in room %s: %v\n%s", roomID, r, debug.Stack())
+				actor.game, actor.record = nil, nil
+				done <- ErrRoomCommandFailed
+			}
+		}()
+
+		err := fn(actor)
+		if errors.Is(err, ports.ErrConflict) {
+			log.Printf("WARN: Game in room %s changed concurrently, reloading: %v", roomID, err)
+			actor.game, actor.record = nil, nil
+			err = fn(actor)
+		}
+		if errors.Is(err, ports.ErrConflict) {
+			actor.game, actor.record = nil, nil
+			err = ErrGameConflict
+		}
+		done <- err
+	}
+	return <-done
+}
+
+/*
+ * currentGame returns a room's latest game from its actor, without touching the
+ * database when the room is already in memory. The result must not be modified.
+ *
+ * Parameters:
+ *   - roomID (string): The unique identifier of the room.
+ *
+ * Returns:
+ *   - *domain.Game: The room's latest game.
+ *   - error: ErrGameNotFound if the room has no game.
+ */
+func (s *GameService) currentGame(roomID string) (*domain.Game, error) {
+	var game *domain.Game
+	err := s.inRoom(roomID, func(room *roomActor) error {
+		var err error
+		game, err = room.current(s.repo)
+		return err
+	})
+	if err != nil {
+		return nil, ErrGameNotFound
+	}
+	return game, nil
+}
+
+/*
+ * seatedPlayers returns the players embedded in a game, nil for an empty seat.
+ *
+ * Parameters:
+ *   - game (*domain.Game): The game to inspect.
+ *
+ * Returns:
+ *   - *domain.Player: The X player.
+ *   - *domain.Player: The O player.
+ */
+func seatedPlayers(game *domain.Game) (*domain.Player, *domain.Player) {
+	var playerX, playerO *domain.Player
+	if game.PlayerXID != nil {
+		x := game.PlayerX
+		playerX = &x
+	}
+	if game.PlayerOID != nil {
+		o := game.PlayerO
+		playerO = &o
+	}
+	return playerX, playerO
+}
@@ -0,0 +1,199 @@
+/*
+ * file: room_actor_services_test.go
+ * package: services
+ * description:
+ *     Tests for the room actor: commands on one room run one at a time, a version
+ *     conflict reloads the room once before retrying, and a panicking command does not
+ *     take the room down.
+ */
+
+package services
+
+import (
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/juan10024/tictactoe-test/internal/core/domain"
+	"github.com/juan10024/tictactoe-test/internal/core/ports"
+	"github.com/juan10024/tictactoe-test/internal/core/ports/portstest"
+)
+
+/*
+ * instrumentedStore counts how the services use an in-memory store.
+ *
+ * Fields:
+ *   - loads (int): Games read by room ID, which the actor only does when its room is not in memory.
+ *   - inTx, maxInTx (int): Moves and transactions started and not finished, now and at most.
+ *   - conflicts (int): Moves and transactions that failed with a version conflict.
+ */
+type instrumentedStore struct {
+	*portstest.MemoryStore
+	mu        sync.Mutex
+	loads     int
+	inTx      int
+	maxInTx   int
+	conflicts int
+}
+
+func (s *instrumentedStore) GetByRoomID(roomID string) (*domain.Game, error) {
+	s.mu.Lock()
+	s.loads++
+	s.mu.Unlock()
+	return s.MemoryStore.GetByRoomID(roomID)
+}
+
+func (s *instrumentedStore) RecordMove(game *domain.Game, move *domain.GameMove) error {
+	return s.write(func() error { return s.MemoryStore.RecordMove(game, move) })
+}
+
+func (s *instrumentedStore) Do(fn func(games ports.GameRepository, rooms ports.RoomRepository) error) error {
+	return s.write(func() error { return s.MemoryStore.Do(fn) })
+}
+
+// write counts a write as started before it reaches the store, and holds it a moment,
+// so two commands of a room applied at the same time would show up in maxInTx.
+func (s *instrumentedStore) write(apply func() error) error {
+	s.mu.Lock()
+	s.inTx++
+	s.maxInTx = max(s.maxInTx, s.inTx)
+	s.mu.Unlock()
+
+	time.Sleep(time.Millisecond)
+	err := apply()
+
+	s.mu.Lock()
+	s.inTx--
+	if errors.Is(err, ports.ErrConflict) {
+		s.conflicts++
+	}
+	s.mu.Unlock()
+	return err
+}
+
+// newInstrumentedEnv builds a test environment whose game service goes through an instrumentedStore.
+func newInstrumentedEnv(t *testing.T) (*testEnv, *instrumentedStore) {
+	store := &instrumentedStore{MemoryStore: portstest.NewMemoryStore()}
+	return newTestEnvOn(t, store.MemoryStore, store, store), store
+}
+
+func TestConcurrentMovesAreSerialized(t *testing.T) {
+	env, store := newInstrumentedEnv(t)
+	game := env.startRoom(t, "room-1", domain.GameConfig{})
+
+	// X sends every possible first move at once: the first one applied wins, and the others
+	// see it was O's turn instead of racing it to the store.
+	var wg sync.WaitGroup
+	errs := make(chan error, 9)
+	for position := 0; position < 9; position++ {
+		wg.Add(1)
+		go func(position int) {
+			defer wg.Done()
+			_, err := env.gs.MakeMove("room-1", env.alice.ID, position)
+			errs <- err
+		}(position)
+	}
+	wg.Wait()
+	close(errs)
+
+	applied := 0
+	for err := range errs {
+		switch {
+		case err == nil:
+			applied++
+		case errors.Is(err, ErrGameConflict):
+			t.Errorf("MakeMove() error = %v, want the moves applied one after the other", err)
+		}
+	}
+	if applied != 1 {
+		t.Errorf("%d moves applied, want 1", applied)
+	}
+	if store.maxInTx != 1 || store.conflicts != 0 {
+		t.Errorf("writes at once = %d with %d conflicts, want 1 and none", store.maxInTx, store.conflicts)
+	}
+	if moves, _ := env.gs.GetGameMoves(game.ID); len(moves.Moves) != 1 {
+		t.Errorf("%d moves recorded, want 1", len(moves.Moves))
+	}
+}
+
+func TestConflictReloadsTheRoomOnce(t *testing.T) {
+	tests := []struct {
+		name      string
+		staleBy   int // Moves another instance plays behind the actor's back.
+		wantLoads int
+	}{
+		{"room in memory is current", 0, 0},
+		{"another instance played a round", 2, 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			env, store := newInstrumentedEnv(t)
+			started := env.startRoom(t, "room-1", domain.GameConfig{})
+			other := env.otherInstance()
+			for i, position := range []int{0, 4}[:tt.staleBy] {
+				mover := env.alice.ID
+				if i == 1 {
+					mover = env.bob.ID
+				}
+				if _, err := other.MakeMove("room-1", mover, position); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			store.loads, store.conflicts = 0, 0
+			// X to move either way, but the actor only finds out about the other moves when saving.
+			if _, err := env.gs.MakeMove("room-1", env.alice.ID, 8); err != nil {
+				t.Fatalf("MakeMove() error = %v", err)
+			}
+			if store.loads != tt.wantLoads || store.conflicts != tt.wantLoads {
+				t.Errorf("loads = %d, conflicts = %d; want %d of each", store.loads, store.conflicts, tt.wantLoads)
+			}
+			if moves, _ := env.gs.GetGameMoves(started.ID); len(moves.Moves) != tt.staleBy+1 {
+				t.Errorf("%d moves recorded, want %d", len(moves.Moves), tt.staleBy+1)
+			}
+		})
+	}
+}
+
+func TestRepeatedConflictIsReported(t *testing.T) {
+	env, _ := newInstrumentedEnv(t)
+	env.startRoom(t, "room-1", domain.GameConfig{})
+
+	attempts := 0
+	err := env.gs.inRoom("room-1", func(room *roomActor) error {
+		attempts++
+		if _, err := room.current(env.gs.repo); err != nil {
+			return err
+		}
+		return &ports.ConflictError{Entity: "game", ID: room.game.ID, Version: room.game.Version}
+	})
+	if !errors.Is(err, ErrGameConflict) || attempts != 2 {
+		t.Errorf("inRoom() = %v after %d attempts, want ErrGameConflict after 2", err, attempts)
+	}
+}
+
+func TestPanickingCommandResetsTheRoom(t *testing.T) {
+	env := newTestEnv(t)
+	game := env.startRoom(t, "room-1", domain.GameConfig{})
+
+	err := env.gs.inRoom("room-1", func(room *roomActor) error {
+		room.game = &domain.Game{RoomID: "room-1", Status: "corrupted"}
+		panic("half-applied command")
+	})
+	if !errors.Is(err, ErrRoomCommandFailed) {
+		t.Fatalf("inRoom() error = %v, want ErrRoomCommandFailed", err)
+	}
+
+	current, err := env.gs.currentGame("room-1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if current.ID != game.ID || current.Status != "in_progress" {
+		t.Errorf("room after the panic = game %d %s, want game %d in_progress reloaded from the store", current.ID, current.Status, game.ID)
+	}
+	if _, err := env.gs.MakeMove("room-1", env.alice.ID, 4); err != nil {
+		t.Errorf("MakeMove() after the panic error = %v, want the room to keep working", err)
+	}
+}
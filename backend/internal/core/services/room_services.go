@@ -0,0 +1,266 @@
+/*
+ * file: room_services.go
+ * package: services
+ * description:
+ *     Defines rooms: a room is created public, password-protected or invite-only
+ *     by its first player, and every later player or observer must satisfy its
+ *     policy. Room owners hand out signed, expiring invites. Rooms also track
+ *     their seats, series score and current game (see GameService.createGame).
+ */
+
+package services
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/juan10024/tictactoe-test/internal/core/domain"
+	"github.com/juan10024/tictactoe-test/internal/core/ports"
+)
+
+const (
+	// DefaultInviteTTL is how long an invite stays valid when no lifetime is requested.
+	DefaultInviteTTL = time.Hour
+	// maxInviteTTL caps the lifetime of an invite.
+	maxInviteTTL = 7 * 24 * time.Hour
+)
+
+var (
+	// ErrRoomAccessDenied is returned when a player enters a private room without a valid password or invite.
+	ErrRoomAccessDenied = errors.New("this room is private: a valid password or invite is required")
+	// ErrNotRoomOwner is returned when somebody other than the room owner asks for an invite.
+	ErrNotRoomOwner = errors.New("only the room owner can create invites")
+	// ErrRoomNotFound is returned when the requested room does not exist.
+	ErrRoomNotFound = errors.New("room not found")
+)
+
+/*
+ * RoomService provides business logic for room settings and access control.
+ *
+ * Fields:
+ *   - repo (ports.RoomRepository): Repository used to persist room settings.
+ *   - hasher (ports.PasswordHasher): Hashes and checks room passwords.
+ *   - invites (ports.InviteIssuer): Issues and verifies room invites.
+ */
+type RoomService struct {
+	repo    ports.RoomRepository
+	hasher  ports.PasswordHasher
+	invites ports.InviteIssuer
+}
+
+/*
+ * NewRoomService creates a new instance of RoomService.
+ *
+ * Parameters:
+ *   - r (ports.RoomRepository): The repository implementation for room settings.
+ *   - h (ports.PasswordHasher): The password hashing implementation.
+ *   - i (ports.InviteIssuer): The room invite implementation.
+ *
+ * Returns:
+ *   - *RoomService: A new service instance.
+ */
+func NewRoomService(r ports.RoomRepository, h ports.PasswordHasher, i ports.InviteIssuer) *RoomService {
+	return &RoomService{repo: r, hasher: h, invites: i}
+}
+
+/*
+ * Invite is a signed invite to a room.
+ *
+ * Fields:
+ *   - Token (string): The invite, presented as ?invite= or in the join request.
+ *   - RoomID (string): The room it admits to.
+ *   - ExpiresAt (time.Time): When the invite stops being valid.
+ */
+type Invite struct {
+	Token     string
+	RoomID    string
+	ExpiresAt time.Time
+}
+
+/*
+ * CreateInvite issues an invite to a room. Only the room owner may invite.
+ *
+ * Parameters:
+ *   - roomID (string): The room to invite to.
+ *   - player (*domain.Player): The authenticated player asking for the invite.
+ *   - ttl (time.Duration): How long the invite stays valid; non-positive selects DefaultInviteTTL.
+ *
+ * Returns:
+ *   - *Invite: The signed invite.
+ *   - error: ErrRoomNotFound, ErrNotRoomOwner, or an error if the invite cannot be issued.
+ */
+func (s *RoomService) CreateInvite(roomID string, player *domain.Player, ttl time.Duration) (*Invite, error) {
+	if ttl <= 0 {
+		ttl = DefaultInviteTTL
+	}
+	if ttl > maxInviteTTL {
+		return nil, fmt.Errorf("invites cannot last longer than %s", maxInviteTTL)
+	}
+
+	room, err := s.repo.GetRoom(roomID)
+	if errors.Is(err, ports.ErrNotFound) {
+		return nil, ErrRoomNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	if room.OwnerID == nil || *room.OwnerID != player.ID {
+		return nil, ErrNotRoomOwner
+	}
+
+	expiresAt := time.Now().Add(ttl)
+	token, err := s.invites.IssueInvite(domain.InviteClaims{RoomID: roomID, ExpiresAt: expiresAt})
+	if err != nil {
+		return nil, err
+	}
+	return &Invite{Token: token, RoomID: roomID, ExpiresAt: expiresAt}, nil
+}
+
+/*
+ * getRoom loads a room's record.
+ *
+ * Parameters:
+ *   - roomID (string): The unique identifier of the room.
+ *
+ * Returns:
+ *   - *domain.Room: The room.
+ *   - error: ErrRoomNotFound, or an error if the room cannot be loaded.
+ */
+func (s *RoomService) getRoom(roomID string) (*domain.Room, error) {
+	room, err := s.repo.GetRoom(roomID)
+	if errors.Is(err, ports.ErrNotFound) {
+		return nil, ErrRoomNotFound
+	}
+	return room, err
+}
+
+/*
+ * enterRoom lets a player into a room, creating the room with the player's settings
+ * if it does not exist yet. The owner and the room's seated players always get in;
+ * anybody else must satisfy the room's privacy policy.
+ *
+ * Parameters:
+ *   - roomID (string): The unique identifier of the room.
+ *   - player (*domain.Player): The player entering, as a player or an observer.
+ *   - config (domain.GameConfig): The room options; only Privacy, BestOf and SwapSides are used, and only on creation.
+ *   - access (domain.RoomAccess): The password or invite presented by the player.
+ *
+ * Returns:
+ *   - *domain.Room: The room entered.
+ *   - error: ErrRoomAccessDenied if the policy is not satisfied, a validation error for
+ *     invalid settings, or an error if the room cannot be loaded or created.
+ */
+func (s *RoomService) enterRoom(roomID string, player *domain.Player, config domain.GameConfig, access domain.RoomAccess) (*domain.Room, error) {
+	room, err := s.repo.GetRoom(roomID)
+	if errors.Is(err, ports.ErrNotFound) {
+		room, err = s.createRoom(roomID, player, config, access.Password)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if (room.OwnerID != nil && *room.OwnerID == player.ID) || room.IsSeated(player.ID) {
+		return room, nil
+	}
+	if err := s.checkAccess(room, access); err != nil {
+		return nil, err
+	}
+	return room, nil
+}
+
+/*
+ * createRoom validates the creator's settings and stores the room.
+ *
+ * Parameters:
+ *   - roomID (string): The unique identifier of the room.
+ *   - owner (*domain.Player): The player creating the room.
+ *   - config (domain.GameConfig): The requested Privacy (empty selects domain.RoomPublic)
+ *     BestOf (0 or 1 for independent games, or 3, 5 or 7) and SwapSides.
+ *   - password (string): The room password, required for domain.RoomPassword.
+ *
+ * Returns:
+ *   - *domain.Room: The stored room; another request may have created it first.
+ *   - error: A validation error, or an error if the room cannot be stored.
+ */
+func (s *RoomService) createRoom(roomID string, owner *domain.Player, config domain.GameConfig, password string) (*domain.Room, error) {
+	room := &domain.Room{ID: roomID, OwnerID: &owner.ID, Privacy: config.Privacy, BestOf: config.BestOf, SwapSides: config.SwapSides}
+	switch config.BestOf {
+	case 0:
+		room.BestOf = 1
+	case 1, 3, 5, 7:
+	default:
+		return nil, errors.New("bestOf must be 1, 3, 5 or 7")
+	}
+
+	switch config.Privacy {
+	case "":
+		room.Privacy = domain.RoomPublic
+	case domain.RoomPublic, domain.RoomInviteOnly:
+	case domain.RoomPassword:
+		if len(password) < 4 || len(password) > 72 {
+			return nil, errors.New("room password must be between 4 and 72 characters")
+		}
+		hash, err := s.hasher.Hash(password)
+		if err != nil {
+			return nil, err
+		}
+		room.PasswordHash = hash
+	default:
+		return nil, fmt.Errorf("unknown room privacy: %s", config.Privacy)
+	}
+	return s.repo.CreateRoom(room)
+}
+
+/*
+ * checkAccess applies a room's privacy policy. A valid invite opens any private room;
+ * a password only opens password-protected ones.
+ *
+ * Parameters:
+ *   - room (*domain.Room): The room being entered.
+ *   - access (domain.RoomAccess): The password or invite presented by the player.
+ *
+ * Returns:
+ *   - error: ErrRoomAccessDenied if the policy is not satisfied.
+ */
+func (s *RoomService) checkAccess(room *domain.Room, access domain.RoomAccess) error {
+	if !room.IsPrivate() {
+		return nil
+	}
+	if access.Invite != "" {
+		claims, err := s.invites.VerifyInvite(access.Invite)
+		if err == nil && claims.RoomID == room.ID {
+			return nil
+		}
+	}
+	if room.Privacy == domain.RoomPassword && access.Password != "" &&
+		s.hasher.Compare(room.PasswordHash, access.Password) == nil {
+		return nil
+	}
+	return ErrRoomAccessDenied
+}
+
+/*
+ * privacyOf returns the privacy of the given rooms; rooms without settings are public.
+ *
+ * Parameters:
+ *   - roomIDs ([]string): The rooms to look up.
+ *
+ * Returns:
+ *   - map[string]string: The privacy of each room, keyed by room ID.
+ *   - error: An error if the rooms cannot be loaded.
+ */
+func (s *RoomService) privacyOf(roomIDs []string) (map[string]string, error) {
+	rooms, err := s.repo.GetRooms(roomIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	privacy := make(map[string]string, len(roomIDs))
+	for _, id := range roomIDs {
+		privacy[id] = domain.RoomPublic
+	}
+	for _, room := range rooms {
+		privacy[room.ID] = room.Privacy
+	}
+	return privacy, nil
+}
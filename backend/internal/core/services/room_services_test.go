@@ -0,0 +1,198 @@
+/*
+ * file: room_services_test.go
+ * package: services
+ * description:
+ *     Tests for private rooms: which passwords and invites open which rooms, who may
+ *     issue invites, the settings a room can be created with, and how a socket
+ *     refused entry is closed.
+ */
+
+package services
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+
+	"github.com/juan10024/tictactoe-test/internal/core/domain"
+	"github.com/juan10024/tictactoe-test/internal/infra/auth"
+	"github.com/juan10024/tictactoe-test/internal/infra/broadcast"
+)
+
+func TestCheckAccess(t *testing.T) {
+	env := newTestEnv(t)
+	hash, err := auth.NewBcryptHasher().Hash("open sesame")
+	if err != nil {
+		t.Fatal(err)
+	}
+	issuer := auth.NewHMACInviteIssuer([]byte("services-test-secret"))
+	invite := func(roomID string, ttl time.Duration) string {
+		token, err := issuer.IssueInvite(domain.InviteClaims{RoomID: roomID, ExpiresAt: time.Now().Add(ttl)})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return token
+	}
+	foreign, err := auth.NewHMACInviteIssuer([]byte("another-secret")).IssueInvite(domain.InviteClaims{RoomID: "room-1", ExpiresAt: time.Now().Add(time.Hour)})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	public := &domain.Room{ID: "room-1", Privacy: domain.RoomPublic}
+	password := &domain.Room{ID: "room-1", Privacy: domain.RoomPassword, PasswordHash: hash}
+	inviteOnly := &domain.Room{ID: "room-1", Privacy: domain.RoomInviteOnly}
+
+	tests := []struct {
+		name    string
+		room    *domain.Room
+		access  domain.RoomAccess
+		granted bool
+	}{
+		{"public room", public, domain.RoomAccess{}, true},
+		{"password room without credentials", password, domain.RoomAccess{}, false},
+		{"password room with the password", password, domain.RoomAccess{Password: "open sesame"}, true},
+		{"password room with a wrong password", password, domain.RoomAccess{Password: "let me in"}, false},
+		{"password room with an invite", password, domain.RoomAccess{Invite: invite("room-1", time.Hour)}, true},
+		{"invite-only room with an invite", inviteOnly, domain.RoomAccess{Invite: invite("room-1", time.Hour)}, true},
+		{"invite-only room with a password", inviteOnly, domain.RoomAccess{Password: "open sesame"}, false},
+		{"invite for another room", inviteOnly, domain.RoomAccess{Invite: invite("room-2", time.Hour)}, false},
+		{"expired invite", inviteOnly, domain.RoomAccess{Invite: invite("room-1", -time.Second)}, false},
+		{"invite signed with another secret", inviteOnly, domain.RoomAccess{Invite: foreign}, false},
+		{"garbage invite", inviteOnly, domain.RoomAccess{Invite: "not-a-token"}, false},
+		{"bad invite, good password", password, domain.RoomAccess{Invite: invite("room-2", time.Hour), Password: "open sesame"}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := env.rooms.checkAccess(tt.room, tt.access)
+			if tt.granted && err != nil {
+				t.Errorf("checkAccess() error = %v, want access granted", err)
+			}
+			if !tt.granted && !errors.Is(err, ErrRoomAccessDenied) {
+				t.Errorf("checkAccess() error = %v, want ErrRoomAccessDenied", err)
+			}
+		})
+	}
+}
+
+func TestInviteOpensTheRoom(t *testing.T) {
+	env := newTestEnv(t)
+	if _, _, err := env.gs.HandleJoinRoom("room-1", env.alice, domain.GameConfig{Privacy: domain.RoomInviteOnly}, domain.RoomAccess{}); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, _, err := env.gs.HandleJoinRoom("room-1", env.bob, domain.GameConfig{}, domain.RoomAccess{}); !errors.Is(err, ErrRoomAccessDenied) {
+		t.Fatalf("joining without an invite error = %v, want ErrRoomAccessDenied", err)
+	}
+
+	invite, err := env.rooms.CreateInvite("room-1", env.alice, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	game, _, err := env.gs.HandleJoinRoom("room-1", env.bob, domain.GameConfig{}, domain.RoomAccess{Invite: invite.Token})
+	if err != nil {
+		t.Fatalf("joining with an invite error = %v", err)
+	}
+	if game.SeatOf(env.bob.ID) != "O" {
+		t.Errorf("bob sits as %q, want O", game.SeatOf(env.bob.ID))
+	}
+
+	// Once seated, bob comes back without the invite.
+	if _, _, err := env.gs.HandleJoinRoom("room-1", env.bob, domain.GameConfig{}, domain.RoomAccess{}); err != nil {
+		t.Errorf("seated player rejoining error = %v", err)
+	}
+}
+
+func TestCreateInvite(t *testing.T) {
+	env := newTestEnv(t)
+	if _, _, err := env.gs.HandleJoinRoom("room-1", env.alice, domain.GameConfig{Privacy: domain.RoomInviteOnly}, domain.RoomAccess{}); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name    string
+		roomID  string
+		player  *domain.Player
+		ttl     time.Duration
+		fails   bool
+		wantErr error // The specific error, if any; nil accepts any when fails.
+	}{
+		{"owner", "room-1", env.alice, time.Minute, false, nil},
+		{"not the owner", "room-1", env.bob, time.Minute, true, ErrNotRoomOwner},
+		{"unknown room", "room-2", env.alice, time.Minute, true, ErrRoomNotFound},
+		{"lifetime too long", "room-1", env.alice, maxInviteTTL + time.Second, true, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			invite, err := env.rooms.CreateInvite(tt.roomID, tt.player, tt.ttl)
+			switch {
+			case tt.fails && (err == nil || (tt.wantErr != nil && !errors.Is(err, tt.wantErr))):
+				t.Errorf("CreateInvite() error = %v, want %v", err, tt.wantErr)
+			case !tt.fails && err != nil:
+				t.Errorf("CreateInvite() error = %v", err)
+			case !tt.fails && (invite.RoomID != tt.roomID || time.Until(invite.ExpiresAt) > tt.ttl):
+				t.Errorf("invite = %s until %s, want %s for %s", invite.RoomID, invite.ExpiresAt, tt.roomID, tt.ttl)
+			}
+		})
+	}
+}
+
+func TestCreateRoomSettings(t *testing.T) {
+	tests := []struct {
+		name   string
+		config domain.GameConfig
+		access domain.RoomAccess
+		valid  bool
+	}{
+		{"defaults", domain.GameConfig{}, domain.RoomAccess{}, true},
+		{"best of 5", domain.GameConfig{BestOf: 5}, domain.RoomAccess{}, true},
+		{"best of 4", domain.GameConfig{BestOf: 4}, domain.RoomAccess{}, false},
+		{"password room", domain.GameConfig{Privacy: domain.RoomPassword}, domain.RoomAccess{Password: "open sesame"}, true},
+		{"password room without a password", domain.GameConfig{Privacy: domain.RoomPassword}, domain.RoomAccess{}, false},
+		{"password too short", domain.GameConfig{Privacy: domain.RoomPassword}, domain.RoomAccess{Password: "abc"}, false},
+		{"unknown privacy", domain.GameConfig{Privacy: "friends"}, domain.RoomAccess{}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			env := newTestEnv(t)
+			_, _, err := env.gs.HandleJoinRoom("room-1", env.alice, tt.config, tt.access)
+			if (err == nil) != tt.valid {
+				t.Errorf("creating the room error = %v, want valid: %v", err, tt.valid)
+			}
+		})
+	}
+}
+
+func TestRefusedJoinClosesWithAFixedReason(t *testing.T) {
+	env := newTestEnv(t)
+	if _, _, err := env.gs.HandleJoinRoom("secret", env.alice, domain.GameConfig{Privacy: domain.RoomInviteOnly}, domain.RoomAccess{}); err != nil {
+		t.Fatal(err)
+	}
+	hub := newTestHub(broadcast.NewMemoryBroadcaster())
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ServeWs(hub, env.gs, w, r, "secret", env.bob, "", domain.GameConfig{}, domain.RoomAccess{})
+	}))
+	defer server.Close()
+
+	conn, _, err := websocket.DefaultDialer.Dial("ws"+strings.TrimPrefix(server.URL, "http"), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+
+	conn.SetReadDeadline(time.Now().Add(time.Second))
+	_, _, err = conn.ReadMessage()
+	var closeErr *websocket.CloseError
+	if !errors.As(err, &closeErr) {
+		t.Fatalf("read error = %v, want a close frame", err)
+	}
+	if closeErr.Code != websocket.ClosePolicyViolation || closeErr.Text != closeReasonAccessDenied {
+		t.Errorf("close = %d %q, want %d %q", closeErr.Code, closeErr.Text, websocket.ClosePolicyViolation, closeReasonAccessDenied)
+	}
+}
@@ -0,0 +1,143 @@
+/*
+ * file: series_services.go
+ * package: services
+ * description:
+ *     Implements best-of-N series: the players of a series room swap symbols after
+ *     every game so the first move alternates, every game state carries the running
+ *     score, and the room is told when a player has won the series.
+ */
+
+package services
+
+import (
+	"encoding/json"
+	"log"
+
+	"github.com/juan10024/tictactoe-test/internal/core/domain"
+	"github.com/juan10024/tictactoe-test/internal/core/protocol"
+)
+
+/*
+ * seriesScore builds the series score of a room for its current game.
+ *
+ * Parameters:
+ *   - room (*domain.Room): The room playing the series.
+ *   - game (*domain.Game): The room's current game.
+ *
+ * Returns:
+ *   - *protocol.SeriesScore: The score, or nil if the room does not play series.
+ */
+func seriesScore(room *domain.Room, game *domain.Game) *protocol.SeriesScore {
+	if !room.IsSeries() {
+		return nil
+	}
+
+	gameNumber := room.GamesPlayed()
+	if game.Status != "finished" {
+		gameNumber++
+	}
+	return &protocol.SeriesScore{
+		BestOf:     room.BestOf,
+		GameNumber: gameNumber,
+		WinsX:      room.WinsOf(game.PlayerXID),
+		WinsO:      room.WinsOf(game.PlayerOID),
+		Draws:      room.Draws,
+		Over:       room.SeriesOver(),
+	}
+}
+
+/*
+ * currentSeries returns the series score of a game's room from the room's actor,
+ * without touching the database when the room is already in memory.
+ *
+ * Parameters:
+ *   - game (*domain.Game): The room's current game.
+ *
+ * Returns:
+ *   - *protocol.SeriesScore: The score, or nil if the room does not play series or cannot be loaded.
+ */
+func (s *GameService) currentSeries(game *domain.Game) *protocol.SeriesScore {
+	var series *protocol.SeriesScore
+	err := s.inRoom(game.RoomID, func(room *roomActor) error {
+		record, err := room.currentRoom(s.roomService)
+		if err != nil {
+			return err
+		}
+		series = seriesScore(record, game)
+		return nil
+	})
+	if err != nil {
+		log.Printf("ERROR: Could not load series of room %s: %v", game.RoomID, err)
+		return nil
+	}
+	return series
+}
+
+/*
+ * seriesResult builds the record of a room's finished series.
+ *
+ * Parameters:
+ *   - room (*domain.Room): The room whose series is over.
+ *
+ * Returns:
+ *   - *domain.SeriesResult: The result to store.
+ */
+func seriesResult(room *domain.Room) *domain.SeriesResult {
+	return &domain.SeriesResult{
+		RoomID:        room.ID,
+		BestOf:        room.BestOf,
+		PlayerOneID:   room.PlayerOneID,
+		PlayerTwoID:   room.PlayerTwoID,
+		PlayerOneWins: room.PlayerOneWins,
+		PlayerTwoWins: room.PlayerTwoWins,
+		Draws:         room.Draws,
+		WinnerID:      room.SeriesWinnerID(),
+	}
+}
+
+/*
+ * swapSeats exchanges the X and O players of a new game, together with their
+ * resume tokens and, in a game against the computer, the bot's symbol.
+ *
+ * Parameters:
+ *   - game (*domain.Game): The game to modify.
+ *
+ * Returns:
+ *   - None.
+ */
+func swapSeats(game *domain.Game) {
+	game.PlayerXID, game.PlayerOID = game.PlayerOID, game.PlayerXID
+	game.PlayerX, game.PlayerO = game.PlayerO, game.PlayerX
+	game.ResumeTokenX, game.ResumeTokenO = game.ResumeTokenO, game.ResumeTokenX
+	if game.BotSymbol != "" {
+		game.BotSymbol = opponentOf(game.BotSymbol)
+	}
+}
+
+/*
+ * announceSeriesOver broadcasts the seriesOver event after the game that decided a series.
+ *
+ * Parameters:
+ *   - hub (*Hub): Reference to the Hub managing rooms and clients.
+ *   - game (*domain.Game): The game that ended the series.
+ *   - series (*protocol.SeriesScore): The final score.
+ *
+ * Returns:
+ *   - None.
+ */
+func announceSeriesOver(hub *Hub, game *domain.Game, series *protocol.SeriesScore) {
+	msg := protocol.SeriesOver{Header: protocol.Header{Type: protocol.TypeSeriesOver}, Series: series}
+	switch {
+	case game.WinnerID != nil && game.PlayerXID != nil && *game.WinnerID == *game.PlayerXID:
+		msg.Winner, msg.WinnerName = "X", game.PlayerX.Name
+	case game.WinnerID != nil && game.PlayerOID != nil && *game.WinnerID == *game.PlayerOID:
+		msg.Winner, msg.WinnerName = "O", game.PlayerO.Name
+	}
+
+	msgBytes, err := json.Marshal(msg)
+	if err != nil {
+		log.Printf("ERROR: Could not marshal series over event: %v", err)
+		return
+	}
+	hub.broadcast(game.RoomID, msgBytes)
+}
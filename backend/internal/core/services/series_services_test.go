@@ -0,0 +1,108 @@
+/*
+ * file: series_services_test.go
+ * package: services
+ * description:
+ *     Tests for best-of-N series: the score shown for each game, the sides swapped
+ *     between games, and the series result stored once a player has won it.
+ */
+
+package services
+
+import (
+	"testing"
+
+	"github.com/juan10024/tictactoe-test/internal/core/domain"
+)
+
+// seriesWant is the part of a series score a test checks.
+type seriesWant struct {
+	gameNumber, winsX, winsO int
+	over                     bool
+}
+
+func TestSeriesScore(t *testing.T) {
+	one, two := uint(1), uint(2)
+	swapped := &domain.Game{PlayerXID: &two, PlayerOID: &one, Status: "in_progress"}
+	finished := &domain.Game{PlayerXID: &one, PlayerOID: &two, Status: "finished"}
+
+	tests := []struct {
+		name string
+		room domain.Room
+		game *domain.Game
+		want *seriesWant
+	}{
+		{"independent games", domain.Room{BestOf: 1}, finished, nil},
+		{"second game, sides swapped", domain.Room{BestOf: 3, PlayerOneWins: 1}, swapped, &seriesWant{gameNumber: 2, winsX: 0, winsO: 1}},
+		{"game that just finished", domain.Room{BestOf: 3, PlayerOneWins: 2, Draws: 1}, finished, &seriesWant{gameNumber: 3, winsX: 2, winsO: 0, over: true}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			room := tt.room
+			room.PlayerOneID, room.PlayerTwoID = &one, &two
+			got := seriesScore(&room, tt.game)
+			if tt.want == nil {
+				if got != nil {
+					t.Errorf("seriesScore() = %+v, want nil", *got)
+				}
+				return
+			}
+			if got == nil || got.BestOf != room.BestOf || got.GameNumber != tt.want.gameNumber ||
+				got.WinsX != tt.want.winsX || got.WinsO != tt.want.winsO || got.Over != tt.want.over {
+				t.Errorf("seriesScore() = %+v, want %+v", got, *tt.want)
+			}
+		})
+	}
+}
+
+func TestSwapSeats(t *testing.T) {
+	one, two := uint(1), uint(2)
+	game := &domain.Game{
+		PlayerXID: &one, PlayerX: domain.Player{Name: "alice"}, ResumeTokenX: "token-alice",
+		PlayerOID: &two, PlayerO: domain.Player{Name: "bot", IsBot: true}, BotSymbol: "O",
+	}
+
+	swapSeats(game)
+	if *game.PlayerXID != two || game.PlayerX.Name != "bot" || *game.PlayerOID != one || game.PlayerO.Name != "alice" {
+		t.Errorf("players = %s (X) and %s (O), want bot and alice", game.PlayerX.Name, game.PlayerO.Name)
+	}
+	if game.ResumeTokenO != "token-alice" || game.ResumeTokenX != "" || game.BotSymbol != "X" {
+		t.Errorf("resume tokens %q / %q and bot symbol %s did not follow the players", game.ResumeTokenX, game.ResumeTokenO, game.BotSymbol)
+	}
+}
+
+func TestSeriesIsPlayedAcrossRematches(t *testing.T) {
+	env := newTestEnv(t)
+	env.startRoom(t, "room-1", domain.GameConfig{BestOf: 3})
+
+	// Alice wins the first game as X; the sides swap, and she wins the second as O.
+	game := env.play(t, "room-1", 0, 3, 1, 4, 2)
+	series := env.gs.currentSeries(game)
+	if series == nil || series.GameNumber != 1 || series.WinsX != 1 || series.Over {
+		t.Fatalf("series after game 1 = %+v, want alice leading 1-0", series)
+	}
+
+	if _, _, err := env.gs.OfferRematch("room-1", env.alice.ID); err != nil {
+		t.Fatal(err)
+	}
+	_, next, err := env.gs.RespondToRematch("room-1", env.bob.ID, true)
+	if err != nil || next == nil {
+		t.Fatalf("accepting the rematch = %v, %v", next, err)
+	}
+	if next.SeatOf(env.alice.ID) != "O" {
+		t.Fatalf("alice plays %s in game 2, want O", next.SeatOf(env.alice.ID))
+	}
+	game = env.play(t, "room-1", 0, 3, 1, 4, 8, 5)
+
+	series = env.gs.currentSeries(game)
+	if series == nil || !series.Over || series.WinsO != 2 {
+		t.Errorf("series after game 2 = %+v, want won 2-0 by alice (O)", series)
+	}
+	results, err := env.store.GetSeriesByRoomID("room-1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(results) != 1 || results[0].WinnerID == nil || *results[0].WinnerID != env.alice.ID || results[0].PlayerOneWins != 2 {
+		t.Errorf("stored series = %+v, want one won 2-0 by alice", results)
+	}
+}
@@ -8,10 +8,18 @@
 package services
 
 import (
+	"errors"
+
 	"github.com/juan10024/tictactoe-test/internal/core/domain"
 	"github.com/juan10024/tictactoe-test/internal/core/ports"
 )
 
+// ErrPlayerNotFound is returned when no player has the requested name.
+var ErrPlayerNotFound = errors.New("player not found")
+
+// DefaultRankingMinGames is the number of finished games a player needs before appearing in the ranking.
+const DefaultRankingMinGames = 5
+
 /*
  * StatsService provides business logic for retrieving and aggregating game statistics.
  *
@@ -39,10 +47,24 @@ func NewStatsService(r ports.StatsRepository) *StatsService {
  * RankingResponse represents the response DTO containing player ranking information.
  *
  * Fields:
- *   - Players ([]domain.Player): A list of top players ordered by number of wins.
+ *   - Players ([]domain.Player): A list of top players ordered by rating.
+ *   - MinGames (int): The minimum number of finished games required to be ranked.
  */
 type RankingResponse struct {
-	Players []domain.Player `json:"players"`
+	Players  []domain.Player `json:"players"`
+	MinGames int             `json:"minGames"`
+}
+
+/*
+ * RatingHistoryResponse represents the response DTO containing a player's rating history.
+ *
+ * Fields:
+ *   - PlayerName (string): The player's name.
+ *   - History ([]domain.RatingHistory): The player's rating after each rated game, oldest first.
+ */
+type RatingHistoryResponse struct {
+	PlayerName string                 `json:"playerName"`
+	History    []domain.RatingHistory `json:"history"`
 }
 
 /*
@@ -63,14 +85,16 @@ type GeneralStatsResponse struct {
  * Fields:
  *   - RoomID (string): The room identifier.
  *   - Games ([]domain.Game): A list of games played in the room.
+ *   - Series ([]domain.SeriesResult): The best-of-N series finished in the room, newest first.
  */
 type GameHistoryResponse struct {
-	RoomID string        `json:"roomId"`
-	Games  []domain.Game `json:"games"`
+	RoomID string                `json:"roomId"`
+	Games  []domain.Game         `json:"games"`
+	Series []domain.SeriesResult `json:"series"`
 }
 
 /*
- * GetGameHistory retrieves the game history for a specific room.
+ * GetGameHistory retrieves the games and series history for a specific room.
  *
  * Parameters:
  *   - roomID (string): The unique identifier of the room.
@@ -85,28 +109,57 @@ func (s *StatsService) GetGameHistory(roomID string) (*GameHistoryResponse, erro
 		return nil, err
 	}
 
+	series, err := s.repo.GetSeriesByRoomID(roomID)
+	if err != nil {
+		return nil, err
+	}
+
 	return &GameHistoryResponse{
 		RoomID: roomID,
 		Games:  games,
+		Series: series,
 	}, nil
 }
 
 /*
- * GetRanking retrieves the top players based on their win count.
+ * GetRanking retrieves the top players based on their rating.
  *
  * Parameters:
- *   - None.
+ *   - minGames (int): The minimum number of finished games required to be ranked.
  *
  * Returns:
  *   - *RankingResponse: DTO containing the top 10 players.
  *   - error: An error if retrieving the data fails.
  */
-func (s *StatsService) GetRanking() (*RankingResponse, error) {
-	players, err := s.repo.GetTopPlayers(10)
+func (s *StatsService) GetRanking(minGames int) (*RankingResponse, error) {
+	players, err := s.repo.GetTopPlayers(10, minGames)
 	if err != nil {
 		return nil, err
 	}
-	return &RankingResponse{Players: players}, nil
+	return &RankingResponse{Players: players, MinGames: minGames}, nil
+}
+
+/*
+ * GetRatingHistory retrieves the rating history of a specific player.
+ *
+ * Parameters:
+ *   - playerName (string): The name of the player.
+ *
+ * Returns:
+ *   - *RatingHistoryResponse: DTO containing the player's rating after each rated game.
+ *   - error: ErrPlayerNotFound, or an error if retrieving the data fails.
+ */
+func (s *StatsService) GetRatingHistory(playerName string) (*RatingHistoryResponse, error) {
+	player, err := s.getPlayer(playerName)
+	if err != nil {
+		return nil, err
+	}
+
+	history, err := s.repo.GetRatingHistory(player.ID)
+	if err != nil {
+		return nil, err
+	}
+	return &RatingHistoryResponse{PlayerName: player.Name, History: history}, nil
 }
 
 /*
@@ -142,10 +195,27 @@ func (s *StatsService) GetGeneralStats() (*GeneralStatsResponse, error) {
  *
  * Returns:
  *   - *domain.Player: DTO containing the player's statistics.
- *   - error: An error if retrieving the data fails.
+ *   - error: ErrPlayerNotFound, or an error if retrieving the data fails.
  */
 func (s *StatsService) GetPlayerStats(playerName string) (*domain.Player, error) {
+	return s.getPlayer(playerName)
+}
+
+/*
+ * getPlayer loads a player by name, translating a missing record into ErrPlayerNotFound.
+ *
+ * Parameters:
+ *   - playerName (string): The name of the player.
+ *
+ * Returns:
+ *   - *domain.Player: The player.
+ *   - error: ErrPlayerNotFound, or the repository error.
+ */
+func (s *StatsService) getPlayer(playerName string) (*domain.Player, error) {
 	player, err := s.repo.GetPlayerByName(playerName)
+	if errors.Is(err, ports.ErrNotFound) {
+		return nil, ErrPlayerNotFound
+	}
 	if err != nil {
 		return nil, err
 	}
@@ -3,20 +3,28 @@
  * package: services
  * description:
  *     Defines the Client struct representing a connected WebSocket user, and
- *     provides the readPump and writePump methods for handling incoming and outgoing messages.
+ *     provides the readPump and writePump methods for handling incoming and outgoing
+ *     messages. Messages follow the protocol package.
  */
 
 package services
 
 import (
 	"encoding/json"
+	"errors"
+	"fmt"
 	"log"
+	"sync/atomic"
 	"time"
 
 	"github.com/gorilla/websocket"
-	"github.com/juan10024/tictactoe-test/internal/core/domain"
+
+	"github.com/juan10024/tictactoe-test/internal/core/protocol"
 )
 
+// errObserver is returned to observers who try to play.
+var errObserver = errors.New("Observers cannot make moves")
+
 // Client represents a single connected WebSocket client.
 type Client struct {
 	hub        *Hub            // Hub instance this client belongs to.
@@ -26,6 +34,8 @@ type Client struct {
 	playerID   uint            // Player ID in the game.
 	playerName string          // Player's display name.
 	isObserver bool            // Whether this client is an observer.
+	version    int             // Negotiated protocol version.
+	seq        atomic.Uint64   // Sequence number of the last message written; only advanced by writePump.
 }
 
 /*
@@ -42,6 +52,9 @@ func (c *Client) readPump(gs *GameService) {
 		log.Printf("Client readPump closing for player %s in room %s", c.playerName, c.room)
 		c.hub.unregister <- c
 		c.conn.Close()
+		if !c.isObserver {
+			scheduleForfeit(c.hub, gs, c.room, c.playerID)
+		}
 	}()
 
 	c.conn.SetReadLimit(maxMessageSize)
@@ -60,144 +73,187 @@ func (c *Client) readPump(gs *GameService) {
 			break
 		}
 
-		var msg struct {
-			Type    string `json:"type"`
-			Payload struct {
-				Position int `json:"position"`
-			} `json:"payload"`
+		req, err := protocol.DecodeClientMessage(message, c.version)
+		if err != nil {
+			c.fail(req, err)
+			continue
 		}
+		c.handle(gs, req)
+	}
+}
 
-		if err := json.Unmarshal(message, &msg); err == nil {
-			switch msg.Type {
-			case "move":
-				if c.isObserver {
-					errorMsg := map[string]interface{}{
-						"type":    "error",
-						"message": "Observers cannot make moves",
-					}
-					errorBytes, _ := json.Marshal(errorMsg)
-					c.send <- errorBytes
-					continue
-				}
-
-				_, err := gs.MakeMove(c.room, c.playerID, msg.Payload.Position)
-				if err != nil {
-					log.Printf("ERROR: Invalid move by player %d in room %s: %v", c.playerID, c.room, err)
-					errorMsg := map[string]interface{}{
-						"type":    "error",
-						"message": err.Error(),
-					}
-					errorBytes, _ := json.Marshal(errorMsg)
-					select {
-					case c.send <- errorBytes:
-					default:
-						log.Printf("WARN: Could not send error message to client in room %s", c.room)
-					}
-				} else {
-					broadcastGameState(c.hub, gs, c.room)
-				}
-
-			case "reset":
-				if c.isObserver {
-					continue
-				}
-
-				// Obtener todos los juegos terminados en la sala, ordenados por created_at DESC
-				finishedGames, err := gs.repo.GetFinishedGamesByRoomID(c.room)
-				if err != nil || len(finishedGames) == 0 {
-					log.Printf("WARN: Cannot reset game in room %s: no finished games found", c.room)
-					continue
-				}
-
-				// Usar el último juego terminado como plantilla
-				latest := finishedGames[0]
-
-				newGame := &domain.Game{
-					RoomID:      latest.RoomID,
-					PlayerXID:   latest.PlayerXID,
-					PlayerX:     latest.PlayerX,
-					PlayerOID:   latest.PlayerOID,
-					PlayerO:     latest.PlayerO,
-					Status:      "in_progress",
-					Board:       "         ",
-					CurrentTurn: "X",
-					WinnerID:    nil,
-				}
-
-				if err := gs.repo.Create(newGame); err != nil {
-					log.Printf("ERROR: Failed to create new game in room %s: %v", c.room, err)
-				} else {
-					broadcastGameState(c.hub, gs, c.room)
-				}
-
-			case "confirmGameStart":
-				log.Printf("Game start confirmed by %s", c.playerName)
-
-			case "playAgainRequest":
-				if !c.isObserver {
-					playAgainMsg := map[string]interface{}{
-						"type":             "playAgainRequest",
-						"requestingPlayer": c.playerName,
-					}
-					playAgainBytes, _ := json.Marshal(playAgainMsg)
-
-					c.hub.mu.RLock()
-					if room, ok := c.hub.rooms[c.room]; ok {
-						for otherClient := range room {
-							if otherClient != c && !otherClient.isObserver {
-								select {
-								case otherClient.send <- playAgainBytes:
-								default:
-									log.Printf("WARN: Could not send playAgainRequest to client %s in room %s", otherClient.playerName, c.room)
-								}
-							}
-						}
-					}
-					c.hub.mu.RUnlock()
-				}
-
-			case "play_again_menu_request":
-				if !c.isObserver {
-					playAgainMenuMsg := map[string]interface{}{
-						"type":             "play_again_menu_request",
-						"requestingPlayer": c.playerName,
-					}
-					playAgainMenuBytes, _ := json.Marshal(playAgainMenuMsg)
-
-					c.hub.mu.RLock()
-					if room, ok := c.hub.rooms[c.room]; ok {
-						for otherClient := range room {
-							if otherClient != c && !otherClient.isObserver {
-								select {
-								case otherClient.send <- playAgainMenuBytes:
-								default:
-									log.Printf("WARN: Could not send play_again_menu_request to client %s in room %s", otherClient.playerName, c.room)
-								}
-							}
-						}
-					}
-					c.hub.mu.RUnlock()
-				}
-			}
-		} else {
-			var position int
-			if err := json.Unmarshal(message, &position); err == nil {
-				if c.isObserver {
-					continue
-				}
-				_, err := gs.MakeMove(c.room, c.playerID, position)
-				if err != nil {
-					log.Printf("ERROR: Invalid move by player %d in room %s: %v", c.playerID, c.room, err)
-				} else {
-					broadcastGameState(c.hub, gs, c.room)
-				}
-			}
+/*
+ * handle executes a decoded client message.
+ *
+ * Parameters:
+ *   - gs (*GameService): Service used to handle game state updates and moves.
+ *   - req (protocol.ClientMessage): The message.
+ *
+ * Returns:
+ *   - None.
+ */
+func (c *Client) handle(gs *GameService, req protocol.ClientMessage) {
+	switch req.Type {
+	case protocol.TypeMove:
+		if c.isObserver {
+			c.fail(req, errObserver)
+			return
+		}
+		move, err := req.Move()
+		if err != nil {
+			c.fail(req, err)
+			return
 		}
+
+		game, err := gs.MakeMove(c.room, c.playerID, move.Position)
+		if errors.Is(err, ErrTimeUp) {
+			c.fail(req, err)
+			announceGameOver(c.hub, gs, game)
+			return
+		}
+		if err != nil {
+			log.Printf("ERROR: Invalid move by player %d in room %s: %v", c.playerID, c.room, err)
+			c.fail(req, err)
+			return
+		}
+		c.ack(req)
+		broadcastMove(c.hub, gs, game, move.Position)
+		if game.IsBotTurn() {
+			scheduleBotMove(c.hub, gs, c.room)
+		}
+
+	case protocol.TypeConfirmGameStart:
+		if _, err := req.ConfirmGameStart(); err != nil {
+			c.fail(req, err)
+			return
+		}
+		log.Printf("Game start confirmed by %s", c.playerName)
+		c.ack(req)
+
+	case protocol.TypeResync:
+		resync, err := req.Resync()
+		if err != nil {
+			c.fail(req, err)
+			return
+		}
+		// Every message written so far was built from a commit older than the game read
+		// next, so the snapshot covers them all and is stamped with the last one's seq.
+		sent := c.seq.Load()
+		if resync.LastSeq > sent {
+			c.fail(req, fmt.Errorf("lastSeq %d is ahead of the last message sent, %d", resync.LastSeq, sent))
+			return
+		}
+		game, err := gs.currentGame(c.room)
+		if err != nil {
+			c.fail(req, err)
+			return
+		}
+		log.Printf("INFO: Resyncing %s in room %s after seq %d, snapshot as of seq %d", c.playerName, c.room, resync.LastSeq, sent)
+		state := clientSnapshot(gs, game, c)
+		state.RequestID = req.RequestID
+		state.AsOfSeq = sent
+		c.reply(state)
+
+	case protocol.TypeRematchOffer, protocol.TypeRematchAccept, protocol.TypeRematchDecline:
+		if c.isObserver {
+			c.fail(req, ErrRematchNotAllowed)
+			return
+		}
+		handleRematch(c, gs, req)
+	}
+}
+
+/*
+ * reply queues a message for this client only. If the client's buffer is full the
+ * connection is closed, as Hub.deliver does: a dropped message would never get a
+ * sequence number, so the client could not notice the gap.
+ *
+ * Parameters:
+ *   - msg (interface{}): A server message from the protocol package.
+ *
+ * Returns:
+ *   - None.
+ */
+func (c *Client) reply(msg interface{}) {
+	msgBytes, err := json.Marshal(msg)
+	if err != nil {
+		log.Printf("ERROR: Could not marshal message for client in room %s: %v", c.room, err)
+		return
+	}
+	select {
+	case c.send <- msgBytes:
+	default:
+		// Closing the connection ends its read pump, which unregisters the client.
+		log.Printf("WARN: Client send buffer full. Closing connection for client in room %s.", c.room)
+		c.conn.Close()
+	}
+}
+
+/*
+ * ack confirms a client message that carried a request ID.
+ *
+ * Parameters:
+ *   - req (protocol.ClientMessage): The applied message.
+ *
+ * Returns:
+ *   - None. Messages without a request ID are not acknowledged.
+ */
+func (c *Client) ack(req protocol.ClientMessage) {
+	if req.RequestID == "" {
+		return
+	}
+	c.reply(protocol.Ack{
+		Header: protocol.Header{Type: protocol.TypeAck, RequestID: req.RequestID},
+		For:    req.Type,
+	})
+}
+
+/*
+ * fail tells the client why its message was refused.
+ *
+ * Parameters:
+ *   - req (protocol.ClientMessage): The refused message; its request ID is echoed.
+ *   - err (error): The reason.
+ *
+ * Returns:
+ *   - None.
+ */
+func (c *Client) fail(req protocol.ClientMessage, err error) {
+	c.reply(protocol.Error{
+		Header:  protocol.Header{Type: protocol.TypeError, RequestID: req.RequestID},
+		Code:    errorCode(err),
+		Message: err.Error(),
+	})
+}
+
+/*
+ * errorCode classifies the error refusing a client message.
+ *
+ * Parameters:
+ *   - err (error): The reason the message was refused.
+ *
+ * Returns:
+ *   - string: One of the protocol Code* constants.
+ */
+func errorCode(err error) string {
+	switch {
+	case errors.Is(err, protocol.ErrMalformedMessage):
+		return protocol.CodeMalformed
+	case errors.Is(err, protocol.ErrUnknownType):
+		return protocol.CodeUnknownType
+	case errors.Is(err, errObserver), errors.Is(err, ErrRematchNotAllowed):
+		return protocol.CodeForbidden
+	case errors.Is(err, ErrTimeUp):
+		return protocol.CodeTimeUp
+	case errors.Is(err, ErrGameConflict):
+		return protocol.CodeConflict
 	}
+	return protocol.CodeRejected
 }
 
 /*
- * writePump sends messages from the hub to the WebSocket client.
+ * writePump sends messages from the hub to the WebSocket client, stamping each one
+ * with the connection's next sequence number.
  *
  * Parameters:
  *   - None.
@@ -228,7 +284,7 @@ func (c *Client) writePump() {
 				log.Printf("Error in writePump for player %s: %v", c.playerName, err)
 				return
 			}
-			w.Write(message)
+			w.Write(protocol.Stamp(message, c.seq.Add(1)))
 
 			if err := w.Close(); err != nil {
 				log.Printf("Error closing writer for player %s: %v", c.playerName, err)
@@ -0,0 +1,90 @@
+/*
+ * file: websocket_client_services_test.go
+ * package: services
+ * description:
+ *     Tests for the messages a connected client sends: a resync is answered with a
+ *     snapshot stamped with the seq it covers, and a lastSeq the server never sent is refused.
+ */
+
+package services
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+
+	"github.com/juan10024/tictactoe-test/internal/core/domain"
+	"github.com/juan10024/tictactoe-test/internal/core/protocol"
+	"github.com/juan10024/tictactoe-test/internal/infra/broadcast"
+)
+
+// answerTo reads the connection until the message carrying requestID, and returns it decoded in both shapes.
+func answerTo(t *testing.T, conn *websocket.Conn, requestID string) (protocol.GameState, protocol.Error) {
+	t.Helper()
+	conn.SetReadDeadline(time.Now().Add(time.Second))
+	for {
+		_, message, err := conn.ReadMessage()
+		if err != nil {
+			t.Fatalf("reading the answer to %s: %v", requestID, err)
+		}
+		var state protocol.GameState
+		var refusal protocol.Error
+		if err := json.Unmarshal(message, &state); err != nil {
+			t.Fatal(err)
+		}
+		if state.RequestID == requestID {
+			json.Unmarshal(message, &refusal)
+			return state, refusal
+		}
+	}
+}
+
+func TestResync(t *testing.T) {
+	env := newTestEnv(t)
+	hub := newTestHub(broadcast.NewMemoryBroadcaster())
+	server := roomServer(t, env, hub)
+	env.startRoom(t, "room-1", domain.GameConfig{})
+	env.play(t, "room-1", 4)
+
+	// The welcome and the join snapshot are seq 1 and 2.
+	conn, joined := dialRoom(t, server, "alice", "")
+	if joined.Seq != 2 {
+		t.Fatalf("join snapshot seq = %d, want 2", joined.Seq)
+	}
+
+	tests := []struct {
+		name     string
+		lastSeq  uint64
+		wantType string
+		wantCode string
+	}{
+		{"after a gap", 1, protocol.TypeGameStateUpdate, ""},
+		{"up to date", 2, protocol.TypeGameStateUpdate, ""},
+		{"ahead of the server", 99, protocol.TypeError, protocol.CodeRejected},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			payload, _ := json.Marshal(protocol.ResyncPayload{LastSeq: tt.lastSeq})
+			req := protocol.ClientMessage{Type: protocol.TypeResync, RequestID: tt.name, Payload: payload}
+			if err := conn.WriteJSON(req); err != nil {
+				t.Fatal(err)
+			}
+
+			state, refusal := answerTo(t, conn, tt.name)
+			if state.Type != tt.wantType || refusal.Code != tt.wantCode {
+				t.Fatalf("answer = %s %q, want %s %q", state.Type, refusal.Code, tt.wantType, tt.wantCode)
+			}
+			if state.Type != protocol.TypeGameStateUpdate {
+				return
+			}
+			if state.AsOfSeq < joined.Seq || state.AsOfSeq >= state.Seq {
+				t.Errorf("snapshot seq %d as of seq %d, want as of a seq from %d up to before its own", state.Seq, state.AsOfSeq, joined.Seq)
+			}
+			if state.GameState == nil || state.GameState.Board != "    X    " {
+				t.Errorf("snapshot board = %v, want the move played", state.GameState)
+			}
+		})
+	}
+}
@@ -9,26 +9,44 @@ package services
 
 import (
 	"encoding/json"
+	"errors"
 	"log"
 	"net/http"
-	"strings"
+	"time"
+
+	"github.com/gorilla/websocket"
 
 	"github.com/juan10024/tictactoe-test/internal/core/domain"
+	"github.com/juan10024/tictactoe-test/internal/core/protocol"
 )
 
-// GameStateBroadcast represents the payload sent to clients with game state updates.
-type GameStateBroadcast struct {
-	Type      string       `json:"type"`
-	GameState *domain.Game `json:"gameState"`
-	Players   struct {
-		X *domain.Player `json:"X"`
-		O *domain.Player `json:"O"`
-	} `json:"players"`
-	IsObserver bool `json:"isObserver"`
+// botMoveDelay gives players a moment to see the previous move before the bot replies.
+const botMoveDelay = 600 * time.Millisecond
+
+// Close reasons of a refused join. A close reason is capped at 123 bytes, so the error
+// itself is only logged.
+const (
+	closeReasonAccessDenied = "room access denied"
+	closeReasonJoinFailed   = "could not join the room"
+)
+
+// roomUpgrader upgrades game room connections and negotiates their protocol version.
+var roomUpgrader = websocket.Upgrader{
+	ReadBufferSize:  1024,
+	WriteBufferSize: 1024,
+	CheckOrigin:     func(r *http.Request) bool { return true },
+	Subprotocols:    protocol.Subprotocols(),
 }
 
 /*
- * ServeWs handles new WebSocket connections and initializes the client.
+ * ServeWs handles new WebSocket connections and initializes the client. The protocol
+ * version is negotiated during the upgrade and confirmed by the welcome message, which
+ * is followed by a snapshot of the room's game; moves then arrive as deltas.
+ *
+ * The welcome is queued before the client is registered, so it is always the first
+ * message. The snapshot is read from the room's actor after registering: a broadcast
+ * queued in between is older than the snapshot, which supersedes it, and nothing that
+ * happens after the read can be missed.
  *
  * Parameters:
  *   - hub (*Hub): Reference to the Hub managing rooms and clients.
@@ -36,46 +54,46 @@ type GameStateBroadcast struct {
  *   - w (http.ResponseWriter): HTTP response writer.
  *   - r (*http.Request): Incoming HTTP request.
  *   - roomID (string): ID of the room to join.
- *   - playerName (string): Name of the player joining.
+ *   - player (*domain.Player): The authenticated player joining.
+ *   - resumeToken (string): The seat's resume token when reconnecting to a game in progress, or "".
+ *   - config (domain.GameConfig): Options applied if the room has to be created.
+ *   - access (domain.RoomAccess): The password or invite for a private room.
  *
  * Returns:
- *   - None.
+ *   - None. A refused join closes the connection with a close code saying why.
  */
-func ServeWs(hub *Hub, gameService *GameService, w http.ResponseWriter, r *http.Request, roomID, playerName string) {
-	conn, err := upgrader.Upgrade(w, r, nil)
+func ServeWs(hub *Hub, gameService *GameService, w http.ResponseWriter, r *http.Request, roomID string, player *domain.Player, resumeToken string, config domain.GameConfig, access domain.RoomAccess) {
+	conn, err := roomUpgrader.Upgrade(w, r, nil)
 	if err != nil {
 		log.Println("WebSocket upgrade error:", err)
 		return
 	}
+	version := protocol.VersionOf(conn.Subprotocol())
 
-	game, player, err := gameService.HandleJoinRoom(roomID, playerName)
-
-	if err != nil && strings.Contains(err.Error(), "already exists in the room") {
-		// Intentar obtener el juego existente para este jugador
-		existingGame, err2 := gameService.repo.GetByRoomID(roomID)
-		if err2 != nil {
-			log.Printf("ERROR: Could not get existing game: %v", err2)
-			conn.Close()
-			return
-		}
-
-		existingPlayer, err3 := gameService.repo.GetOrCreatePlayerByName(playerName)
-		if err3 != nil {
-			log.Printf("ERROR: Could not get player: %v", err3)
-			conn.Close()
-			return
-		}
-
-		game = existingGame
-		player = existingPlayer
-	} else if err != nil {
+	game, player, err := gameService.HandleJoinRoom(roomID, player, config, access)
+	if err != nil {
 		log.Printf("ERROR: Could not handle join room: %v", err)
+		code, reason := websocket.CloseInternalServerErr, closeReasonJoinFailed
+		if errors.Is(err, ErrRoomAccessDenied) {
+			code, reason = websocket.ClosePolicyViolation, closeReasonAccessDenied
+		}
+		conn.WriteControl(websocket.CloseMessage, websocket.FormatCloseMessage(code, reason), time.Now().Add(writeWait))
 		conn.Close()
 		return
 	}
 
+	// A seated player who returns within their reconnection grace period keeps their seat.
+	reconnecting := cancelForfeit(hub, roomID, player.ID)
+
+	// A player presenting their seat's resume token reclaims it even if their previous
+	// connection has not been noticed as closed yet; that stale connection is dropped.
+	if canResume(game, player.ID, resumeToken) {
+		hub.evictPlayer(roomID, player.ID)
+		reconnecting = true
+	}
+
 	isObserver := false
-	if game.Status == "in_progress" ||
+	if (game.Status == "in_progress" && !reconnecting) ||
 		(game.PlayerXID != nil && *game.PlayerXID != player.ID &&
 			game.PlayerOID != nil && *game.PlayerOID != player.ID) {
 		isObserver = true
@@ -89,41 +107,32 @@ func ServeWs(hub *Hub, gameService *GameService, w http.ResponseWriter, r *http.
 		playerID:   player.ID,
 		playerName: player.Name,
 		isObserver: isObserver,
+		version:    version,
 	}
-	hub.register <- client
-
-	var playerX, playerO *domain.Player
-	if game.PlayerXID != nil {
-		playerX, _ = gameService.GetPlayerByID(*game.PlayerXID)
-	}
-	if game.PlayerOID != nil {
-		playerO, _ = gameService.GetPlayerByID(*game.PlayerOID)
-	}
+	client.reply(protocol.Welcome{
+		Header:   protocol.Header{Type: protocol.TypeWelcome},
+		Version:  version,
+		Versions: []int{protocol.Version2, protocol.Version1},
+	})
 
-	broadcastMsg := GameStateBroadcast{
-		Type:      "gameStateUpdate",
-		GameState: game,
-		Players: struct {
-			X *domain.Player `json:"X"`
-			O *domain.Player `json:"O"`
-		}{X: playerX, O: playerO},
-		IsObserver: isObserver,
-	}
+	hub.register <- client
 
-	msgBytes, err := json.Marshal(broadcastMsg)
-	if err != nil {
-		log.Printf("ERROR: Could not marshal game state for new client: %v", err)
+	if current, err := gameService.currentGame(roomID); err == nil {
+		game = current
 	} else {
-		client.send <- msgBytes
+		log.Printf("WARN: Could not reload game of room %s after join: %v", roomID, err)
 	}
+	client.reply(clientSnapshot(gameService, game, client))
 
 	if !isObserver && game.Status == "waiting" && game.PlayerXID != nil && game.PlayerOID != nil {
-		game.Status = "in_progress"
-		game.CurrentTurn = "X"
-		if err := gameService.repo.Update(game); err != nil {
+		started, err := gameService.StartGame(roomID)
+		if err != nil {
 			log.Printf("ERROR: Could not start game in room %s: %v", roomID, err)
-		} else {
+		} else if started != nil {
 			broadcastGameState(hub, gameService, roomID)
+			if started.IsBotTurn() {
+				scheduleBotMove(hub, gameService, roomID)
+			}
 		}
 	}
 
@@ -132,7 +141,8 @@ func ServeWs(hub *Hub, gameService *GameService, w http.ResponseWriter, r *http.
 }
 
 /*
- * broadcastGameState retrieves the current game state and sends it to all clients in the room.
+ * broadcastGameState sends the room's current game state, held by its actor, to all clients
+ * in the room, followed by a seriesOver event if the game decided the room's series.
  *
  * Parameters:
  *   - hub (*Hub): Reference to the Hub managing rooms and clients.
@@ -143,34 +153,246 @@ func ServeWs(hub *Hub, gameService *GameService, w http.ResponseWriter, r *http.
  *   - None.
  */
 func broadcastGameState(hub *Hub, gs *GameService, roomID string) {
-	game, err := gs.repo.GetByRoomID(roomID)
+	game, err := gs.currentGame(roomID)
 	if err != nil {
 		log.Printf("ERROR: Could not get game state for room %s: %v", roomID, err)
 		return
 	}
 
-	var playerX, playerO *domain.Player
-	if game.PlayerXID != nil {
-		playerX, _ = gs.GetPlayerByID(*game.PlayerXID)
+	broadcastMsg := gameStateMessage(gs, game)
+	msgBytes, err := json.Marshal(broadcastMsg)
+	if err != nil {
+		log.Printf("ERROR: Could not marshal game state: %v", err)
+		return
 	}
-	if game.PlayerOID != nil {
-		playerO, _ = gs.GetPlayerByID(*game.PlayerOID)
+
+	hub.broadcast(roomID, msgBytes)
+	if game.Status == "finished" && broadcastMsg.Series != nil && broadcastMsg.Series.Over {
+		announceSeriesOver(hub, game, broadcastMsg.Series)
 	}
+	syncTurnTimer(hub, gs, game)
+}
 
-	broadcastMsg := GameStateBroadcast{
-		Type:      "gameStateUpdate",
-		GameState: game,
-		Players: struct {
-			X *domain.Player `json:"X"`
-			O *domain.Player `json:"O"`
-		}{X: playerX, O: playerO},
+/*
+ * gameStateMessage builds the gameStateUpdate message describing a room's game.
+ *
+ * Parameters:
+ *   - gs (*GameService): Service used to load the room's series score.
+ *   - game (*domain.Game): The room's game.
+ *
+ * Returns:
+ *   - protocol.GameState: The message, as broadcast to the whole room.
+ */
+func gameStateMessage(gs *GameService, game *domain.Game) protocol.GameState {
+	playerX, playerO := seatedPlayers(game)
+	return protocol.GameState{
+		Header:      protocol.Header{Type: protocol.TypeGameStateUpdate},
+		GameState:   protocol.NewGame(game),
+		Players:     protocol.Players{X: protocol.NewPlayer(playerX), O: protocol.NewPlayer(playerO)},
+		ActiveBoard: game.ActiveBoard,
+		Clock:       clockSnapshot(game, time.Now()),
+		Series:      gs.currentSeries(game),
 	}
+}
 
-	msgBytes, err := json.Marshal(broadcastMsg)
+/*
+ * clientSnapshot builds the gameStateUpdate snapshot sent privately to one client,
+ * which tells a seated player their seat and resume token.
+ *
+ * Parameters:
+ *   - gs (*GameService): Service used to load the room's series score.
+ *   - game (*domain.Game): The room's game.
+ *   - client (*Client): The client the snapshot is for.
+ *
+ * Returns:
+ *   - protocol.GameState: The message.
+ */
+func clientSnapshot(gs *GameService, game *domain.Game, client *Client) protocol.GameState {
+	state := gameStateMessage(gs, game)
+	state.IsObserver = client.isObserver
+	if !client.isObserver {
+		state.Seat = game.SeatOf(client.playerID)
+		state.ResumeToken = game.ResumeTokenOf(client.playerID)
+	}
+	return state
+}
+
+/*
+ * broadcastMove tells the room about a move: version 2 clients get a moveApplied delta
+ * and version 1 clients the whole game. A move that ends the game is followed by a
+ * snapshot for everybody, since it also changes the players' stats and series score.
+ *
+ * Parameters:
+ *   - hub (*Hub): Reference to the Hub managing rooms and clients.
+ *   - gs (*GameService): Service to retrieve game and player data.
+ *   - game (*domain.Game): The game right after the move.
+ *   - position (int): The position played.
+ *
+ * Returns:
+ *   - None.
+ */
+func broadcastMove(hub *Hub, gs *GameService, game *domain.Game, position int) {
+	delta, err := json.Marshal(moveAppliedMessage(game, position))
+	if err != nil {
+		log.Printf("ERROR: Could not marshal move: %v", err)
+		return
+	}
+	hub.broadcastVersion(game.RoomID, protocol.Version2, delta)
+
+	if game.Status == "finished" {
+		broadcastGameState(hub, gs, game.RoomID)
+		return
+	}
+
+	snapshot, err := json.Marshal(gameStateMessage(gs, game))
 	if err != nil {
 		log.Printf("ERROR: Could not marshal game state: %v", err)
 		return
 	}
+	hub.broadcastVersion(game.RoomID, protocol.Version1, snapshot)
+	syncTurnTimer(hub, gs, game)
+}
+
+/*
+ * moveAppliedMessage builds the moveApplied delta of a move.
+ *
+ * Parameters:
+ *   - game (*domain.Game): The game right after the move.
+ *   - position (int): The position played.
+ *
+ * Returns:
+ *   - protocol.MoveApplied: The message.
+ */
+func moveAppliedMessage(game *domain.Game, position int) protocol.MoveApplied {
+	msg := protocol.MoveApplied{
+		Header:          protocol.Header{Type: protocol.TypeMoveApplied},
+		GameID:          game.ID,
+		MoveNumber:      game.MovesPlayed(),
+		Position:        position,
+		Symbol:          game.Board[position : position+1],
+		ActiveBoard:     game.ActiveBoard,
+		SubBoardWinners: game.SubBoardWinners,
+		Clock:           clockSnapshot(game, time.Now()),
+	}
+	if game.Status == "finished" {
+		msg.Outcome = &protocol.Outcome{
+			Status:   game.Status,
+			Reason:   game.EndReason,
+			Winner:   winnerSymbol(game),
+			WinnerID: game.WinnerID,
+		}
+	} else {
+		msg.NextTurn = game.CurrentTurn
+	}
+	return msg
+}
+
+/*
+ * winnerSymbol returns the symbol of a finished game's winner.
+ *
+ * Parameters:
+ *   - game (*domain.Game): The game to inspect.
+ *
+ * Returns:
+ *   - string: "X" or "O", or "" if the game has no winner.
+ */
+func winnerSymbol(game *domain.Game) string {
+	if game.WinnerID == nil {
+		return ""
+	}
+	if game.PlayerOID != nil && *game.WinnerID == *game.PlayerOID {
+		return "O"
+	}
+	return "X"
+}
+
+/*
+ * syncTurnTimer arms the room's server-side timer for the current turn's deadline,
+ * or stops it when the game has no running clock.
+ *
+ * Parameters:
+ *   - hub (*Hub): Reference to the Hub holding the room timers.
+ *   - gs (*GameService): Service used to end the game on timeout.
+ *   - game (*domain.Game): The latest game state of the room.
+ *
+ * Returns:
+ *   - None.
+ */
+func syncTurnTimer(hub *Hub, gs *GameService, game *domain.Game) {
+	deadline, ok := turnDeadline(game)
+	if !ok {
+		hub.stopTimer(game.RoomID)
+		return
+	}
 
-	hub.broadcast(roomID, msgBytes)
+	roomID := game.RoomID
+	hub.scheduleTimer(roomID, time.Until(deadline), func() {
+		finished, err := gs.TimeoutGame(roomID)
+		if err != nil {
+			log.Printf("ERROR: Could not end game on time in room %s: %v", roomID, err)
+			return
+		}
+		if finished != nil {
+			announceGameOver(hub, gs, finished)
+		}
+	})
+}
+
+/*
+ * announceGameOver broadcasts the final game state followed by a gameOver event
+ * explaining why the game ended.
+ *
+ * Parameters:
+ *   - hub (*Hub): Reference to the Hub managing rooms and clients.
+ *   - gs (*GameService): Service to retrieve game and player data.
+ *   - game (*domain.Game): The finished game.
+ *
+ * Returns:
+ *   - None.
+ */
+func announceGameOver(hub *Hub, gs *GameService, game *domain.Game) {
+	broadcastGameState(hub, gs, game.RoomID)
+
+	msgBytes, err := json.Marshal(protocol.GameOver{
+		Header:    protocol.Header{Type: protocol.TypeGameOver},
+		Reason:    game.EndReason,
+		Winner:    winnerSymbol(game),
+		GameState: protocol.NewGame(game),
+	})
+	if err != nil {
+		log.Printf("ERROR: Could not marshal game over event: %v", err)
+		return
+	}
+	hub.broadcast(game.RoomID, msgBytes)
+}
+
+/*
+ * scheduleBotMove lets the computer opponent reply after a short delay and
+ * broadcasts its move to the room like any other player's move.
+ *
+ * Parameters:
+ *   - hub (*Hub): Reference to the Hub managing rooms and clients.
+ *   - gs (*GameService): Service used to play the bot's turn.
+ *   - roomID (string): ID of the room the bot is playing in.
+ *
+ * Returns:
+ *   - None.
+ */
+func scheduleBotMove(hub *Hub, gs *GameService, roomID string) {
+	go func() {
+		time.Sleep(botMoveDelay)
+
+		game, position, err := gs.PlayBotTurn(roomID)
+		if errors.Is(err, ErrTimeUp) {
+			announceGameOver(hub, gs, game)
+			return
+		}
+		if err != nil {
+			log.Printf("ERROR: Bot could not move in room %s: %v", roomID, err)
+			return
+		}
+		if game != nil {
+			broadcastMove(hub, gs, game, position)
+		}
+	}()
 }
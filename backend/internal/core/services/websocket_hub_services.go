@@ -8,12 +8,15 @@
 package services
 
 import (
+	"encoding/json"
 	"log"
 	"net/http"
 	"sync"
 	"time"
 
 	"github.com/gorilla/websocket"
+
+	"github.com/juan10024/tictactoe-test/internal/core/ports"
 )
 
 const (
@@ -36,27 +39,57 @@ type Hub struct {
 	unregister chan *Client                // Unregister client.
 	rooms      map[string]map[*Client]bool // Rooms and their clients.
 	mu         sync.RWMutex                // Protects rooms map.
+	timers     map[string]*time.Timer      // Server-side timers (turn clocks, reconnection grace periods).
+	timersMu   sync.Mutex                  // Protects timers map.
+
+	reconnectGrace time.Duration         // How long a disconnected player has to return before forfeiting.
+	broadcaster    ports.RoomBroadcaster // Carries room messages to the clients of every instance.
+
+	instance      string                             // Identifies this instance's presence.
+	presenceQueue chan string                        // Rooms whose local clients changed, to publish.
+	peers         map[string]map[string]peerPresence // Other instances' clients, by room and instance.
+	presenceMu    sync.RWMutex                       // Protects peers.
+}
+
+// roomEnvelope is what the Hub publishes on the broadcaster: a client message and who should
+// receive it, or the presence of an instance's clients in the room.
+type roomEnvelope struct {
+	Payload     json.RawMessage `json:"payload,omitempty"`
+	PlayersOnly bool            `json:"playersOnly,omitempty"` // Skip observers.
+	Except      uint            `json:"except,omitempty"`      // Skip this player's connections.
+	Version     int             `json:"version,omitempty"`     // Only clients speaking this protocol version; 0 for all.
+	Presence    *roomPresence   `json:"presence,omitempty"`    // Set instead of Payload; not delivered to clients.
 }
 
 /*
  * NewHub creates and initializes a new Hub instance.
  *
  * Parameters:
- *   - None.
+ *   - reconnectGrace (time.Duration): How long a player who disconnects from a game in
+ *     progress has to reconnect before the game is forfeited.
+ *   - broadcaster (ports.RoomBroadcaster): The pub/sub channel shared by all backend instances.
  *
  * Returns:
  *   - *Hub: a pointer to a new Hub instance.
  */
-func NewHub() *Hub {
-	return &Hub{
-		register:   make(chan *Client),
-		unregister: make(chan *Client),
-		rooms:      make(map[string]map[*Client]bool),
+func NewHub(reconnectGrace time.Duration, broadcaster ports.RoomBroadcaster) *Hub {
+	h := &Hub{
+		register:       make(chan *Client),
+		unregister:     make(chan *Client),
+		rooms:          make(map[string]map[*Client]bool),
+		timers:         make(map[string]*time.Timer),
+		reconnectGrace: reconnectGrace,
+		broadcaster:    broadcaster,
+		instance:       newInstanceID(),
+		presenceQueue:  make(chan string, presenceQueueSize),
+		peers:          make(map[string]map[string]peerPresence),
 	}
+	broadcaster.Subscribe(h.deliver)
+	return h
 }
 
 /*
- * Run starts the main event loop for the Hub.
+ * Run starts the main event loop for the Hub, and the publishing of its presence.
  *
  * Parameters:
  *   - None.
@@ -65,6 +98,8 @@ func NewHub() *Hub {
  *   - None.
  */
 func (h *Hub) Run() {
+	go h.runPresence()
+
 	for {
 		select {
 		case client := <-h.register:
@@ -74,6 +109,7 @@ func (h *Hub) Run() {
 			}
 			h.rooms[client.room][client] = true
 			h.mu.Unlock()
+			h.presenceChanged(client.room)
 			log.Printf("INFO: Client registered to room %s", client.room)
 
 		case client := <-h.unregister:
@@ -86,6 +122,7 @@ func (h *Hub) Run() {
 				}
 			}
 			h.mu.Unlock()
+			h.presenceChanged(client.room)
 			close(client.send)
 			log.Printf("INFO: Client unregistered from room %s", client.room)
 		}
@@ -93,7 +130,7 @@ func (h *Hub) Run() {
 }
 
 /*
- * Broadcast sends a message to all connected clients in a specified room.
+ * broadcast sends a message to all clients in a room, on every backend instance.
  *
  * Parameters:
  *   - roomID (string): The unique identifier of the room to broadcast to.
@@ -103,17 +140,251 @@ func (h *Hub) Run() {
  *   - None.
  */
 func (h *Hub) broadcast(roomID string, message []byte) {
+	h.publish(roomID, roomEnvelope{Payload: message})
+}
+
+/*
+ * broadcastVersion sends a message to the clients of a room that speak a given protocol
+ * version, on every backend instance.
+ *
+ * Parameters:
+ *   - roomID (string): The unique identifier of the room to broadcast to.
+ *   - version (int): The protocol version of the recipients.
+ *   - message ([]byte): The message payload, encoded for that version.
+ *
+ * Returns:
+ *   - None.
+ */
+func (h *Hub) broadcastVersion(roomID string, version int, message []byte) {
+	h.publish(roomID, roomEnvelope{Payload: message, Version: version})
+}
+
+/*
+ * broadcastToPlayers sends a message to the seated players of a room, except the given one,
+ * on every backend instance.
+ *
+ * Parameters:
+ *   - roomID (string): The unique identifier of the room.
+ *   - message ([]byte): The message payload.
+ *   - except (uint): The player who should not receive it, usually its sender.
+ *
+ * Returns:
+ *   - None.
+ */
+func (h *Hub) broadcastToPlayers(roomID string, message []byte, except uint) {
+	h.publish(roomID, roomEnvelope{Payload: message, PlayersOnly: true, Except: except})
+}
+
+/*
+ * publish hands an envelope to the broadcaster.
+ *
+ * Parameters:
+ *   - roomID (string): The unique identifier of the room.
+ *   - envelope (roomEnvelope): The message and its audience.
+ *
+ * Returns:
+ *   - None. Failures are logged.
+ */
+func (h *Hub) publish(roomID string, envelope roomEnvelope) {
+	data, err := json.Marshal(envelope)
+	if err != nil {
+		log.Printf("ERROR: Could not marshal message for room %s: %v", roomID, err)
+		return
+	}
+	if err := h.broadcaster.Publish(roomID, data); err != nil {
+		log.Printf("ERROR: Could not publish message to room %s: %v", roomID, err)
+	}
+}
+
+/*
+ * deliver is the broadcaster subscription: it sends a published message to this
+ * instance's clients in the room, or records another instance's presence in it.
+ * A client whose buffer is full is disconnected.
+ *
+ * Parameters:
+ *   - roomID (string): The unique identifier of the room.
+ *   - data ([]byte): The published roomEnvelope.
+ *
+ * Returns:
+ *   - None.
+ */
+func (h *Hub) deliver(roomID string, data []byte) {
+	if roomID == lobbyChannel {
+		return
+	}
+
+	var envelope roomEnvelope
+	if err := json.Unmarshal(data, &envelope); err != nil {
+		log.Printf("ERROR: Could not decode message for room %s: %v", roomID, err)
+		return
+	}
+	if envelope.Presence != nil {
+		h.recordPresence(roomID, *envelope.Presence)
+		return
+	}
+
 	h.mu.RLock()
 	defer h.mu.RUnlock()
-	if room, ok := h.rooms[roomID]; ok {
-		for client := range room {
-			select {
-			case client.send <- message:
-			default:
-				log.Printf("WARN: Client send buffer full. Closing connection for client in room %s.", client.room)
-				close(client.send)
-				delete(room, client)
-			}
+	for client := range h.rooms[roomID] {
+		if envelope.PlayersOnly && client.isObserver {
+			continue
+		}
+		if envelope.Except != 0 && client.playerID == envelope.Except {
+			continue
 		}
+		if envelope.Version != 0 && client.version != envelope.Version {
+			continue
+		}
+		select {
+		case client.send <- envelope.Payload:
+		default:
+			// Closing the connection ends its read pump, which unregisters the client.
+			log.Printf("WARN: Client send buffer full. Closing connection for client in room %s.", roomID)
+			client.conn.Close()
+		}
+	}
+}
+
+/*
+ * hasPlayer reports whether a player is connected to a room as a player (not an observer),
+ * on this or any other instance.
+ *
+ * Parameters:
+ *   - roomID (string): The unique identifier of the room.
+ *   - playerID (uint): The player's ID.
+ *
+ * Returns:
+ *   - bool: True if at least one of the player's connections is still registered.
+ */
+func (h *Hub) hasPlayer(roomID string, playerID uint) bool {
+	h.mu.RLock()
+	for client := range h.rooms[roomID] {
+		if client.playerID == playerID && !client.isObserver {
+			h.mu.RUnlock()
+			return true
+		}
+	}
+	h.mu.RUnlock()
+
+	for _, peer := range h.peersOf(roomID) {
+		if peer.players[playerID] {
+			return true
+		}
+	}
+	return false
+}
+
+/*
+ * evictPlayer closes the seated connections a player still has in a room, so a
+ * reconnecting client can replace them. Their read pumps then unregister them.
+ *
+ * Parameters:
+ *   - roomID (string): The unique identifier of the room.
+ *   - playerID (uint): The player's ID.
+ *
+ * Returns:
+ *   - None.
+ */
+func (h *Hub) evictPlayer(roomID string, playerID uint) {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	for client := range h.rooms[roomID] {
+		if client.playerID == playerID && !client.isObserver {
+			log.Printf("INFO: Replacing stale connection of player %s in room %s", client.playerName, roomID)
+			client.conn.Close()
+		}
+	}
+}
+
+/*
+ * hasClients reports whether anybody, player or observer, is connected to a room,
+ * on this or any other instance.
+ *
+ * Parameters:
+ *   - roomID (string): The unique identifier of the room.
+ *
+ * Returns:
+ *   - bool: True if the room has at least one registered client.
+ */
+func (h *Hub) hasClients(roomID string) bool {
+	h.mu.RLock()
+	local := len(h.rooms[roomID])
+	h.mu.RUnlock()
+	return local > 0 || len(h.peersOf(roomID)) > 0
+}
+
+/*
+ * spectators counts the observers connected to a room across all instances.
+ *
+ * Parameters:
+ *   - roomID (string): The unique identifier of the room.
+ *
+ * Returns:
+ *   - int: The number of observer connections.
+ */
+func (h *Hub) spectators(roomID string) int {
+	h.mu.RLock()
+	count := 0
+	for client := range h.rooms[roomID] {
+		if client.isObserver {
+			count++
+		}
+	}
+	h.mu.RUnlock()
+
+	for _, peer := range h.peersOf(roomID) {
+		count += peer.observers
+	}
+	return count
+}
+
+/*
+ * scheduleTimer arms the timer stored under key to run fn after d, replacing any pending timer.
+ *
+ * Parameters:
+ *   - key (string): The timer key, usually the room ID.
+ *   - d (time.Duration): The delay before fn runs.
+ *   - fn (func()): The callback, run on its own goroutine.
+ *
+ * Returns:
+ *   - None.
+ */
+func (h *Hub) scheduleTimer(key string, d time.Duration, fn func()) {
+	h.timersMu.Lock()
+	defer h.timersMu.Unlock()
+	if t, ok := h.timers[key]; ok {
+		t.Stop()
+	}
+
+	// The callback takes timersMu before reading t, so t is always assigned by then.
+	var t *time.Timer
+	t = time.AfterFunc(d, func() {
+		h.timersMu.Lock()
+		if h.timers[key] == t {
+			delete(h.timers, key)
+		}
+		h.timersMu.Unlock()
+		fn()
+	})
+	h.timers[key] = t
+}
+
+/*
+ * stopTimer cancels the pending timer stored under key, if any.
+ *
+ * Parameters:
+ *   - key (string): The timer key, usually the room ID.
+ *
+ * Returns:
+ *   - bool: True if a pending timer was cancelled.
+ */
+func (h *Hub) stopTimer(key string) bool {
+	h.timersMu.Lock()
+	defer h.timersMu.Unlock()
+	t, ok := h.timers[key]
+	if !ok {
+		return false
 	}
+	delete(h.timers, key)
+	return t.Stop()
 }
@@ -0,0 +1,142 @@
+/*
+ * file: websocket_lobby_services.go
+ * package: services
+ * description:
+ *     Serves the lobby WebSocket: a subscriber first receives a snapshot of the
+ *     open rooms, then every room created/started/finished event. Nothing is
+ *     expected from the client besides keeping the connection alive.
+ */
+
+package services
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/gorilla/websocket"
+
+	"github.com/juan10024/tictactoe-test/internal/core/protocol"
+)
+
+// lobbySendBuffer is how many lobby events may wait for a slow subscriber before it is dropped.
+const lobbySendBuffer = 64
+
+/*
+ * ServeLobby upgrades a request to the lobby WebSocket and streams lobby events
+ * until the connection closes.
+ *
+ * Parameters:
+ *   - lobby (*Lobby): The lobby to subscribe to.
+ *   - w (http.ResponseWriter): HTTP response writer.
+ *   - r (*http.Request): Incoming HTTP request.
+ *
+ * Returns:
+ *   - None.
+ */
+func ServeLobby(lobby *Lobby, w http.ResponseWriter, r *http.Request) {
+	conn, err := upgrader.Upgrade(w, r, nil)
+	if err != nil {
+		log.Println("WebSocket upgrade error:", err)
+		return
+	}
+	defer conn.Close()
+
+	// Subscribe before taking the snapshot, so no event falls between the two.
+	send := make(chan []byte, lobbySendBuffer)
+	lobby.subscribe(send, conn)
+	defer lobby.unsubscribe(send)
+
+	rooms, err := lobby.Rooms()
+	if err != nil {
+		log.Printf("ERROR: Could not list rooms for lobby subscriber: %v", err)
+		return
+	}
+	views := make([]protocol.LobbyRoom, 0, len(rooms))
+	for _, room := range rooms {
+		views = append(views, protocol.NewLobbyRoom(room.Game, room.Privacy, room.Spectators))
+	}
+	snapshot, err := json.Marshal(protocol.LobbySnapshot{Type: protocol.TypeLobbySnapshot, Rooms: views})
+	if err != nil {
+		log.Printf("ERROR: Could not marshal lobby snapshot: %v", err)
+		return
+	}
+	if !writeLobbyMessage(conn, snapshot) {
+		return
+	}
+
+	closed := make(chan struct{})
+	go readLobbyMessages(conn, closed)
+
+	ping := time.NewTicker(pingPeriod)
+	defer ping.Stop()
+
+	for {
+		select {
+		case message := <-send:
+			if !writeLobbyMessage(conn, message) {
+				return
+			}
+
+		case <-ping.C:
+			conn.SetWriteDeadline(time.Now().Add(writeWait))
+			if err := conn.WriteMessage(websocket.PingMessage, nil); err != nil {
+				return
+			}
+
+		case <-closed:
+			return
+		}
+	}
+}
+
+/*
+ * readLobbyMessages discards the client's messages and keeps the read deadline
+ * alive with pongs, until the connection closes.
+ *
+ * Parameters:
+ *   - conn (*websocket.Conn): The client connection.
+ *   - closed (chan struct{}): Closed when the connection ends.
+ *
+ * Returns:
+ *   - None.
+ */
+func readLobbyMessages(conn *websocket.Conn, closed chan struct{}) {
+	defer close(closed)
+
+	conn.SetReadLimit(maxMessageSize)
+	conn.SetReadDeadline(time.Now().Add(pongWait))
+	conn.SetPongHandler(func(string) error {
+		conn.SetReadDeadline(time.Now().Add(pongWait))
+		return nil
+	})
+
+	for {
+		if _, _, err := conn.ReadMessage(); err != nil {
+			if websocket.IsUnexpectedCloseError(err, websocket.CloseGoingAway, websocket.CloseAbnormalClosure) {
+				log.Printf("error in lobby connection: %v", err)
+			}
+			return
+		}
+	}
+}
+
+/*
+ * writeLobbyMessage sends an encoded message on the lobby socket.
+ *
+ * Parameters:
+ *   - conn (*websocket.Conn): The client connection.
+ *   - message ([]byte): The JSON message.
+ *
+ * Returns:
+ *   - bool: False if the write failed and the connection should be dropped.
+ */
+func writeLobbyMessage(conn *websocket.Conn, message []byte) bool {
+	conn.SetWriteDeadline(time.Now().Add(writeWait))
+	if err := conn.WriteMessage(websocket.TextMessage, message); err != nil {
+		log.Printf("ERROR: Could not write lobby message: %v", err)
+		return false
+	}
+	return true
+}
@@ -0,0 +1,188 @@
+/*
+ * file: websocket_matchmaking_services.go
+ * package: services
+ * description:
+ *     Serves the quick play WebSocket: a player enqueues with their preferences,
+ *     may cancel, and receives a matchFound message with the room to join. Closing
+ *     the connection leaves the queue.
+ */
+
+package services
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/gorilla/websocket"
+
+	"github.com/juan10024/tictactoe-test/internal/core/domain"
+	"github.com/juan10024/tictactoe-test/internal/core/protocol"
+)
+
+/*
+ * ServeMatchmaking upgrades a request to the matchmaking WebSocket and serves it
+ * until the player is matched or leaves.
+ *
+ * Parameters:
+ *   - mm (*Matchmaker): The matchmaker holding the queue.
+ *   - w (http.ResponseWriter): HTTP response writer.
+ *   - r (*http.Request): Incoming HTTP request.
+ *   - player (*domain.Player): The authenticated player.
+ *
+ * Returns:
+ *   - None.
+ */
+func ServeMatchmaking(mm *Matchmaker, w http.ResponseWriter, r *http.Request, player *domain.Player) {
+	conn, err := upgrader.Upgrade(w, r, nil)
+	if err != nil {
+		log.Println("WebSocket upgrade error:", err)
+		return
+	}
+	defer conn.Close()
+
+	incoming := make(chan protocol.MatchmakingRequest)
+	done := make(chan struct{})
+	closed := make(chan struct{})
+	defer close(done)
+	go readMatchmakingMessages(conn, incoming, done, closed)
+
+	ping := time.NewTicker(pingPeriod)
+	defer ping.Stop()
+
+	var ticket *MatchTicket
+	defer func() {
+		if ticket != nil && mm.Cancel(ticket) {
+			log.Printf("INFO: Player %s left the matchmaking queue", player.Name)
+		}
+	}()
+
+	for {
+		var result <-chan MatchResult
+		if ticket != nil {
+			result = ticket.Result()
+		}
+
+		select {
+		case msg := <-incoming:
+			switch msg.Type {
+			case protocol.TypeEnqueue:
+				if ticket != nil {
+					writeMatchmakingMessage(conn, matchmakingError(ErrAlreadyQueued.Error()))
+					continue
+				}
+				ticket, err = mm.Enqueue(player, msg.Payload)
+				if err != nil {
+					writeMatchmakingMessage(conn, matchmakingError(err.Error()))
+					continue
+				}
+				writeMatchmakingMessage(conn, protocol.Queued{Type: protocol.TypeQueued, Preferences: msg.Payload})
+
+			case protocol.TypeCancel:
+				// A ticket that was already paired cannot be cancelled; its match is on its way.
+				if ticket != nil && mm.Cancel(ticket) {
+					ticket = nil
+					writeMatchmakingMessage(conn, protocol.Cancelled{Type: protocol.TypeCancelled})
+				}
+
+			default:
+				writeMatchmakingMessage(conn, matchmakingError("Unknown message type"))
+			}
+
+		case outcome := <-result:
+			ticket = nil
+			if outcome.Err != nil {
+				writeMatchmakingMessage(conn, matchmakingError("Could not create the match room, please try again"))
+				continue
+			}
+			writeMatchmakingMessage(conn, outcome.Match)
+			conn.SetWriteDeadline(time.Now().Add(writeWait))
+			conn.WriteMessage(websocket.CloseMessage, websocket.FormatCloseMessage(websocket.CloseNormalClosure, "match found"))
+			return
+
+		case <-ping.C:
+			conn.SetWriteDeadline(time.Now().Add(writeWait))
+			if err := conn.WriteMessage(websocket.PingMessage, nil); err != nil {
+				return
+			}
+
+		case <-closed:
+			return
+		}
+	}
+}
+
+/*
+ * readMatchmakingMessages forwards the client's messages until the connection closes.
+ *
+ * Parameters:
+ *   - conn (*websocket.Conn): The client connection.
+ *   - incoming (chan<- protocol.MatchmakingRequest): Receives each valid message.
+ *   - done (<-chan struct{}): Closed when the server stops serving the connection.
+ *   - closed (chan struct{}): Closed when the connection ends.
+ *
+ * Returns:
+ *   - None.
+ */
+func readMatchmakingMessages(conn *websocket.Conn, incoming chan<- protocol.MatchmakingRequest, done <-chan struct{}, closed chan struct{}) {
+	defer close(closed)
+
+	conn.SetReadLimit(maxMessageSize)
+	conn.SetReadDeadline(time.Now().Add(pongWait))
+	conn.SetPongHandler(func(string) error {
+		conn.SetReadDeadline(time.Now().Add(pongWait))
+		return nil
+	})
+
+	for {
+		_, data, err := conn.ReadMessage()
+		if err != nil {
+			if websocket.IsUnexpectedCloseError(err, websocket.CloseGoingAway, websocket.CloseAbnormalClosure) {
+				log.Printf("error in matchmaking connection: %v", err)
+			}
+			return
+		}
+
+		var msg protocol.MatchmakingRequest
+		if err := json.Unmarshal(data, &msg); err != nil {
+			continue
+		}
+		select {
+		case incoming <- msg:
+		case <-done:
+			return
+		}
+	}
+}
+
+/*
+ * writeMatchmakingMessage sends a JSON message on the matchmaking socket.
+ *
+ * Parameters:
+ *   - conn (*websocket.Conn): The client connection.
+ *   - msg (interface{}): The message to send.
+ *
+ * Returns:
+ *   - None. Write errors end the connection through its reader.
+ */
+func writeMatchmakingMessage(conn *websocket.Conn, msg interface{}) {
+	conn.SetWriteDeadline(time.Now().Add(writeWait))
+	if err := conn.WriteJSON(msg); err != nil {
+		log.Printf("ERROR: Could not write matchmaking message: %v", err)
+		conn.Close()
+	}
+}
+
+/*
+ * matchmakingError builds the message refusing a client's request.
+ *
+ * Parameters:
+ *   - message (string): Why the request was refused.
+ *
+ * Returns:
+ *   - protocol.MatchmakingError: The message.
+ */
+func matchmakingError(message string) protocol.MatchmakingError {
+	return protocol.MatchmakingError{Type: protocol.TypeError, Message: message}
+}
@@ -0,0 +1,71 @@
+/*
+ * file: invite_test.go
+ * package: auth
+ * description:
+ *     Tests for room invites: an invite round-trips its room, and expired, roomless,
+ *     tampered or foreign invites are rejected.
+ */
+
+package auth
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/juan10024/tictactoe-test/internal/core/domain"
+)
+
+func TestInviteRoundTrip(t *testing.T) {
+	issuer := NewHMACInviteIssuer([]byte("invite-test-secret"))
+	claims := domain.InviteClaims{RoomID: "room-1", ExpiresAt: time.Now().Add(time.Hour).UTC().Truncate(time.Second)}
+
+	token, err := issuer.IssueInvite(claims)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := issuer.VerifyInvite(token)
+	if err != nil {
+		t.Fatalf("VerifyInvite() error = %v", err)
+	}
+	if *got != claims {
+		t.Errorf("VerifyInvite() = %+v, want %+v", *got, claims)
+	}
+}
+
+func TestInviteRejected(t *testing.T) {
+	secret := []byte("invite-test-secret")
+	issue := func(issuer *HMACInviteIssuer, claims domain.InviteClaims) string {
+		token, err := issuer.IssueInvite(claims)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return token
+	}
+	session, err := NewHMACTokenIssuer(secret).Issue(domain.AuthClaims{PlayerID: 7, PlayerName: "alice", ExpiresAt: time.Now().Add(time.Hour)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	valid := issue(NewHMACInviteIssuer(secret), domain.InviteClaims{RoomID: "room-1", ExpiresAt: time.Now().Add(time.Hour)})
+
+	tests := []struct {
+		name  string
+		token string
+	}{
+		{"expired", issue(NewHMACInviteIssuer(secret), domain.InviteClaims{RoomID: "room-1", ExpiresAt: time.Now().Add(-time.Second)})},
+		{"without a room", issue(NewHMACInviteIssuer(secret), domain.InviteClaims{ExpiresAt: time.Now().Add(time.Hour)})},
+		{"signed with another secret", issue(NewHMACInviteIssuer([]byte("another-secret")), domain.InviteClaims{RoomID: "room-1", ExpiresAt: time.Now().Add(time.Hour)})},
+		{"session token", session},
+		{"truncated signature", valid[:len(valid)-2]},
+		{"empty", ""},
+	}
+
+	issuer := NewHMACInviteIssuer(secret)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := issuer.VerifyInvite(tt.token); !errors.Is(err, ErrInvalidToken) {
+				t.Errorf("VerifyInvite() error = %v, want ErrInvalidToken", err)
+			}
+		})
+	}
+}
@@ -0,0 +1,57 @@
+/*
+ * file: password.go
+ * package: auth
+ * description:
+ *     Provides the bcrypt implementation of the ports.PasswordHasher port.
+ */
+
+package auth
+
+import "golang.org/x/crypto/bcrypt"
+
+/*
+ * BcryptHasher hashes passwords with bcrypt at the library's default cost.
+ */
+type BcryptHasher struct{}
+
+/*
+ * NewBcryptHasher constructs a new BcryptHasher instance.
+ *
+ * Returns:
+ *   - *BcryptHasher: The password hasher.
+ */
+func NewBcryptHasher() *BcryptHasher {
+	return &BcryptHasher{}
+}
+
+/*
+ * Hash derives a salted bcrypt hash from a password.
+ *
+ * Parameters:
+ *   - password (string): The plain-text password (at most 72 bytes).
+ *
+ * Returns:
+ *   - string: The encoded hash, safe to store.
+ *   - error: An error if hashing fails.
+ */
+func (h *BcryptHasher) Hash(password string) (string, error) {
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	if err != nil {
+		return "", err
+	}
+	return string(hash), nil
+}
+
+/*
+ * Compare checks a password against a stored hash.
+ *
+ * Parameters:
+ *   - hash (string): The stored bcrypt hash.
+ *   - password (string): The plain-text password to check.
+ *
+ * Returns:
+ *   - error: nil if the password matches, otherwise an error.
+ */
+func (h *BcryptHasher) Compare(hash, password string) error {
+	return bcrypt.CompareHashAndPassword([]byte(hash), []byte(password))
+}
@@ -0,0 +1,152 @@
+/*
+ * file: token.go
+ * package: auth
+ * description:
+ *     Provides the HMAC-SHA256 implementation of the ports.TokenIssuer port.
+ *     A token is the base64url-encoded JSON claims followed by a dot and the
+ *     base64url-encoded signature of those claims.
+ */
+
+package auth
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/juan10024/tictactoe-test/internal/core/domain"
+)
+
+// ErrInvalidToken is returned when a token is malformed, tampered with, or expired.
+var ErrInvalidToken = errors.New("invalid or expired token")
+
+/*
+ * HMACTokenIssuer signs and verifies session tokens with a shared secret.
+ *
+ * Fields:
+ *   - secret ([]byte): The HMAC key; every backend instance must use the same one.
+ */
+type HMACTokenIssuer struct {
+	secret []byte
+}
+
+/*
+ * NewHMACTokenIssuer constructs a new HMACTokenIssuer instance.
+ *
+ * Parameters:
+ *   - secret ([]byte): The HMAC key.
+ *
+ * Returns:
+ *   - *HMACTokenIssuer: A token issuer bound to the secret.
+ */
+func NewHMACTokenIssuer(secret []byte) *HMACTokenIssuer {
+	return &HMACTokenIssuer{secret: secret}
+}
+
+/*
+ * Issue encodes and signs the given claims.
+ *
+ * Parameters:
+ *   - claims (domain.AuthClaims): The identity to embed in the token.
+ *
+ * Returns:
+ *   - string: The signed token.
+ *   - error: An error if the claims cannot be encoded.
+ */
+func (i *HMACTokenIssuer) Issue(claims domain.AuthClaims) (string, error) {
+	return encodeSigned(i.secret, claims)
+}
+
+/*
+ * Verify checks a token's signature and expiry and returns its claims.
+ *
+ * Parameters:
+ *   - token (string): The token presented by the client.
+ *
+ * Returns:
+ *   - *domain.AuthClaims: The verified claims.
+ *   - error: ErrInvalidToken if the token is malformed, tampered with, or expired.
+ */
+func (i *HMACTokenIssuer) Verify(token string) (*domain.AuthClaims, error) {
+	var claims domain.AuthClaims
+	if err := decodeSigned(i.secret, token, &claims); err != nil {
+		return nil, err
+	}
+	if time.Now().After(claims.ExpiresAt) {
+		return nil, ErrInvalidToken
+	}
+	return &claims, nil
+}
+
+/*
+ * encodeSigned encodes claims as JSON and appends their signature.
+ *
+ * Parameters:
+ *   - secret ([]byte): The HMAC key.
+ *   - claims (interface{}): The claims to embed in the token.
+ *
+ * Returns:
+ *   - string: The signed token.
+ *   - error: An error if the claims cannot be encoded.
+ */
+func encodeSigned(secret []byte, claims interface{}) (string, error) {
+	payload, err := json.Marshal(claims)
+	if err != nil {
+		return "", err
+	}
+
+	encoded := base64.RawURLEncoding.EncodeToString(payload)
+	return encoded + "." + base64.RawURLEncoding.EncodeToString(sign(secret, encoded)), nil
+}
+
+/*
+ * decodeSigned checks a token's signature and decodes its claims. Expiry is left to the caller.
+ *
+ * Parameters:
+ *   - secret ([]byte): The HMAC key.
+ *   - token (string): The token presented by the client.
+ *   - claims (interface{}): Pointer receiving the decoded claims.
+ *
+ * Returns:
+ *   - error: ErrInvalidToken if the token is malformed or tampered with.
+ */
+func decodeSigned(secret []byte, token string, claims interface{}) error {
+	encoded, signature, ok := strings.Cut(token, ".")
+	if !ok {
+		return ErrInvalidToken
+	}
+
+	got, err := base64.RawURLEncoding.DecodeString(signature)
+	if err != nil || !hmac.Equal(got, sign(secret, encoded)) {
+		return ErrInvalidToken
+	}
+
+	payload, err := base64.RawURLEncoding.DecodeString(encoded)
+	if err != nil {
+		return ErrInvalidToken
+	}
+	if err := json.Unmarshal(payload, claims); err != nil {
+		return ErrInvalidToken
+	}
+	return nil
+}
+
+/*
+ * sign computes the HMAC-SHA256 of the encoded claims.
+ *
+ * Parameters:
+ *   - secret ([]byte): The HMAC key.
+ *   - encoded (string): The base64url-encoded claims.
+ *
+ * Returns:
+ *   - []byte: The signature.
+ */
+func sign(secret []byte, encoded string) []byte {
+	mac := hmac.New(sha256.New, secret)
+	mac.Write([]byte(encoded))
+	return mac.Sum(nil)
+}
@@ -0,0 +1,89 @@
+/*
+ * file: token_test.go
+ * package: auth
+ * description:
+ *     Tests for session tokens: a token round-trips its claims, and expired,
+ *     tampered, malformed or foreign tokens are rejected.
+ */
+
+package auth
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/juan10024/tictactoe-test/internal/core/domain"
+)
+
+func TestSessionTokenRoundTrip(t *testing.T) {
+	issuer := NewHMACTokenIssuer([]byte("token-test-secret"))
+	claims := domain.AuthClaims{PlayerID: 7, PlayerName: "alice", Guest: true, ExpiresAt: time.Now().Add(time.Hour).UTC().Truncate(time.Second)}
+
+	token, err := issuer.Issue(claims)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := issuer.Verify(token)
+	if err != nil {
+		t.Fatalf("Verify() error = %v", err)
+	}
+	if *got != claims {
+		t.Errorf("Verify() = %+v, want %+v", *got, claims)
+	}
+}
+
+func TestSessionTokenRejected(t *testing.T) {
+	issuer := NewHMACTokenIssuer([]byte("token-test-secret"))
+	valid, err := issuer.Issue(domain.AuthClaims{PlayerID: 7, PlayerName: "alice", ExpiresAt: time.Now().Add(time.Hour)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	expired, err := issuer.Issue(domain.AuthClaims{PlayerID: 7, PlayerName: "alice", ExpiresAt: time.Now().Add(-time.Second)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	foreign, err := NewHMACTokenIssuer([]byte("another-secret")).Issue(domain.AuthClaims{PlayerID: 7, PlayerName: "alice", ExpiresAt: time.Now().Add(time.Hour)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	payload, signature, _ := strings.Cut(valid, ".")
+	forged, err := encodeSigned([]byte("another-secret"), domain.AuthClaims{PlayerID: 1, PlayerName: "admin", ExpiresAt: time.Now().Add(time.Hour)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	forgedPayload, _, _ := strings.Cut(forged, ".")
+
+	tests := []struct {
+		name  string
+		token string
+	}{
+		{"expired", expired},
+		{"signed with another secret", foreign},
+		{"claims swapped under a valid signature", forgedPayload + "." + signature},
+		{"signature not base64", payload + ".!!!"},
+		{"claims not base64", "!!!." + signature},
+		{"no signature", payload},
+		{"empty", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := issuer.Verify(tt.token); !errors.Is(err, ErrInvalidToken) {
+				t.Errorf("Verify() error = %v, want ErrInvalidToken", err)
+			}
+		})
+	}
+}
+
+func TestInviteIsNotASessionToken(t *testing.T) {
+	secret := []byte("token-test-secret")
+	invite, err := NewHMACInviteIssuer(secret).IssueInvite(domain.InviteClaims{RoomID: "room-1", ExpiresAt: time.Now().Add(time.Hour)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := NewHMACTokenIssuer(secret).Verify(invite); !errors.Is(err, ErrInvalidToken) {
+		t.Errorf("Verify(invite) error = %v, want ErrInvalidToken", err)
+	}
+}
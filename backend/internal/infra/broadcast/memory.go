@@ -0,0 +1,67 @@
+/*
+ * file: memory.go
+ * package: broadcast
+ * description:
+ *     Provides the in-process implementation of the ports.RoomBroadcaster port,
+ *     for a single backend instance.
+ */
+
+package broadcast
+
+import "sync"
+
+/*
+ * MemoryBroadcaster hands every published message straight to the local subscribers.
+ *
+ * Fields:
+ *   - mu (sync.RWMutex): Protects handlers.
+ *   - handlers ([]func(string, []byte)): The registered subscribers.
+ */
+type MemoryBroadcaster struct {
+	mu       sync.RWMutex
+	handlers []func(roomID string, message []byte)
+}
+
+/*
+ * NewMemoryBroadcaster constructs a new MemoryBroadcaster instance.
+ *
+ * Returns:
+ *   - *MemoryBroadcaster: A broadcaster with no subscribers.
+ */
+func NewMemoryBroadcaster() *MemoryBroadcaster {
+	return &MemoryBroadcaster{}
+}
+
+/*
+ * Publish delivers a message to every subscriber before returning.
+ *
+ * Parameters:
+ *   - roomID (string): The room the message belongs to.
+ *   - message ([]byte): The message payload.
+ *
+ * Returns:
+ *   - error: Always nil.
+ */
+func (b *MemoryBroadcaster) Publish(roomID string, message []byte) error {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	for _, handler := range b.handlers {
+		handler(roomID, message)
+	}
+	return nil
+}
+
+/*
+ * Subscribe registers a handler for every published message.
+ *
+ * Parameters:
+ *   - handler (func(string, []byte)): Called with the room ID and payload of each message.
+ *
+ * Returns:
+ *   - None.
+ */
+func (b *MemoryBroadcaster) Subscribe(handler func(roomID string, message []byte)) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.handlers = append(b.handlers, handler)
+}
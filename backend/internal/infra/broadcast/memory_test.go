@@ -0,0 +1,28 @@
+/*
+ * file: memory_test.go
+ * package: broadcast
+ * description:
+ *     Tests for the in-process broadcaster: a message reaches every subscriber.
+ */
+
+package broadcast
+
+import "testing"
+
+func TestMemoryBroadcasterReachesEverySubscriber(t *testing.T) {
+	b := NewMemoryBroadcaster()
+	var got []string
+	for _, name := range []string{"first", "second"} {
+		b.Subscribe(func(roomID string, message []byte) {
+			got = append(got, name+" "+roomID+" "+string(message))
+		})
+	}
+
+	if err := b.Publish("room-1", []byte("hello")); err != nil {
+		t.Fatal(err)
+	}
+	want := []string{"first room-1 hello", "second room-1 hello"}
+	if len(got) != len(want) || got[0] != want[0] || got[1] != want[1] {
+		t.Errorf("delivered %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,199 @@
+/*
+ * file: postgres.go
+ * package: broadcast
+ * description:
+ *     Provides the Postgres LISTEN/NOTIFY implementation of the ports.RoomBroadcaster
+ *     port, so backend replicas sharing the database also share their rooms.
+ */
+
+package broadcast
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"gorm.io/gorm"
+)
+
+const (
+	// notifyChannel is the Postgres channel every instance listens on.
+	notifyChannel = "room_events"
+	// maxNotifyPayload is Postgres' limit on a NOTIFY payload (8000 bytes), minus some headroom.
+	maxNotifyPayload = 7900
+	// relistenDelay is how long to wait before reconnecting after the listener connection drops.
+	relistenDelay = 2 * time.Second
+)
+
+/*
+ * PostgresBroadcaster publishes room messages with NOTIFY and receives the messages
+ * of every instance, its own included, on a dedicated LISTEN connection.
+ *
+ * Fields:
+ *   - db (*gorm.DB): The shared connection pool, used to send notifications.
+ *   - dsn (string): Connection string of the dedicated listener connection.
+ *   - mu (sync.RWMutex): Protects handlers.
+ *   - handlers ([]func(string, []byte)): The registered subscribers.
+ */
+type PostgresBroadcaster struct {
+	db       *gorm.DB
+	dsn      string
+	mu       sync.RWMutex
+	handlers []func(roomID string, message []byte)
+}
+
+/*
+ * NewPostgresBroadcaster constructs a new PostgresBroadcaster instance.
+ * Listen must be started for subscribers to receive anything.
+ *
+ * Parameters:
+ *   - db (*gorm.DB): A GORM database connection instance.
+ *   - dsn (string): The connection string for the listener connection.
+ *
+ * Returns:
+ *   - *PostgresBroadcaster: A broadcaster bound to the database.
+ */
+func NewPostgresBroadcaster(db *gorm.DB, dsn string) *PostgresBroadcaster {
+	return &PostgresBroadcaster{db: db, dsn: dsn}
+}
+
+/*
+ * Publish sends a message to every instance through pg_notify.
+ *
+ * Parameters:
+ *   - roomID (string): The room the message belongs to.
+ *   - message ([]byte): The message payload.
+ *
+ * Returns:
+ *   - error: An error if the message is too large for a notification or cannot be sent.
+ */
+func (b *PostgresBroadcaster) Publish(roomID string, message []byte) error {
+	payload := encodePayload(roomID, message)
+	if len(payload) > maxNotifyPayload {
+		return fmt.Errorf("room %s message of %d bytes exceeds the notification limit", roomID, len(payload))
+	}
+	return b.db.Exec("SELECT pg_notify(?, ?)", notifyChannel, payload).Error
+}
+
+/*
+ * Subscribe registers a handler for every message published by any instance.
+ *
+ * Parameters:
+ *   - handler (func(string, []byte)): Called with the room ID and payload of each message.
+ *
+ * Returns:
+ *   - None.
+ */
+func (b *PostgresBroadcaster) Subscribe(handler func(roomID string, message []byte)) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.handlers = append(b.handlers, handler)
+}
+
+/*
+ * Listen receives notifications until ctx is cancelled, reconnecting whenever the
+ * listener connection drops. It blocks and is meant to run on its own goroutine.
+ * Messages published while disconnected are lost.
+ *
+ * Parameters:
+ *   - ctx (context.Context): Stops the listener when cancelled.
+ *
+ * Returns:
+ *   - None.
+ */
+func (b *PostgresBroadcaster) Listen(ctx context.Context) {
+	for ctx.Err() == nil {
+		if err := b.listenOnce(ctx); err != nil && ctx.Err() == nil {
+			log.Printf("ERROR: Room event listener stopped: %v", err)
+		}
+
+		select {
+		case <-ctx.Done():
+		case <-time.After(relistenDelay):
+		}
+	}
+}
+
+/*
+ * listenOnce opens a listener connection and dispatches notifications until it fails.
+ *
+ * Parameters:
+ *   - ctx (context.Context): Stops the listener when cancelled.
+ *
+ * Returns:
+ *   - error: The error that ended the connection.
+ */
+func (b *PostgresBroadcaster) listenOnce(ctx context.Context) error {
+	conn, err := pgx.Connect(ctx, b.dsn)
+	if err != nil {
+		return err
+	}
+	defer conn.Close(context.Background())
+
+	if _, err := conn.Exec(ctx, "LISTEN "+pgx.Identifier{notifyChannel}.Sanitize()); err != nil {
+		return err
+	}
+	log.Printf("INFO: Listening for room events on channel %s", notifyChannel)
+
+	for {
+		notification, err := conn.WaitForNotification(ctx)
+		if err != nil {
+			return err
+		}
+
+		roomID, message, ok := decodePayload(notification.Payload)
+		if !ok {
+			log.Printf("WARN: Ignoring malformed room event payload")
+			continue
+		}
+
+		b.mu.RLock()
+		for _, handler := range b.handlers {
+			handler(roomID, message)
+		}
+		b.mu.RUnlock()
+	}
+}
+
+/*
+ * encodePayload prefixes the message with its room ID as "<len>:<roomID><message>",
+ * so room IDs may contain any character.
+ *
+ * Parameters:
+ *   - roomID (string): The room the message belongs to.
+ *   - message ([]byte): The message payload.
+ *
+ * Returns:
+ *   - string: The notification payload.
+ */
+func encodePayload(roomID string, message []byte) string {
+	return strconv.Itoa(len(roomID)) + ":" + roomID + string(message)
+}
+
+/*
+ * decodePayload splits a notification payload built by encodePayload.
+ *
+ * Parameters:
+ *   - payload (string): The notification payload.
+ *
+ * Returns:
+ *   - string: The room ID.
+ *   - []byte: The message payload.
+ *   - bool: False if the payload is malformed.
+ */
+func decodePayload(payload string) (string, []byte, bool) {
+	prefix, rest, ok := strings.Cut(payload, ":")
+	if !ok {
+		return "", nil, false
+	}
+	n, err := strconv.Atoi(prefix)
+	if err != nil || n < 0 || n > len(rest) {
+		return "", nil, false
+	}
+	return rest[:n], []byte(rest[n:]), true
+}
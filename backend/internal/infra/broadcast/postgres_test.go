@@ -0,0 +1,56 @@
+/*
+ * file: postgres_test.go
+ * package: broadcast
+ * description:
+ *     Tests for the notification payload format: room IDs of any content round-trip,
+ *     and malformed payloads are rejected instead of misrouted.
+ */
+
+package broadcast
+
+import "testing"
+
+func TestPayloadRoundTrip(t *testing.T) {
+	tests := []struct {
+		name    string
+		roomID  string
+		message string
+	}{
+		{"plain room", "room-1", `{"type":"moveApplied"}`},
+		{"lobby channel", "", `{"type":"roomUpdated"}`},
+		{"room ID with a colon", "a:b", `{}`},
+		{"room ID of digits", "42", `7:not a prefix`},
+		{"empty message", "room-1", ""},
+		{"multibyte room ID", "sala-ñandú", "ok"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			roomID, message, ok := decodePayload(encodePayload(tt.roomID, []byte(tt.message)))
+			if !ok || roomID != tt.roomID || string(message) != tt.message {
+				t.Errorf("round trip = %q, %q, %v; want %q, %q", roomID, message, ok, tt.roomID, tt.message)
+			}
+		})
+	}
+}
+
+func TestDecodeMalformedPayload(t *testing.T) {
+	tests := []struct {
+		name    string
+		payload string
+	}{
+		{"empty", ""},
+		{"no separator", "room-1{}"},
+		{"length not a number", "x:room-1{}"},
+		{"negative length", "-1:room-1{}"},
+		{"length past the end", "9:room"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if roomID, message, ok := decodePayload(tt.payload); ok {
+				t.Errorf("decodePayload(%q) = %q, %q, want rejected", tt.payload, roomID, message)
+			}
+		})
+	}
+}
@@ -12,10 +12,13 @@ package repository
 
 import (
 	"errors"
+	"time"
 
 	"github.com/juan10024/tictactoe-test/internal/core/domain"
+	"github.com/juan10024/tictactoe-test/internal/core/ports"
 
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 /*
@@ -30,16 +33,82 @@ type GormGameRepository struct {
 }
 
 /*
- * UpdatePlayer persists an existing player's updated fields to the database.
+ * UpdatePlayer persists an existing player's updated fields to the database,
+ * provided nobody else updated the player since it was read.
  *
  * Parameters:
  *   - player (*domain.Player): The player entity with updated values.
  *
  * Returns:
- *   - error: An error if the update fails, otherwise nil.
+ *   - error: A *ports.ConflictError if the player's version changed, an error if the update fails, otherwise nil.
  */
 func (r *GormGameRepository) UpdatePlayer(player *domain.Player) error {
-	return r.db.Save(player).Error
+	return updateVersioned(r.db, player, "player", player.ID, &player.Version)
+}
+
+/*
+ * UpdateRatings saves the rating fields of the given players and appends their
+ * rating history entries in a single transaction.
+ *
+ * Parameters:
+ *   - players ([]*domain.Player): The players with their new ratings.
+ *   - history ([]domain.RatingHistory): The history entries to append.
+ *
+ * Returns:
+ *   - error: A *ports.ConflictError if a player's version changed, or an error if any
+ *     write fails; nothing is persisted in either case.
+ */
+func (r *GormGameRepository) UpdateRatings(players []*domain.Player, history []domain.RatingHistory) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		for _, p := range players {
+			err := updateVersioned(tx, p, "player", p.ID, &p.Version, "rating", "rating_deviation", "rating_volatility")
+			if err != nil {
+				return err
+			}
+		}
+		if len(history) == 0 {
+			return nil
+		}
+		return tx.Create(&history).Error
+	})
+}
+
+/*
+ * updateVersioned writes a game or player only if its version is still the one that
+ * was read, and bumps the version. On failure the in-memory version is left untouched.
+ *
+ * Parameters:
+ *   - tx (*gorm.DB): The connection or transaction to write with.
+ *   - model (interface{}): Pointer to the game, player or room to save.
+ *   - entity (string): The entity name reported in a conflict.
+ *   - id (interface{}): The record's primary key.
+ *   - version (*int64): The record's Version field.
+ *   - columns (...string): The columns to write; all of them when empty.
+ *
+ * Returns:
+ *   - error: A *ports.ConflictError if no row had the expected version, or an error if the update fails.
+ */
+func updateVersioned(tx *gorm.DB, model interface{}, entity string, id interface{}, version *int64, columns ...string) error {
+	read := *version
+	*version = read + 1
+
+	query := tx.Omit(clause.Associations).Where("version = ?", read)
+	if len(columns) > 0 {
+		query = query.Select(append(columns, "version"))
+	} else {
+		query = query.Select("*").Omit("id", "created_at", clause.Associations)
+	}
+
+	result := query.Updates(model)
+	if result.Error != nil {
+		*version = read
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		*version = read
+		return &ports.ConflictError{Entity: entity, ID: id, Version: read}
+	}
+	return nil
 }
 
 /*
@@ -69,33 +138,101 @@ func (r *GormGameRepository) Create(game *domain.Game) error {
 }
 
 /*
- * Update saves the updated fields of a game record into the database.
+ * Update saves the updated fields of a game record into the database,
+ * provided nobody else updated the game since it was read.
  *
  * Parameters:
  *   - game (*domain.Game): The game entity with modifications.
  *
  * Returns:
- *   - error: An error if the update fails, otherwise nil.
+ *   - error: A *ports.ConflictError if the game's version changed, an error if the update fails, otherwise nil.
  */
 func (r *GormGameRepository) Update(game *domain.Game) error {
-	return r.db.Save(game).Error
+	return updateVersioned(r.db, game, "game", game.ID, &game.Version)
 }
 
 /*
- * GetByRoomID retrieves a game by its associated RoomID.
+ * RecordMove saves the updated game and inserts the move that produced it
+ * in a single transaction, so the board and the move log never diverge.
  *
  * Parameters:
- *   - roomID (string): The unique identifier of the room.
+ *   - game (*domain.Game): The game entity after the move was applied.
+ *   - move (*domain.GameMove): The move to record.
+ *
+ * Returns:
+ *   - error: A *ports.ConflictError if the game's version changed, or an error if either
+ *     write fails; nothing is persisted in either case.
+ */
+func (r *GormGameRepository) RecordMove(game *domain.Game, move *domain.GameMove) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := updateVersioned(tx, game, "game", game.ID, &game.Version); err != nil {
+			return err
+		}
+		move.GameID = game.ID
+		return tx.Omit(clause.Associations).Create(move).Error
+	})
+}
+
+/*
+ * GetByID retrieves a game by its unique ID, including its players and winner.
+ *
+ * Parameters:
+ *   - id (uint): The game's ID.
  *
  * Returns:
  *   - *domain.Game: The matching game entity.
- *   - error: An error if the query fails or the record is not found.
+ *   - error: ports.ErrNotFound if no game has that ID, or an error if the query fails.
+ */
+func (r *GormGameRepository) GetByID(id uint) (*domain.Game, error) {
+	var game domain.Game
+	err := r.db.Preload("PlayerX").Preload("PlayerO").Preload("Winner").First(&game, id).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ports.ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &game, nil
+}
+
+/*
+ * GetMovesByGameID retrieves every move of a game in the order they were played.
+ *
+ * Parameters:
+ *   - gameID (uint): The game's ID.
+ *
+ * Returns:
+ *   - []domain.GameMove: The moves, ordered by move number.
+ *   - error: An error if the query fails.
+ */
+func (r *GormGameRepository) GetMovesByGameID(gameID uint) ([]domain.GameMove, error) {
+	var moves []domain.GameMove
+	err := r.db.Preload("Player").
+		Where("game_id = ?", gameID).
+		Order("move_number ASC").
+		Find(&moves).Error
+	if err != nil {
+		return nil, err
+	}
+	return moves, nil
+}
+
+/*
+ * GetByRoomID retrieves the current game of a room.
+ *
+ * Parameters:
+ *   - roomID (string): The unique identifier of the room.
+ *
+ * Returns:
+ *   - *domain.Game: The game the room's current_game_id points to.
+ *   - error: An error if the query fails or the room has no game.
  */
 func (r *GormGameRepository) GetByRoomID(roomID string) (*domain.Game, error) {
+	current := r.db.Model(&domain.Room{}).Select("current_game_id").Where("id = ?", roomID)
+
 	var game domain.Game
 	err := r.db.Preload("PlayerX").Preload("PlayerO").
-		Where("room_id = ?", roomID).
-		Order("created_at DESC").
+		Where("id = (?)", current).
 		First(&game).Error
 	return &game, err
 }
@@ -116,6 +253,57 @@ func (r *GormGameRepository) GetOrCreatePlayerByName(name string) (*domain.Playe
 	return &player, err
 }
 
+/*
+ * GetPlayerByName retrieves a player by their exact name.
+ *
+ * Parameters:
+ *   - name (string): The player's name.
+ *
+ * Returns:
+ *   - *domain.Player: The matching player.
+ *   - error: ports.ErrNotFound if no player has that name, or an error if the query fails.
+ */
+func (r *GormGameRepository) GetPlayerByName(name string) (*domain.Player, error) {
+	var player domain.Player
+	err := r.db.Where("name = ?", name).First(&player).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ports.ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &player, nil
+}
+
+/*
+ * CreatePlayer inserts a new player record into the database.
+ *
+ * Parameters:
+ *   - player (*domain.Player): The player entity to persist.
+ *
+ * Returns:
+ *   - error: An error if creation fails, e.g. because the name is taken.
+ */
+func (r *GormGameRepository) CreatePlayer(player *domain.Player) error {
+	return r.db.Create(player).Error
+}
+
+/*
+ * GetOrCreateBotPlayer retrieves the player row of a computer opponent, creating it if needed.
+ *
+ * Parameters:
+ *   - name (string): The bot's display name.
+ *
+ * Returns:
+ *   - *domain.Player: The bot player.
+ *   - error: An error if the operation fails.
+ */
+func (r *GormGameRepository) GetOrCreateBotPlayer(name string) (*domain.Player, error) {
+	var player domain.Player
+	err := r.db.Where(domain.Player{Name: name}).Attrs(domain.Player{IsBot: true}).FirstOrCreate(&player).Error
+	return &player, err
+}
+
 /*
  * GetPlayerByID retrieves a player by their unique ID.
  *
@@ -159,6 +347,49 @@ func (r *GormGameRepository) GetFinishedGamesByRoomID(roomID string) ([]domain.G
 	return games, nil
 }
 
+/*
+ * GetWaitingGamesBefore retrieves the games still waiting for an opponent that were created before a given instant.
+ *
+ * Parameters:
+ *   - before (time.Time): Only games created earlier than this are returned.
+ *
+ * Returns:
+ *   - []domain.Game: The stale waiting games.
+ *   - error: An error if the query fails.
+ */
+func (r *GormGameRepository) GetWaitingGamesBefore(before time.Time) ([]domain.Game, error) {
+	var games []domain.Game
+	err := r.db.Where("status = ? AND created_at < ?", "waiting", before).
+		Order("created_at ASC").
+		Find(&games).Error
+	if err != nil {
+		return nil, err
+	}
+	return games, nil
+}
+
+/*
+ * GetOpenGames retrieves the current game of every open room that is waiting for
+ * an opponent or being played, newest first.
+ *
+ * Returns:
+ *   - []domain.Game: The open games, with both players preloaded.
+ *   - error: An error if the query fails.
+ */
+func (r *GormGameRepository) GetOpenGames() ([]domain.Game, error) {
+	current := r.db.Model(&domain.Room{}).Select("current_game_id").Where("closed_at IS NULL")
+
+	var games []domain.Game
+	err := r.db.Preload("PlayerX").Preload("PlayerO").
+		Where("status IN ? AND id IN (?)", []string{"waiting", "in_progress"}, current).
+		Order("created_at DESC").
+		Find(&games).Error
+	if err != nil {
+		return nil, err
+	}
+	return games, nil
+}
+
 /*
  * GormStatsRepository is the GORM implementation of the StatsRepository port.
  *
@@ -183,21 +414,46 @@ func NewGormStatsRepository(db *gorm.DB) *GormStatsRepository {
 }
 
 /*
- * GetTopPlayers retrieves the top players ranked by number of wins.
+ * GetTopPlayers retrieves the top players ranked by rating.
  *
  * Parameters:
  *   - limit (int): The maximum number of players to retrieve.
+ *   - minGames (int): The minimum number of finished games a player needs to be ranked.
  *
  * Returns:
  *   - []domain.Player: The list of top players.
  *   - error: An error if the query fails.
  */
-func (r *GormStatsRepository) GetTopPlayers(limit int) ([]domain.Player, error) {
+func (r *GormStatsRepository) GetTopPlayers(limit, minGames int) ([]domain.Player, error) {
 	var players []domain.Player
-	err := r.db.Order("wins desc").Limit(limit).Find(&players).Error
+	err := r.db.Where("wins + draws + losses >= ?", minGames).
+		Order("rating desc").Order("wins desc").
+		Limit(limit).
+		Find(&players).Error
 	return players, err
 }
 
+/*
+ * GetRatingHistory retrieves a player's rating after each rated game, oldest first.
+ *
+ * Parameters:
+ *   - playerID (uint): The player's ID.
+ *
+ * Returns:
+ *   - []domain.RatingHistory: The player's rating history.
+ *   - error: An error if the query fails.
+ */
+func (r *GormStatsRepository) GetRatingHistory(playerID uint) ([]domain.RatingHistory, error) {
+	var history []domain.RatingHistory
+	err := r.db.Where("player_id = ?", playerID).
+		Order("created_at ASC").Order("id ASC").
+		Find(&history).Error
+	if err != nil {
+		return nil, err
+	}
+	return history, nil
+}
+
 /*
  * CountGames returns the total number of games played.
  *
@@ -246,6 +502,28 @@ func (r *GormStatsRepository) GetGamesByRoomID(roomID string) ([]domain.Game, er
 	return games, nil
 }
 
+/*
+ * GetSeriesByRoomID retrieves the finished series of a room, newest first.
+ *
+ * Parameters:
+ *   - roomID (string): The room identifier.
+ *
+ * Returns:
+ *   - []domain.SeriesResult: The room's series results, with both players preloaded.
+ *   - error: An error if the query fails.
+ */
+func (r *GormStatsRepository) GetSeriesByRoomID(roomID string) ([]domain.SeriesResult, error) {
+	var series []domain.SeriesResult
+	result := r.db.Preload("PlayerOne").Preload("PlayerTwo").
+		Where("room_id = ?", roomID).
+		Order("created_at DESC").
+		Find(&series)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	return series, nil
+}
+
 /*
  * GetPlayerByName retrieves a player by their exact name.
  *
@@ -0,0 +1,161 @@
+/*
+ * file: repository_test.go
+ * package: repository
+ * description:
+ *     Tests for versioned updates: the UPDATE only matches the version that was read,
+ *     bumps it on success, and reports a conflict without touching the in-memory
+ *     version when no row matched. Runs against a fake connection, without a database.
+ */
+
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/juan10024/tictactoe-test/internal/core/domain"
+	"github.com/juan10024/tictactoe-test/internal/core/ports"
+
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+/*
+ * fakePool is a gorm.ConnPool that records the statements executed and answers them
+ * with a fixed number of affected rows or a fixed error.
+ *
+ * Fields:
+ *   - rowsAffected (int64): The rows every statement reports as changed.
+ *   - err (error): The error every statement fails with, if any.
+ *   - statements ([]string): The SQL executed so far.
+ *   - args ([][]interface{}): The arguments of each statement.
+ */
+type fakePool struct {
+	rowsAffected int64
+	err          error
+	statements   []string
+	args         [][]interface{}
+}
+
+func (p *fakePool) ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error) {
+	p.statements = append(p.statements, query)
+	p.args = append(p.args, args)
+	if p.err != nil {
+		return nil, p.err
+	}
+	return driver.RowsAffected(p.rowsAffected), nil
+}
+
+func (p *fakePool) PrepareContext(ctx context.Context, query string) (*sql.Stmt, error) {
+	return nil, errors.New("fakePool: prepared statements are not supported")
+}
+
+func (p *fakePool) QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error) {
+	return nil, errors.New("fakePool: queries are not supported")
+}
+
+func (p *fakePool) QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row {
+	return nil
+}
+
+// openFake opens a GORM connection whose statements go to pool.
+func openFake(t *testing.T, pool *fakePool) *gorm.DB {
+	t.Helper()
+	db, err := gorm.Open(postgres.New(postgres.Config{Conn: pool}), &gorm.Config{
+		SkipDefaultTransaction: true,
+		Logger:                 logger.Default.LogMode(logger.Silent),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return db
+}
+
+func TestVersionedUpdates(t *testing.T) {
+	type update struct {
+		entity  string
+		id      interface{}
+		version func() *int64
+		apply   func(db *gorm.DB) error
+	}
+	updates := map[string]func() update{
+		"game": func() update {
+			game := &domain.Game{RoomID: "room-1", Version: 3}
+			game.ID = 9
+			return update{"game", uint(9), func() *int64 { return &game.Version }, func(db *gorm.DB) error {
+				return NewGormGameRepository(db).Update(game)
+			}}
+		},
+		"player": func() update {
+			player := &domain.Player{Name: "alice", Version: 3}
+			player.ID = 7
+			return update{"player", uint(7), func() *int64 { return &player.Version }, func(db *gorm.DB) error {
+				return NewGormGameRepository(db).UpdatePlayer(player)
+			}}
+		},
+		"room": func() update {
+			room := &domain.Room{ID: "room-1", Version: 3}
+			return update{"room", "room-1", func() *int64 { return &room.Version }, func(db *gorm.DB) error {
+				return NewGormRoomRepository(db).UpdateRoom(room)
+			}}
+		},
+	}
+
+	dbErr := errors.New("connection reset")
+	tests := []struct {
+		name         string
+		rowsAffected int64
+		err          error
+		wantVersion  int64
+		wantConflict bool
+		wantErr      error
+	}{
+		{"version matched", 1, nil, 4, false, nil},
+		{"version changed", 0, nil, 3, true, nil},
+		{"statement failed", 0, dbErr, 3, false, dbErr},
+	}
+
+	for entity, newUpdate := range updates {
+		for _, tt := range tests {
+			t.Run(entity+"/"+tt.name, func(t *testing.T) {
+				pool := &fakePool{rowsAffected: tt.rowsAffected, err: tt.err}
+				u := newUpdate()
+				err := u.apply(openFake(t, pool))
+
+				var conflict *ports.ConflictError
+				switch {
+				case tt.wantConflict:
+					if !errors.As(err, &conflict) || !errors.Is(err, ports.ErrConflict) {
+						t.Fatalf("error = %v, want a *ports.ConflictError", err)
+					}
+					if conflict.Entity != u.entity || conflict.ID != u.id || conflict.Version != 3 {
+						t.Errorf("conflict = %+v, want %s %v at version 3", *conflict, u.entity, u.id)
+					}
+				case !errors.Is(err, tt.wantErr):
+					t.Fatalf("error = %v, want %v", err, tt.wantErr)
+				case errors.As(err, &conflict):
+					t.Fatalf("error = %v, want no conflict", err)
+				}
+				if got := *u.version(); got != tt.wantVersion {
+					t.Errorf("in-memory version = %d, want %d", got, tt.wantVersion)
+				}
+
+				if len(pool.statements) != 1 {
+					t.Fatalf("%d statements executed, want 1", len(pool.statements))
+				}
+				statement, args := pool.statements[0], pool.args[0]
+				if !strings.HasPrefix(statement, "UPDATE") || !strings.Contains(statement, "WHERE version = $") {
+					t.Errorf("statement = %s, want an UPDATE guarded by the version", statement)
+				}
+				if version, id := args[len(args)-2], args[len(args)-1]; version != int64(3) || id != u.id {
+					t.Errorf("statement guards on version %v of %v, want version 3 of %v", version, id, u.id)
+				}
+			})
+		}
+	}
+}
@@ -0,0 +1,126 @@
+/*
+ * file: room_repository.go
+ * package: repository
+ * description:
+ *     Provides the GORM implementation of the RoomRepository port, which stores
+ *     each room's settings, seats, series score and current game.
+ */
+
+package repository
+
+import (
+	"errors"
+
+	"github.com/juan10024/tictactoe-test/internal/core/domain"
+	"github.com/juan10024/tictactoe-test/internal/core/ports"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+/*
+ * GormRoomRepository is the GORM implementation of the RoomRepository port.
+ */
+type GormRoomRepository struct {
+	db *gorm.DB
+}
+
+/*
+ * NewGormRoomRepository constructs a new GormRoomRepository instance.
+ *
+ * Parameters:
+ *   - db (*gorm.DB): A GORM database connection instance.
+ *
+ * Returns:
+ *   - *GormRoomRepository: A repository instance bound to the database.
+ */
+func NewGormRoomRepository(db *gorm.DB) *GormRoomRepository {
+	return &GormRoomRepository{db: db}
+}
+
+/*
+ * CreateRoom inserts a room unless another request (possibly on another backend
+ * instance) created it first, in which case the existing room wins.
+ *
+ * Parameters:
+ *   - room (*domain.Room): The room to create.
+ *
+ * Returns:
+ *   - *domain.Room: The stored room, which may differ from the one given.
+ *   - error: An error if the insert or the reload fails.
+ */
+func (r *GormRoomRepository) CreateRoom(room *domain.Room) (*domain.Room, error) {
+	if err := r.db.Clauses(clause.OnConflict{DoNothing: true}).Create(room).Error; err != nil {
+		return nil, err
+	}
+	return r.GetRoom(room.ID)
+}
+
+/*
+ * GetRoom retrieves a room by its ID.
+ *
+ * Parameters:
+ *   - id (string): The room ID.
+ *
+ * Returns:
+ *   - *domain.Room: The matching room.
+ *   - error: ports.ErrNotFound if the room does not exist, or an error if the query fails.
+ */
+func (r *GormRoomRepository) GetRoom(id string) (*domain.Room, error) {
+	var room domain.Room
+	err := r.db.Where("id = ?", id).First(&room).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ports.ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &room, nil
+}
+
+/*
+ * GetRooms retrieves the rooms with the given IDs; unknown IDs are skipped.
+ *
+ * Parameters:
+ *   - ids ([]string): The room IDs.
+ *
+ * Returns:
+ *   - []domain.Room: The matching rooms, in no particular order.
+ *   - error: An error if the query fails.
+ */
+func (r *GormRoomRepository) GetRooms(ids []string) ([]domain.Room, error) {
+	var rooms []domain.Room
+	if len(ids) == 0 {
+		return rooms, nil
+	}
+	if err := r.db.Where("id IN ?", ids).Find(&rooms).Error; err != nil {
+		return nil, err
+	}
+	return rooms, nil
+}
+
+/*
+ * UpdateRoom saves a room, provided nobody else updated it since it was read.
+ *
+ * Parameters:
+ *   - room (*domain.Room): The room with its updated fields.
+ *
+ * Returns:
+ *   - error: A *ports.ConflictError if the room's version changed, an error if the update fails, otherwise nil.
+ */
+func (r *GormRoomRepository) UpdateRoom(room *domain.Room) error {
+	return updateVersioned(r.db, room, "room", room.ID, &room.Version)
+}
+
+/*
+ * CreateSeriesResult inserts the result of a finished series.
+ *
+ * Parameters:
+ *   - result (*domain.SeriesResult): The series result to persist.
+ *
+ * Returns:
+ *   - error: An error if creation fails, otherwise nil.
+ */
+func (r *GormRoomRepository) CreateSeriesResult(result *domain.SeriesResult) error {
+	return r.db.Omit(clause.Associations).Create(result).Error
+}
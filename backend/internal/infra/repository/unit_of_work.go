@@ -0,0 +1,53 @@
+/*
+ * file: unit_of_work.go
+ * package: repository
+ * description:
+ *     Provides the GORM implementation of the ports.UnitOfWork port on top of
+ *     db.Transaction.
+ */
+
+package repository
+
+import (
+	"github.com/juan10024/tictactoe-test/internal/core/ports"
+
+	"gorm.io/gorm"
+)
+
+/*
+ * GormUnitOfWork runs repository writes inside a database transaction.
+ */
+type GormUnitOfWork struct {
+	db *gorm.DB
+}
+
+/*
+ * NewGormUnitOfWork constructs a new GormUnitOfWork instance.
+ *
+ * Parameters:
+ *   - db (*gorm.DB): A GORM database connection instance.
+ *
+ * Returns:
+ *   - *GormUnitOfWork: A unit of work bound to the database.
+ */
+func NewGormUnitOfWork(db *gorm.DB) *GormUnitOfWork {
+	return &GormUnitOfWork{db: db}
+}
+
+/*
+ * Do runs fn with a GormGameRepository and a GormRoomRepository bound to a new
+ * transaction, committing it if fn succeeds and rolling it back otherwise.
+ * Transactions the repositories open themselves (e.g. in RecordMove) become
+ * savepoints of this one.
+ *
+ * Parameters:
+ *   - fn (func(ports.GameRepository, ports.RoomRepository) error): The writes to run atomically.
+ *
+ * Returns:
+ *   - error: The error returned by fn, or an error if the transaction cannot be committed.
+ */
+func (u *GormUnitOfWork) Do(fn func(games ports.GameRepository, rooms ports.RoomRepository) error) error {
+	return u.db.Transaction(func(tx *gorm.DB) error {
+		return fn(NewGormGameRepository(tx), NewGormRoomRepository(tx))
+	})
+}
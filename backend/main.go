@@ -10,14 +10,22 @@
 package main
 
 import (
+	"context"
+	"crypto/rand"
 	"log"
 	"net/http"
+	"os"
 	"time"
 
 	"github.com/juan10024/tictactoe-test/internal/adapters/db"
 	"github.com/juan10024/tictactoe-test/internal/adapters/handlers"
+	"github.com/juan10024/tictactoe-test/internal/core/ports"
+	"github.com/juan10024/tictactoe-test/internal/core/rules"
 	"github.com/juan10024/tictactoe-test/internal/core/services"
+	"github.com/juan10024/tictactoe-test/internal/infra/auth"
+	"github.com/juan10024/tictactoe-test/internal/infra/broadcast"
 	"github.com/juan10024/tictactoe-test/internal/infra/repository"
+	"gorm.io/gorm"
 )
 
 /*
@@ -46,35 +54,39 @@ func main() {
 	// Dependency Injection
 	gameRepo := repository.NewGormGameRepository(dbConn)
 	statsRepo := repository.NewGormStatsRepository(dbConn)
+	roomRepo := repository.NewGormRoomRepository(dbConn)
+	secret := authSecret()
+	hasher := auth.NewBcryptHasher()
 
-	hub := services.NewHub()
+	broadcaster := roomBroadcaster(dbConn)
+	hub := services.NewHub(durationFromEnv("RECONNECT_GRACE_PERIOD", 30*time.Second), broadcaster)
 	go hub.Run()
 
-	gameService := services.NewGameService(gameRepo)
+	roomService := services.NewRoomService(roomRepo, hasher, auth.NewHMACInviteIssuer(secret))
+	gameService := services.NewGameService(gameRepo, repository.NewGormUnitOfWork(dbConn), roomService, rules.NewClassic(), rules.NewGomoku(), rules.NewUltimate())
+	lobby := services.NewLobby(hub, gameService, broadcaster)
 	statsService := services.NewStatsService(statsRepo)
+	authService := services.NewAuthService(gameRepo, auth.NewHMACTokenIssuer(secret), hasher)
 
-	// Handler & Router Configuration
-	gameHandler := handlers.NewGameHandler(gameService, hub)
-	_ = gameHandler
+	go services.RunStaleGameJanitor(hub, gameService, durationFromEnv("WAITING_GAME_TTL", 30*time.Minute))
 
-	statsHandler := handlers.NewStatsHandler(statsService)
-	wsHandler := handlers.NewWebSocketHandler(hub, gameService)
-	roomHandler := handlers.NewRoomHandler(gameService)
+	matchmaker := services.NewMatchmaker(gameService)
+	go matchmaker.Run(time.Second)
 
-	// Router registration
-	router := http.NewServeMux()
+	// Handler & Router Configuration
+	router := handlers.NewRouter(handlers.Handlers{
+		Auth:        handlers.NewAuthHandler(authService),
+		Stats:       handlers.NewStatsHandler(statsService),
+		Rooms:       handlers.NewRoomHandler(gameService, roomService, authService),
+		Games:       handlers.NewGameHandler(gameService, hub),
+		Lobby:       handlers.NewLobbyHandler(lobby),
+		WebSocket:   handlers.NewWebSocketHandler(hub, gameService, authService),
+		Matchmaking: handlers.NewMatchmakingHandler(matchmaker, authService),
+	})
 
 	// Attach CORS middleware
 	corsHandler := corsMiddleware(router)
 
-	// Register endpoints
-	router.HandleFunc("/ws/join/", wsHandler.HandleConnection)
-	router.HandleFunc("/api/stats/ranking", statsHandler.GetRanking)
-	router.HandleFunc("/api/stats/general", statsHandler.GetGeneralStats)
-	router.HandleFunc("/api/stats/player", statsHandler.GetPlayerStats)
-	router.HandleFunc("/api/rooms/history/", statsHandler.GetGameHistory)
-	router.HandleFunc("/api/rooms/join/", roomHandler.JoinRoom)
-
 	// HTTP Server Configuration & Launch
 	server := &http.Server{
 		Addr:         ":8080",
@@ -90,6 +102,78 @@ func main() {
 	}
 }
 
+/*
+ * durationFromEnv reads a duration (e.g. "30s", "10m") from an environment variable.
+ *
+ * Parameters:
+ *   - key (string): The environment variable name.
+ *   - fallback (time.Duration): The value used when the variable is unset or invalid.
+ *
+ * Returns:
+ *   - time.Duration: The configured duration.
+ */
+func durationFromEnv(key string, fallback time.Duration) time.Duration {
+	raw := os.Getenv(key)
+	if raw == "" {
+		return fallback
+	}
+	d, err := time.ParseDuration(raw)
+	if err != nil || d < 0 {
+		log.Printf("WARN: Invalid %s %q, using %s", key, raw, fallback)
+		return fallback
+	}
+	return d
+}
+
+/*
+ * authSecret reads the key signing session tokens and room invites from AUTH_SECRET.
+ * Without it a random key is generated, so tokens do not survive a restart.
+ *
+ * Parameters:
+ *   - None.
+ *
+ * Returns:
+ *   - []byte: The signing key.
+ */
+func authSecret() []byte {
+	if secret := os.Getenv("AUTH_SECRET"); secret != "" {
+		return []byte(secret)
+	}
+
+	log.Println("WARN: AUTH_SECRET is not set, using a random key; sessions will not survive a restart")
+	secret := make([]byte, 32)
+	if _, err := rand.Read(secret); err != nil {
+		log.Fatalf("FATAL: Could not generate a session signing key: %v", err)
+	}
+	return secret
+}
+
+/*
+ * roomBroadcaster selects how room messages reach clients from ROOM_BROADCASTER:
+ * "memory" (default) keeps them within this instance, "postgres" shares them with
+ * every instance connected to the same database through LISTEN/NOTIFY.
+ *
+ * Parameters:
+ *   - dbConn (*gorm.DB): The database connection pool.
+ *
+ * Returns:
+ *   - ports.RoomBroadcaster: The configured broadcaster, already listening.
+ */
+func roomBroadcaster(dbConn *gorm.DB) ports.RoomBroadcaster {
+	switch mode := os.Getenv("ROOM_BROADCASTER"); mode {
+	case "", "memory":
+		return broadcast.NewMemoryBroadcaster()
+	case "postgres":
+		broadcaster := broadcast.NewPostgresBroadcaster(dbConn, db.DSNFromEnv())
+		go broadcaster.Listen(context.Background())
+		log.Println("INFO: Sharing rooms across instances through Postgres LISTEN/NOTIFY.")
+		return broadcaster
+	default:
+		log.Fatalf("FATAL: Unknown ROOM_BROADCASTER %q, expected memory or postgres", mode)
+		return nil
+	}
+}
+
 /*
  * corsMiddleware adds CORS (Cross-Origin Resource Sharing) headers to HTTP responses.
  *
@@ -104,6 +188,7 @@ func corsMiddleware(next http.Handler) http.Handler {
 		w.Header().Set("Access-Control-Allow-Origin", "*")
 		w.Header().Set("Access-Control-Allow-Methods", "GET, POST, PUT, DELETE, OPTIONS")
 		w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization")
+		w.Header().Set("Access-Control-Expose-Headers", "Deprecation, Link")
 
 		if r.Method == "OPTIONS" {
 			w.WriteHeader(http.StatusOK)
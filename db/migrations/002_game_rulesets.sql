@@ -0,0 +1,15 @@
+/*
+ * file: 002_game_rulesets.sql
+ * package: migrations
+ * description:
+ *     Records which ruleset each game uses and lets the board column hold
+ *     boards of any size, so variants other than the classic 3x3 game can be stored.
+ */
+
+-- Ruleset identifier resolved by the GameService (e.g. "classic").
+ALTER TABLE games ADD COLUMN IF NOT EXISTS ruleset VARCHAR(20) NOT NULL DEFAULT 'classic';
+
+-- The board encoding is owned by the ruleset and is no longer fixed to nine cells.
+-- Casting CHAR(9) to TEXT drops trailing blanks, which are empty cells here, so the
+-- existing boards are padded back to their nine cells.
+ALTER TABLE games ALTER COLUMN board TYPE TEXT USING rpad(board::text, 9);
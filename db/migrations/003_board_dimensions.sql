@@ -0,0 +1,16 @@
+/*
+ * file: 003_board_dimensions.sql
+ * package: migrations
+ * description:
+ *     Stores the board size and win length of each game so rooms can be created
+ *     with arbitrary N x M boards and K-in-a-row win conditions.
+ *     Existing rows default to the classic 3x3, three-in-a-row game.
+ */
+
+ALTER TABLE games ADD COLUMN IF NOT EXISTS board_width INTEGER NOT NULL DEFAULT 3;
+ALTER TABLE games ADD COLUMN IF NOT EXISTS board_height INTEGER NOT NULL DEFAULT 3;
+ALTER TABLE games ADD COLUMN IF NOT EXISTS win_length INTEGER NOT NULL DEFAULT 3;
+
+-- Boards are bounded by the application (at most 25 x 25 cells).
+ALTER TABLE games ADD CONSTRAINT chk_games_board_size
+    CHECK (board_width BETWEEN 3 AND 25 AND board_height BETWEEN 3 AND 25 AND char_length(board) = board_width * board_height);
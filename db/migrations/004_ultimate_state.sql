@@ -0,0 +1,13 @@
+/*
+ * file: 004_ultimate_state.sql
+ * package: migrations
+ * description:
+ *     Adds the extra state needed by the Ultimate (meta) Tic-Tac-Toe ruleset:
+ *     the sub-board the next move is forced into and the result of each sub-board.
+ */
+
+-- NULL when the player to move may choose any undecided sub-board.
+ALTER TABLE games ADD COLUMN IF NOT EXISTS active_board INTEGER CHECK (active_board BETWEEN 0 AND 8);
+
+-- One character per sub-board: 'X', 'O', 'D' (drawn) or ' ' (undecided). Empty for other rulesets.
+ALTER TABLE games ADD COLUMN IF NOT EXISTS sub_board_winners VARCHAR(9) NOT NULL DEFAULT '';
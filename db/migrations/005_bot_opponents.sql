@@ -0,0 +1,14 @@
+/*
+ * file: 005_bot_opponents.sql
+ * package: migrations
+ * description:
+ *     Supports computer opponents. Bots are regular players flagged with is_bot,
+ *     so their games count towards stats and ranking, and each game records the
+ *     bot's difficulty and the symbol it plays.
+ */
+
+ALTER TABLE players ADD COLUMN IF NOT EXISTS is_bot BOOLEAN NOT NULL DEFAULT FALSE;
+
+-- "random", "greedy" or "perfect"; empty for games between humans.
+ALTER TABLE games ADD COLUMN IF NOT EXISTS bot_level VARCHAR(10) NOT NULL DEFAULT '';
+ALTER TABLE games ADD COLUMN IF NOT EXISTS bot_symbol VARCHAR(1) NOT NULL DEFAULT '';
@@ -0,0 +1,12 @@
+/*
+ * file: 006_move_log.sql
+ * package: migrations
+ * description:
+ *     Numbers every recorded move so a game can be replayed ply by ply.
+ *     Moves are written in the same transaction as the board update.
+ */
+
+ALTER TABLE game_moves ADD COLUMN IF NOT EXISTS move_number INTEGER NOT NULL DEFAULT 0;
+
+-- A ply can only be recorded once per game; also serves replay queries ordered by move number.
+CREATE UNIQUE INDEX IF NOT EXISTS idx_game_moves_game_id_move_number ON game_moves(game_id, move_number);
@@ -0,0 +1,29 @@
+/*
+ * file: 007_player_ratings.sql
+ * package: migrations
+ * description:
+ *     Adds Glicko-2 ratings to players and a rating history table.
+ *     The ranking is ordered by rating instead of raw win count, and the
+ *     history allows charting a player's rating over time.
+ */
+
+ALTER TABLE players ADD COLUMN IF NOT EXISTS rating DOUBLE PRECISION NOT NULL DEFAULT 1500;
+ALTER TABLE players ADD COLUMN IF NOT EXISTS rating_deviation DOUBLE PRECISION NOT NULL DEFAULT 350;
+ALTER TABLE players ADD COLUMN IF NOT EXISTS rating_volatility DOUBLE PRECISION NOT NULL DEFAULT 0.06;
+
+-- Index on rating for ranking queries.
+CREATE INDEX IF NOT EXISTS idx_players_rating ON players(rating DESC);
+
+-- Table: rating_history
+-- One row per player per rated game, written in the same transaction as the new rating.
+CREATE TABLE IF NOT EXISTS rating_history (
+    id SERIAL PRIMARY KEY,
+    player_id INTEGER NOT NULL REFERENCES players(id) ON DELETE CASCADE,
+    game_id INTEGER REFERENCES games(id) ON DELETE SET NULL,
+    rating DOUBLE PRECISION NOT NULL,
+    rating_deviation DOUBLE PRECISION NOT NULL,
+    rating_volatility DOUBLE PRECISION NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+-- Index for retrieving a player's history in chronological order.
+CREATE INDEX IF NOT EXISTS idx_rating_history_player_id ON rating_history(player_id, created_at);
@@ -0,0 +1,21 @@
+/*
+ * file: 008_time_controls.sql
+ * package: migrations
+ * description:
+ *     Adds chess-style time controls to games. The server keeps each player's
+ *     remaining time and the instant the current turn started, and records
+ *     why a game ended (e.g. "timeout").
+ */
+
+-- Time control, in seconds: either move_time per move, or base_time plus increment per move.
+ALTER TABLE games ADD COLUMN IF NOT EXISTS move_time INTEGER NOT NULL DEFAULT 0;
+ALTER TABLE games ADD COLUMN IF NOT EXISTS base_time INTEGER NOT NULL DEFAULT 0;
+ALTER TABLE games ADD COLUMN IF NOT EXISTS increment INTEGER NOT NULL DEFAULT 0;
+
+-- Clock state: milliseconds left for each player when the current turn started.
+ALTER TABLE games ADD COLUMN IF NOT EXISTS time_left_x BIGINT NOT NULL DEFAULT 0;
+ALTER TABLE games ADD COLUMN IF NOT EXISTS time_left_o BIGINT NOT NULL DEFAULT 0;
+ALTER TABLE games ADD COLUMN IF NOT EXISTS turn_started_at TIMESTAMPTZ;
+
+-- "win", "draw", "timeout", ...; empty while the game is not finished.
+ALTER TABLE games ADD COLUMN IF NOT EXISTS end_reason VARCHAR(20) NOT NULL DEFAULT '';
@@ -0,0 +1,10 @@
+/*
+ * file: 009_game_expiry.sql
+ * package: migrations
+ * description:
+ *     Supports expiring abandoned rooms: waiting games nobody is connected to are
+ *     moved to the "expired" status by a periodic job that looks them up by age.
+ */
+
+-- Speeds up the periodic lookup of stale waiting games.
+CREATE INDEX IF NOT EXISTS idx_games_status_created_at ON games(status, created_at);
@@ -0,0 +1,9 @@
+/*
+ * file: 010_player_auth.sql
+ * package: migrations
+ * description:
+ *     Adds password-based accounts. A player with a password hash is registered
+ *     and can only be played by logging in; an empty hash marks a guest name.
+ */
+
+ALTER TABLE players ADD COLUMN IF NOT EXISTS password_hash VARCHAR(100) NOT NULL DEFAULT '';
@@ -0,0 +1,10 @@
+/*
+ * file: 011_seat_resume_tokens.sql
+ * package: migrations
+ * description:
+ *     Stores a secret per seat so a player who reconnects to a game in progress
+ *     (e.g. after refreshing the page) can reclaim their X/O seat.
+ */
+
+ALTER TABLE games ADD COLUMN IF NOT EXISTS resume_token_x VARCHAR(64) NOT NULL DEFAULT '';
+ALTER TABLE games ADD COLUMN IF NOT EXISTS resume_token_o VARCHAR(64) NOT NULL DEFAULT '';
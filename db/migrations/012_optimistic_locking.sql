@@ -0,0 +1,11 @@
+/*
+ * file: 012_optimistic_locking.sql
+ * package: migrations
+ * description:
+ *     Adds a version to games and players. Every update is conditional on the
+ *     version it read and increments it, so concurrent writers can no longer
+ *     silently overwrite each other (e.g. lose a win/loss increment).
+ */
+
+ALTER TABLE games ADD COLUMN IF NOT EXISTS version BIGINT NOT NULL DEFAULT 1;
+ALTER TABLE players ADD COLUMN IF NOT EXISTS version BIGINT NOT NULL DEFAULT 1;
@@ -0,0 +1,22 @@
+/*
+ * file: 013_rooms.sql
+ * package: migrations
+ * description:
+ *     Stores each room's settings in their own table instead of deriving rooms
+ *     from games.room_id. A room is public, password-protected or invite-only;
+ *     existing rooms are kept public and owned by the first game's X player.
+ */
+
+CREATE TABLE IF NOT EXISTS rooms (
+    id VARCHAR(50) PRIMARY KEY,
+    owner_id INTEGER REFERENCES players(id) ON DELETE SET NULL,
+    privacy VARCHAR(10) NOT NULL DEFAULT 'public', -- "public", "password", "invite"
+    password_hash VARCHAR(100) NOT NULL DEFAULT '',
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+INSERT INTO rooms (id, owner_id, privacy, created_at)
+SELECT DISTINCT ON (room_id) room_id, player_x_id, 'public', created_at
+FROM games
+ORDER BY room_id, created_at ASC
+ON CONFLICT (id) DO NOTHING;
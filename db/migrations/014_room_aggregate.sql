@@ -0,0 +1,43 @@
+/*
+ * file: 014_room_aggregate.sql
+ * package: migrations
+ * description:
+ *     Makes rooms first-class: a room keeps its two seats, the series score, a
+ *     pointer to its current game (replacing the "latest game by created_at"
+ *     lookup) and when it was closed. Games now reference their room.
+ */
+
+ALTER TABLE rooms ADD COLUMN IF NOT EXISTS player_one_id INTEGER REFERENCES players(id) ON DELETE SET NULL;
+ALTER TABLE rooms ADD COLUMN IF NOT EXISTS player_two_id INTEGER REFERENCES players(id) ON DELETE SET NULL;
+ALTER TABLE rooms ADD COLUMN IF NOT EXISTS player_one_wins INTEGER NOT NULL DEFAULT 0;
+ALTER TABLE rooms ADD COLUMN IF NOT EXISTS player_two_wins INTEGER NOT NULL DEFAULT 0;
+ALTER TABLE rooms ADD COLUMN IF NOT EXISTS draws INTEGER NOT NULL DEFAULT 0;
+ALTER TABLE rooms ADD COLUMN IF NOT EXISTS current_game_id INTEGER REFERENCES games(id) ON DELETE SET NULL;
+ALTER TABLE rooms ADD COLUMN IF NOT EXISTS version BIGINT NOT NULL DEFAULT 1;
+ALTER TABLE rooms ADD COLUMN IF NOT EXISTS updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW();
+ALTER TABLE rooms ADD COLUMN IF NOT EXISTS closed_at TIMESTAMPTZ;
+
+-- Existing rooms point at their latest game and seat its players; expired rooms are closed.
+UPDATE rooms SET
+    current_game_id = latest.id,
+    player_one_id = latest.player_x_id,
+    player_two_id = latest.player_o_id,
+    closed_at = CASE WHEN latest.status = 'expired' THEN latest.updated_at END
+FROM (
+    SELECT DISTINCT ON (room_id) id, room_id, player_x_id, player_o_id, status, updated_at
+    FROM games
+    ORDER BY room_id, created_at DESC
+) AS latest
+WHERE rooms.id = latest.room_id AND rooms.current_game_id IS NULL;
+
+-- Rooms listed in the lobby are the open ones.
+CREATE INDEX IF NOT EXISTS idx_rooms_closed_at ON rooms(closed_at);
+
+ALTER TABLE games DROP CONSTRAINT IF EXISTS fk_games_room;
+ALTER TABLE games ADD CONSTRAINT fk_games_room FOREIGN KEY (room_id) REFERENCES rooms(id);
+
+DROP TRIGGER IF EXISTS set_timestamp ON rooms;
+CREATE TRIGGER set_timestamp
+BEFORE UPDATE ON rooms
+FOR EACH ROW
+EXECUTE PROCEDURE trigger_set_timestamp();
@@ -0,0 +1,24 @@
+/*
+ * file: 015_series.sql
+ * package: migrations
+ * description:
+ *     Lets rooms be played as best-of-3/5/7 series and keeps the result of every
+ *     finished series.
+ */
+
+ALTER TABLE rooms ADD COLUMN IF NOT EXISTS best_of INTEGER NOT NULL DEFAULT 1;
+
+CREATE TABLE IF NOT EXISTS series_results (
+    id SERIAL PRIMARY KEY,
+    room_id VARCHAR(50) NOT NULL REFERENCES rooms(id) ON DELETE CASCADE,
+    best_of INTEGER NOT NULL,
+    player_one_id INTEGER REFERENCES players(id) ON DELETE SET NULL,
+    player_two_id INTEGER REFERENCES players(id) ON DELETE SET NULL,
+    player_one_wins INTEGER NOT NULL DEFAULT 0,
+    player_two_wins INTEGER NOT NULL DEFAULT 0,
+    draws INTEGER NOT NULL DEFAULT 0,
+    winner_id INTEGER REFERENCES players(id) ON DELETE SET NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_series_results_room_id ON series_results(room_id);
@@ -0,0 +1,8 @@
+/*
+ * file: 016_rematch_swap_sides.sql
+ * package: migrations
+ * description:
+ *     Lets a room's creator choose that the players exchange X and O on every rematch.
+ */
+
+ALTER TABLE rooms ADD COLUMN IF NOT EXISTS swap_sides BOOLEAN NOT NULL DEFAULT FALSE;
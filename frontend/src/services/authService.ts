@@ -0,0 +1,104 @@
+/*
+ * file: authService.ts
+ * module: services
+ * description:
+ *    Service functions for the backend session tokens.
+ *    - Registers, logs in, or starts a guest session
+ *    - Keeps the current session in localStorage
+ *    - Provides `ensureSession` to get a token for a player before joining a room
+ *
+ * usage:
+ *    import { ensureSession } from '../services/authService'
+ *
+ *    const token = await ensureSession(playerName)
+ */
+
+// Dependencies
+import { API_URL } from '../../config'
+
+// Interfaces
+
+export interface Session {
+  token: string
+  expiresAt: string
+  guest: boolean
+  player: any
+}
+
+const SESSION_KEY = 'session'
+
+// Service Functions
+
+/**
+ * Read the stored session, if it is still valid
+ * @returns Session | null
+ */
+export const getStoredSession = (): Session | null => {
+  const raw = localStorage.getItem(SESSION_KEY)
+  if (!raw) return null
+
+  try {
+    const session: Session = JSON.parse(raw)
+    return new Date(session.expiresAt) > new Date() ? session : null
+  } catch {
+    return null
+  }
+}
+
+/**
+ * Send credentials to one of the /api/v1/auth endpoints and store the session
+ * @param path - The auth endpoint ("register", "login" or "guest")
+ * @param body - The request body
+ * @param token - A session token to send along, if any
+ * @returns Promise<Session>
+ * @throws Error if the request is rejected
+ */
+const requestSession = async (path: string, body: object, token?: string): Promise<Session> => {
+  const response = await fetch(`${API_URL}/api/v1/auth/${path}`, {
+    method: 'POST',
+    headers: {
+      'Content-Type': 'application/json',
+      ...(token ? { Authorization: `Bearer ${token}` } : {}),
+    },
+    body: JSON.stringify(body),
+  })
+
+  const data = await response.json()
+  if (!response.ok) {
+    throw new Error(data.error || 'Authentication failed')
+  }
+
+  localStorage.setItem(SESSION_KEY, JSON.stringify(data))
+  return data
+}
+
+/**
+ * Register a player name with a password; a name played as a guest is claimed
+ * with the stored guest session
+ */
+export const register = (playerName: string, password: string) => {
+  const session = getStoredSession()
+  const guestToken = session?.guest && session.player?.name === playerName ? session.token : undefined
+  return requestSession('register', { playerName, password }, guestToken)
+}
+
+/** Log in as a registered player */
+export const login = (playerName: string, password: string) =>
+  requestSession('login', { playerName, password })
+
+/** Start a guest session for an unregistered name */
+export const loginAsGuest = (playerName: string) => requestSession('guest', { playerName })
+
+/**
+ * Get a token for the given player, reusing the stored session when it matches
+ * @param playerName - The name the player wants to play as
+ * @returns Promise<string> - The session token
+ * @throws Error if the name is registered by someone else
+ */
+export const ensureSession = async (playerName: string): Promise<string> => {
+  const session = getStoredSession()
+  if (session && session.player?.name === playerName) {
+    return session.token
+  }
+  return (await loginAsGuest(playerName)).token
+}
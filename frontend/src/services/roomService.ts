@@ -16,13 +16,10 @@
  */
 
 import { API_URL } from '../../config'
+import { ensureSession } from './authService'
 
 // Request/Response Interfaces
 
-export interface JoinRoomRequest {
-  playerName: string
-}
-
 export interface JoinRoomResponse {
   error: boolean
   message: string
@@ -36,7 +33,7 @@ export interface JoinRoomResponse {
 // Service Functions
 
 /**
- * Join an existing game room by roomId and playerName
+ * Join an existing game room by roomId, authenticated as playerName
  * @param roomId - The identifier of the room to join
  * @param playerName - The name of the player joining the room
  * @returns Promise<JoinRoomResponse>
@@ -46,12 +43,14 @@ export const joinRoom = async (
   roomId: string,
   playerName: string
 ): Promise<JoinRoomResponse> => {
-  const response = await fetch(`${API_URL}/api/rooms/join/${roomId}`, {
+  const token = await ensureSession(playerName)
+  const response = await fetch(`${API_URL}/api/v1/rooms/${roomId}/join`, {
     method: 'POST',
     headers: {
       'Content-Type': 'application/json',
+      Authorization: `Bearer ${token}`,
     },
-    body: JSON.stringify({ playerName }),
+    body: JSON.stringify({}),
   })
 
   const data: JoinRoomResponse = await response.json()
@@ -28,7 +28,7 @@ import { API_URL } from '../../config'
  * @throws Error if request fails
  */
 export const fetchGeneralStats = async () => {
-  const response = await fetch(`${API_URL}/api/stats/general`)
+  const response = await fetch(`${API_URL}/api/v1/stats/general`)
   if (!response.ok) {
     throw new Error('Failed to fetch general statistics')
   }
@@ -41,7 +41,7 @@ export const fetchGeneralStats = async () => {
  * @throws Error if request fails
  */
 export const fetchRanking = async () => {
-  const response = await fetch(`${API_URL}/api/stats/ranking`)
+  const response = await fetch(`${API_URL}/api/v1/stats/ranking`)
   if (!response.ok) {
     throw new Error('Failed to fetch ranking')
   }
@@ -55,7 +55,7 @@ export const fetchRanking = async () => {
  * @throws Error if request fails
  */
 export const fetchGameHistory = async (roomId: string) => {
-  const response = await fetch(`${API_URL}/api/rooms/history/${roomId}`)
+  const response = await fetch(`${API_URL}/api/v1/rooms/${roomId}/history`)
   if (!response.ok) {
     throw new Error('Failed to fetch game history')
   }
@@ -70,7 +70,7 @@ export const fetchGameHistory = async (roomId: string) => {
  */
 export const fetchPlayerStats = async (playerName: string) => {
   const response = await fetch(
-    `${API_URL}/api/stats/player?playerName=${encodeURIComponent(playerName)}`
+    `${API_URL}/api/v1/players/${encodeURIComponent(playerName)}/stats`
   )
   if (!response.ok) {
     throw new Error('Failed to fetch player statistics')
@@ -21,6 +21,7 @@
 import { create } from 'zustand'
 import { z } from 'zod'
 import { WS_URL } from '../../config'
+import { ensureSession } from '../services/authService'
 
 
 // Schemas
@@ -36,7 +37,7 @@ const PlayerSchema = z.object({
 
 // Game state validation schema
 const GameStateSchema = z.object({
-  ID: z.number(),
+  id: z.number(),
   roomID: z.string(),
   board: z.string().length(9), 
   currentTurn: z.enum(['X', 'O']),
@@ -75,7 +76,7 @@ interface GameStoreState {
 }
 
 interface GameStoreActions {
-  connect: (roomId: string, playerName: string) => void
+  connect: (roomId: string, playerName: string) => Promise<void>
   disconnect: () => void
   makeMove: (position: number) => void
   resetGame: () => void
@@ -120,15 +121,28 @@ export const useGameStore = create<GameStore>((set, get) => ({
   },
 
   /** Connect to game WebSocket and setup listeners */
-  connect: (roomId: string, playerName: string) => {
+  connect: async (roomId: string, playerName: string) => {
     set({ playerName })
+
+    let token: string
+    try {
+      token = await ensureSession(playerName)
+    } catch (err: any) {
+      set({ error: err.message || 'Could not start a session.', isValidationComplete: true })
+      return
+    }
+
     const currentSocket = get().socket
     if (currentSocket) {
       currentSocket.close(1000, 'Reconnecting with new session')
     }
 
-    const wsUrl = `${WS_URL}/join/${roomId}?playerName=${encodeURIComponent(playerName)}`
-    const ws = new WebSocket(wsUrl)
+    const wsUrl = `${WS_URL}/join/${roomId}?token=${encodeURIComponent(token)}`
+    const resumeToken = sessionStorage.getItem(`resume:${roomId}`)
+    const ws = new WebSocket(
+      resumeToken ? `${wsUrl}&resume=${encodeURIComponent(resumeToken)}` : wsUrl,
+      ['tictactoe.v2']
+    )
 
     ws.onopen = () => {
       set({
@@ -145,14 +159,37 @@ export const useGameStore = create<GameStore>((set, get) => ({
       })
     }
 
+    // Sequence number of the last server message, to detect lost messages
+    let lastSeq = 0
+    const requestResync = () => {
+      ws.send(JSON.stringify({ type: 'resync', payload: { lastSeq } }))
+    }
+
     // Handle server messages
     ws.onmessage = (event) => {
       try {
         const message = JSON.parse(event.data)
 
+        if (typeof message.seq === 'number') {
+          // Report the last message received before the gap
+          if (lastSeq > 0 && message.seq !== lastSeq + 1) requestResync()
+          lastSeq = message.seq
+        }
+
         switch (message.type) {
+          // Protocol framing: negotiated version and acknowledgements
+          case 'welcome':
+          case 'ack':
+            break
+
           case 'gameStateUpdate': {
             const parsedState = GameStateSchema.parse(message.gameState)
+
+            // Keep the seat's resume token so a page refresh reclaims the seat
+            if (message.resumeToken) {
+              sessionStorage.setItem(`resume:${roomId}`, message.resumeToken)
+            }
+
             const currentPlayerName = get().playerName
 
             const playerX = message.players?.X
@@ -195,6 +232,38 @@ export const useGameStore = create<GameStore>((set, get) => ({
             break
           }
 
+          // A single move, applied on the last snapshot
+          case 'moveApplied': {
+            const { gameState } = get()
+            if (!gameState || gameState.id !== message.gameId) {
+              requestResync()
+              break
+            }
+
+            // Moves already in the snapshot are skipped; a missing one asks for a new snapshot
+            const played = gameState.board.replace(/ /g, '').length
+            if (message.moveNumber <= played) break
+            if (message.moveNumber !== played + 1) {
+              requestResync()
+              break
+            }
+
+            const board =
+              gameState.board.substring(0, message.position) +
+              message.symbol +
+              gameState.board.substring(message.position + 1)
+
+            // A finished game is followed by a full gameStateUpdate
+            set({
+              gameState: {
+                ...gameState,
+                board,
+                currentTurn: message.nextTurn ?? gameState.currentTurn,
+              },
+            })
+            break
+          }
+
           case 'gameStartConfirmation': {
             set({
               showConfirmationModal: true,
@@ -203,40 +272,40 @@ export const useGameStore = create<GameStore>((set, get) => ({
             break
           }
 
-          case 'playAgainRequest': {
-            const { playerName: currentPlayerName } = get()
-            if (message.requestingPlayer !== currentPlayerName) {
-              set({
-                showPlayAgainConfirmation: true,
-                playAgainRequestingPlayer: message.requestingPlayer,
+          // Rematch negotiation state, tracked by the server
+          case 'rematchUpdate': {
+            const { playerName: currentPlayerName, isObserver } = get()
+            if (isObserver) break
+
+            if (message.state === 'offered') {
+              if (message.offeredByName !== currentPlayerName) {
+                set({
+                  showPlayAgainConfirmation: true,
+                  playAgainRequestingPlayer: message.offeredByName,
+                })
+                showWaitingModal(
+                  message.offeredByName,
+                  currentPlayerName || ''
+                )
+              }
+            } else if (message.state === 'accepted') {
+              const event = new CustomEvent('playAgainAccepted')
+              window.dispatchEvent(event)
+              handlePlayAgainAccept()
+            } else {
+              const rejectedBy =
+                message.state === 'expired'
+                  ? 'timeout'
+                  : message.declinedByName
+              const event = new CustomEvent('playAgainRejected', {
+                detail: { rejectedBy },
               })
-              showWaitingModal(
-                message.requestingPlayer,
-                currentPlayerName || ''
-              )
+              window.dispatchEvent(event)
+              handlePlayAgainReject(rejectedBy)
             }
             break
           }
 
-          case 'play_again_waiting':
-            break
-
-          case 'play_again_accepted': {
-            const event = new CustomEvent('playAgainAccepted')
-            window.dispatchEvent(event)
-            handlePlayAgainAccept()
-            break
-          }
-
-          case 'play_again_rejected': {
-            const event = new CustomEvent('playAgainRejected', {
-              detail: { rejectedBy: message.rejectedBy },
-            })
-            window.dispatchEvent(event)
-            handlePlayAgainReject(message.rejectedBy)
-            break
-          }
-
           case 'error': {
             if (
               message.message &&
@@ -288,10 +357,7 @@ export const useGameStore = create<GameStore>((set, get) => ({
     const { socket } = get()
     if (socket && socket.readyState === WebSocket.OPEN) {
       socket.send(
-        JSON.stringify({
-          type: 'play_again_response',
-          payload: { accepted },
-        })
+        JSON.stringify({ type: accepted ? 'rematchAccept' : 'rematchDecline' })
       )
     }
   },
@@ -328,9 +394,9 @@ export const useGameStore = create<GameStore>((set, get) => ({
     }
   },
 
-  /** Reset game state locally and notify server */
+  /** Reset game state locally; the server starts the next game once both players accept the rematch */
   resetGame: () => {
-    const { socket, gameState, isObserver } = get()
+    const { gameState, isObserver } = get()
     if (isObserver) return
 
     if (gameState) {
@@ -352,10 +418,6 @@ export const useGameStore = create<GameStore>((set, get) => ({
         playAgainRequestingPlayer: null,
       })
     }
-
-    if (socket && socket.readyState === WebSocket.OPEN && gameState) {
-      socket.send(JSON.stringify({ type: 'reset' }))
-    }
   },
 
   /** Disconnect from WebSocket and reset state */
@@ -388,17 +450,12 @@ export const useGameStore = create<GameStore>((set, get) => ({
   setShowPlayAgainConfirmation: (show) =>
     set({ showPlayAgainConfirmation: show }),
 
-  /** Initiate play again request */
-  setPlayAgainRequest: (playerName) => {
+  /** Offer a rematch; the opponent's answer arrives as a rematchUpdate */
+  setPlayAgainRequest: (_playerName) => {
     const { socket, setShowEndGameModal } = get()
     if (socket && socket.readyState === WebSocket.OPEN) {
       setShowEndGameModal(false)
-      socket.send(
-        JSON.stringify({
-          type: 'playAgainRequest',
-          payload: { requestingPlayer: playerName },
-        })
-      )
+      socket.send(JSON.stringify({ type: 'rematchOffer' }))
     }
   },
 